@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"my_portfolio/backup"
+	"my_portfolio/profit_calculator"
+	"my_portfolio/settings"
+	"my_portfolio/sqlite_storage"
+	"my_portfolio/token_extractor"
+	"my_portfolio/weight_tracker"
+)
+
+// appData 启动时准备好的配置、数据目录和各工具的存储
+type appData struct {
+	config     *settings.Config
+	dir        string
+	migrated   []string // 从旧位置迁移到数据目录的文件
+	dirErr     error
+	restored   []string // 本次启动时完成恢复的文件
+	restoreErr error
+	storages   *toolStorages
+	storageErr error
+	exported   []string // 切换回JSON文件后从SQLite数据库导出的数据说明
+	exportErr  error
+}
+
+// prepareAppData 加载配置，确定数据目录并迁移旧数据文件，完成待恢复的备份，再按配置打开各工具的存储。
+// 窗口模式和命令行模式共用，保证两者读写同一份数据。
+func prepareAppData(fallbackRoot string) *appData {
+	// 加载应用配置（失败时使用默认配置）
+	config, _ := settings.LoadConfig()
+	data := &appData{config: config}
+
+	// 确定数据目录，并迁移以前的数据目录、当前工作目录和可执行文件所在目录中的旧数据文件
+	data.dir, data.migrated, data.dirErr = settings.PrepareDataDir(config, fallbackRoot, dataFiles)
+	if data.dir == "" {
+		data.dir = "."
+	}
+
+	// 完成上次在设置中准备的备份恢复（必须在打开任何存储之前）
+	data.restored, data.restoreErr = backup.ApplyPendingRestore(filepath.Join(data.dir, backup.PendingRestoreDir))
+	if len(data.restored) > 0 {
+		data.config, _ = settings.LoadConfig()
+	}
+
+	// 切换回JSON文件后，先把SQLite数据库中的数据导出到JSON文件；
+	// 导出失败时数据仍在数据库中，本次继续使用SQLite，避免显示旧的JSON数据
+	if data.config.Backend() != settings.BackendSQLite {
+		data.exported, data.exportErr = exportSQLiteData(data.dir, data.config)
+		if data.exportErr != nil {
+			data.config.StorageBackend = settings.BackendSQLite
+		}
+	}
+
+	// 按配置的存储方式创建各工具的存储（失败时使用JSON文件）
+	data.storages, data.storageErr = openToolStorages(data.dir, data.config)
+	return data
+}
+
+// exportSQLiteData 将SQLite数据库中各工具的数据导出到JSON文件并清空数据库中的记录，
+// 之后再切换到SQLite时会重新导入。数据库不存在时直接返回；已加密的工具不使用数据库，不导出。
+func exportSQLiteData(dataDir string, config *settings.Config) ([]string, error) {
+	path := filepath.Join(dataDir, sqlite_storage.DefaultDBFile)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	db, err := sqlite_storage.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var exported []string
+	if !config.IsEncrypted(weight_tracker.ToolID) {
+		weightStorage, err := weight_tracker.NewSQLiteStorage(db)
+		if err != nil {
+			return exported, err
+		}
+		count, err := weightStorage.ExportJSON(filepath.Join(dataDir, weight_tracker.DefaultDataFile))
+		if err != nil {
+			return exported, fmt.Errorf("导出体重记录失败: %w", err)
+		}
+		if count > 0 {
+			exported = append(exported, fmt.Sprintf("体重记录 %d 条", count))
+		}
+	}
+
+	if !config.IsEncrypted(profit_calculator.ToolID) {
+		profitStorage, err := profit_calculator.NewSQLiteStorage(db)
+		if err != nil {
+			return exported, err
+		}
+		count, err := profitStorage.ExportJSON(filepath.Join(dataDir, profit_calculator.DefaultDataFile))
+		if err != nil {
+			return exported, fmt.Errorf("导出收益数据失败: %w", err)
+		}
+		if count > 0 {
+			exported = append(exported, fmt.Sprintf("收益数据 %d 条", count))
+		}
+	}
+
+	tokenStorage, err := token_extractor.NewSQLiteStorage(db)
+	if err != nil {
+		return exported, err
+	}
+	count, err := tokenStorage.ExportJSON(filepath.Join(dataDir, token_extractor.DefaultHistoryFile))
+	if err != nil {
+		return exported, fmt.Errorf("导出Token历史失败: %w", err)
+	}
+	if count > 0 {
+		exported = append(exported, fmt.Sprintf("Token历史 %d 条", count))
+	}
+
+	return exported, nil
+}
+
+// toolStorages 按配置的存储后端创建的各工具存储，为nil时工具使用默认的JSON文件
+type toolStorages struct {
+	db       *sql.DB
+	weight   weight_tracker.Storage
+	profit   profit_calculator.Storage
+	tokens   token_extractor.Storage
+	imported []string // 本次从JSON文件导入的数据说明
+}
+
+// openToolStorages 按配置创建各工具的存储。
+// 使用SQLite时会执行数据库迁移，并在数据库为空时导入现有的JSON数据；已加密的工具仍使用加密JSON文件。
+// 出错时所有工具都回退到JSON文件，避免数据分散在两种存储中。
+func openToolStorages(dataDir string, config *settings.Config) (*toolStorages, error) {
+	if config.Backend() != settings.BackendSQLite {
+		return &toolStorages{}, nil
+	}
+
+	db, err := sqlite_storage.Open(filepath.Join(dataDir, sqlite_storage.DefaultDBFile))
+	if err != nil {
+		return &toolStorages{}, err
+	}
+
+	storages, err := openSQLiteStorages(db, dataDir, config)
+	if err != nil {
+		db.Close()
+		return &toolStorages{}, err
+	}
+	return storages, nil
+}
+
+// openSQLiteStorages 在数据库上创建各工具的存储并导入JSON数据
+func openSQLiteStorages(db *sql.DB, dataDir string, config *settings.Config) (*toolStorages, error) {
+	storages := &toolStorages{db: db}
+
+	if !config.IsEncrypted(weight_tracker.ToolID) {
+		weightStorage, err := weight_tracker.NewSQLiteStorage(db)
+		if err != nil {
+			return nil, err
+		}
+		count, err := weightStorage.ImportJSON(filepath.Join(dataDir, weight_tracker.DefaultDataFile))
+		if err != nil {
+			return nil, fmt.Errorf("导入体重记录失败: %w", err)
+		}
+		if count > 0 {
+			storages.imported = append(storages.imported, fmt.Sprintf("体重记录 %d 条", count))
+		}
+		storages.weight = weightStorage
+	}
+
+	if !config.IsEncrypted(profit_calculator.ToolID) {
+		profitStorage, err := profit_calculator.NewSQLiteStorage(db)
+		if err != nil {
+			return nil, err
+		}
+		count, err := profitStorage.ImportJSON(filepath.Join(dataDir, profit_calculator.DefaultDataFile))
+		if err != nil {
+			return nil, fmt.Errorf("导入收益数据失败: %w", err)
+		}
+		if count > 0 {
+			storages.imported = append(storages.imported, fmt.Sprintf("收益数据 %d 条", count))
+		}
+		storages.profit = profitStorage
+	}
+
+	tokenStorage, err := token_extractor.NewSQLiteStorage(db)
+	if err != nil {
+		return nil, err
+	}
+	count, err := tokenStorage.ImportJSON(filepath.Join(dataDir, token_extractor.DefaultHistoryFile))
+	if err != nil {
+		return nil, fmt.Errorf("导入Token历史失败: %w", err)
+	}
+	if count > 0 {
+		storages.imported = append(storages.imported, fmt.Sprintf("Token历史 %d 条", count))
+	}
+	storages.tokens = tokenStorage
+
+	return storages, nil
+}
+
+// registerBackupSources 注册完整备份包含的数据来源
+func registerBackupSources(dataDir string, storages *toolStorages) {
+	backup.RegisterSource(backup.Source{
+		ID:    weight_tracker.ToolID,
+		Title: "体重记录",
+		Files: []string{filepath.Join(dataDir, weight_tracker.DefaultDataFile)},
+	})
+
+	profitPath := filepath.Join(dataDir, profit_calculator.DefaultDataFile)
+	backup.RegisterSource(backup.Source{
+		ID:    profit_calculator.ToolID,
+		Title: "收益计算",
+		Files: []string{profitPath, profit_calculator.AuditPathFor(profitPath)},
+	})
+
+	backup.RegisterSource(backup.Source{
+		ID:    token_extractor.ToolID,
+		Title: "Token提取历史",
+		Files: []string{
+			filepath.Join(dataDir, token_extractor.DefaultHistoryFile),
+			filepath.Join(dataDir, token_extractor.DefaultProfilesFile),
+			filepath.Join(dataDir, token_extractor.DefaultVaultFile),
+		},
+	})
+
+	// SQLite 数据库包含所有工具的数据，只能整体恢复
+	backup.RegisterSource(backup.Source{
+		ID:    "database",
+		Title: "SQLite 数据库",
+		Files: []string{filepath.Join(dataDir, sqlite_storage.DefaultDBFile)},
+		Prepare: func() error {
+			if storages.db == nil {
+				return nil
+			}
+			// 将 WAL 日志写回主文件，保证备份的数据库文件完整
+			_, err := storages.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+			return err
+		},
+	})
+
+	if source, err := settings.BackupSource(); err == nil {
+		backup.RegisterSource(source)
+	}
+}
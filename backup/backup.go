@@ -0,0 +1,316 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+const (
+	formatName   = "my_portfolio-backup"
+	version      = 1
+	manifestName = "manifest.json"
+
+	maxManifestSize = 1 << 20 // 清单文件的大小上限
+)
+
+var (
+	ErrNotBackup          = errors.New("不是有效的备份文件")
+	ErrUnsupportedVersion = errors.New("备份文件版本高于当前程序支持的版本，请升级应用")
+
+	errEntryTooLarge = errors.New("文件大小超过清单记录")
+)
+
+// Source 备份的数据来源（一个工具或一类设置）
+type Source struct {
+	ID    string   // 来源标识，例如工具ID
+	Title string   // 显示名称
+	Files []string // 需要备份的文件（绝对路径），不存在的文件会被跳过
+
+	// Prepare 在读取文件前调用（可选），例如让数据库把日志写回主文件
+	Prepare func() error
+
+	// Transform 恢复前处理文件内容（可选），例如保留当前机器特有的设置
+	Transform func(name string, data []byte) ([]byte, error)
+}
+
+var sources []Source
+
+// RegisterSource 注册备份来源，注册后会包含在完整备份中
+func RegisterSource(source Source) {
+	sources = append(sources, source)
+}
+
+// Sources 返回已注册的备份来源
+func Sources() []Source {
+	return sources
+}
+
+// findSource 按标识查找备份来源
+func findSource(id string) (Source, bool) {
+	for _, source := range sources {
+		if source.ID == id {
+			return source, true
+		}
+	}
+	return Source{}, false
+}
+
+// Manifest 备份清单
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry 备份中的单个文件
+type Entry struct {
+	Source string `json:"source"` // 来源标识
+	Title  string `json:"title"`  // 来源显示名称
+	Name   string `json:"name"`   // 文件名
+	Path   string `json:"path"`   // 压缩包内的路径
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// SourceIDs 返回清单中包含的来源（按出现顺序）
+func (m *Manifest) SourceIDs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, entry := range m.Entries {
+		if !seen[entry.Source] {
+			seen[entry.Source] = true
+			ids = append(ids, entry.Source)
+		}
+	}
+	return ids
+}
+
+// EntriesFor 返回某个来源的所有文件
+func (m *Manifest) EntriesFor(sourceID string) []Entry {
+	var entries []Entry
+	for _, entry := range m.Entries {
+		if entry.Source == sourceID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ArchiveName 返回按时间命名的备份文件名
+func ArchiveName(t time.Time) string {
+	return "my_portfolio-backup-" + t.Format("20060102-150405") + ".zip"
+}
+
+// WriteArchive 将所有已注册来源的文件打包写入 w，返回备份清单
+func WriteArchive(w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:    formatName,
+		Version:   version,
+		CreatedAt: time.Now(),
+		Entries:   []Entry{},
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, source := range sources {
+		if source.Prepare != nil {
+			if err := source.Prepare(); err != nil {
+				return nil, fmt.Errorf("准备备份 %s 失败: %w", source.Title, err)
+			}
+		}
+
+		for _, file := range source.Files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("读取 %s 失败: %w", file, err)
+			}
+
+			entry := Entry{
+				Source: source.ID,
+				Title:  source.Title,
+				Name:   filepath.Base(file),
+				Path:   path.Join(source.ID, filepath.Base(file)),
+				Size:   int64(len(data)),
+				SHA256: checksum(data),
+			}
+			if err := writeZipFile(zw, entry.Path, data, manifest.CreatedAt); err != nil {
+				return nil, err
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipFile(zw, manifestName, manifestData, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// CreateArchive 在指定路径创建备份文件（先写临时文件，完成后再重命名）
+func CreateArchive(dest string) (*Manifest, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := WriteArchive(tmp)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeZipFile 向压缩包写入一个文件
+func writeZipFile(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Archive 已校验的备份文件
+type Archive struct {
+	Manifest *Manifest
+	files    map[string][]byte // 压缩包内路径 -> 内容
+}
+
+// OpenArchive 打开并校验磁盘上的备份文件
+func OpenArchive(name string) (*Archive, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ReadArchive(f, info.Size())
+}
+
+// ReadArchive 读取并校验备份文件：检查格式、版本以及每个文件的大小和校验和。
+// 只读取清单中列出的文件，每个文件最多读取清单记录的大小，避免损坏或恶意的压缩包占满内存
+func ReadArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrNotBackup
+	}
+
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	manifestFile, ok := entries[manifestName]
+	if !ok {
+		return nil, ErrNotBackup
+	}
+	manifestData, err := readZipFile(manifestFile, maxManifestSize)
+	if err != nil {
+		return nil, ErrNotBackup
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil || manifest.Format != formatName {
+		return nil, ErrNotBackup
+	}
+	if manifest.Version > version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range manifest.Entries {
+		f, ok := entries[entry.Path]
+		if !ok {
+			return nil, fmt.Errorf("备份不完整，缺少文件 %s", entry.Path)
+		}
+		content, err := readZipFile(f, entry.Size)
+		if errors.Is(err, errEntryTooLarge) {
+			return nil, fmt.Errorf("备份文件 %s 的校验和不匹配，备份可能已损坏", entry.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", entry.Path, err)
+		}
+		if int64(len(content)) != entry.Size || checksum(content) != entry.SHA256 {
+			return nil, fmt.Errorf("备份文件 %s 的校验和不匹配，备份可能已损坏", entry.Path)
+		}
+		files[entry.Path] = content
+	}
+
+	return &Archive{
+		Manifest: &manifest,
+		files:    files,
+	}, nil
+}
+
+// readZipFile 读取压缩包中的单个文件，内容超过 limit 字节时返回 errEntryTooLarge
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	if limit < 0 || f.UncompressedSize64 > uint64(limit) {
+		return nil, errEntryTooLarge
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errEntryTooLarge
+	}
+	return data, nil
+}
+
+// checksum 计算 SHA-256 校验和
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
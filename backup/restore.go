@@ -0,0 +1,157 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"my_portfolio/safe_file"
+)
+
+// PendingRestoreDir 数据目录下保存待恢复文件的子目录
+//
+// 运行中的工具会在保存时覆盖恢复的文件，因此恢复分两步：
+// 先把文件解压到该目录，下次启动时在打开任何存储之前再替换到目标位置。
+const PendingRestoreDir = "pending_restore"
+
+const pendingFile = "pending.json"
+
+// pendingEntry 待恢复的文件
+type pendingEntry struct {
+	Staged string `json:"staged"` // 暂存文件名
+	Target string `json:"target"` // 恢复的目标路径
+	Title  string `json:"title"`
+}
+
+// RestorePlan 恢复时将被替换的文件
+type RestorePlan struct {
+	Entry  Entry
+	Target string // 目标路径，为空表示当前程序没有对应的数据来源
+}
+
+// Plan 返回恢复指定来源时将被替换的文件
+func (a *Archive) Plan(sourceIDs []string) []RestorePlan {
+	var plans []RestorePlan
+	for _, id := range sourceIDs {
+		source, ok := findSource(id)
+		for _, entry := range a.Manifest.EntriesFor(id) {
+			plan := RestorePlan{Entry: entry}
+			if ok {
+				plan.Target = targetFor(source, entry.Name)
+			}
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+// targetFor 查找来源中与备份文件同名的目标文件
+func targetFor(source Source, name string) string {
+	for _, file := range source.Files {
+		if filepath.Base(file) == name {
+			return file
+		}
+	}
+	return ""
+}
+
+// StageRestore 将指定来源的文件解压到暂存目录，下次启动时由 ApplyPendingRestore 完成恢复。
+// 返回将被替换的目标文件。
+func (a *Archive) StageRestore(sourceIDs []string, stagingDir string) ([]string, error) {
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return nil, err
+	}
+
+	var pending []pendingEntry
+	var targets []string
+	for i, plan := range a.Plan(sourceIDs) {
+		if plan.Target == "" {
+			return nil, fmt.Errorf("当前程序无法恢复 %s 的文件 %s", plan.Entry.Title, plan.Entry.Name)
+		}
+
+		data := a.files[plan.Entry.Path]
+		if source, _ := findSource(plan.Entry.Source); source.Transform != nil {
+			var err error
+			if data, err = source.Transform(plan.Entry.Name, data); err != nil {
+				return nil, fmt.Errorf("处理 %s 失败: %w", plan.Entry.Name, err)
+			}
+		}
+
+		staged := fmt.Sprintf("%d-%s", i, plan.Entry.Name)
+		if err := safe_file.WriteFileWithBackups(filepath.Join(stagingDir, staged), data, 0600, 0); err != nil {
+			return nil, err
+		}
+
+		pending = append(pending, pendingEntry{
+			Staged: staged,
+			Target: plan.Target,
+			Title:  plan.Entry.Title,
+		})
+		targets = append(targets, plan.Target)
+	}
+
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := safe_file.WriteFileWithBackups(filepath.Join(stagingDir, pendingFile), data, 0600, 0); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// ApplyPendingRestore 完成上次暂存的恢复，返回已恢复的文件。没有待恢复的文件时直接返回。
+// 被替换的文件会保留为 .bak1 历史版本。
+func ApplyPendingRestore(stagingDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(stagingDir, pendingFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pending []pendingEntry
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, err
+	}
+
+	var restored []string
+	for _, entry := range pending {
+		content, err := os.ReadFile(filepath.Join(stagingDir, entry.Staged))
+		if err != nil {
+			return restored, err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(entry.Target), 0700); err != nil {
+			return restored, err
+		}
+		if err := safe_file.WriteFile(entry.Target, content, 0600); err != nil {
+			return restored, err
+		}
+
+		// SQLite 的日志文件属于被替换的旧数据库，必须一并删除
+		os.Remove(entry.Target + "-wal")
+		os.Remove(entry.Target + "-shm")
+
+		restored = append(restored, entry.Target)
+	}
+
+	return restored, os.RemoveAll(stagingDir)
+}
+
+// CancelPendingRestore 取消尚未完成的恢复
+func CancelPendingRestore(stagingDir string) error {
+	return os.RemoveAll(stagingDir)
+}
+
+// HasPendingRestore 是否有等待下次启动完成的恢复
+func HasPendingRestore(stagingDir string) bool {
+	_, err := os.Stat(filepath.Join(stagingDir, pendingFile))
+	return err == nil
+}
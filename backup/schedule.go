@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkInterval 自动备份检查是否到期的间隔
+const checkInterval = 10 * time.Minute
+
+// Schedule 自动备份设置
+type Schedule struct {
+	Dir      string        // 备份目录，为空表示关闭自动备份
+	Interval time.Duration // 备份间隔，0表示关闭自动备份
+	Keep     int           // 保留的备份数量，0表示全部保留
+	Last     time.Time     // 上次自动备份的时间
+}
+
+// Enabled 是否启用了自动备份
+func (s Schedule) Enabled() bool {
+	return s.Dir != "" && s.Interval > 0
+}
+
+// Due 当前是否应该执行自动备份
+func (s Schedule) Due(now time.Time) bool {
+	return s.Enabled() && now.Sub(s.Last) >= s.Interval
+}
+
+// RunScheduler 定期检查并执行自动备份，直到 ctx 被取消。
+// 每次检查都会调用 schedule 获取最新设置，备份完成或失败后调用 onBackup。
+func RunScheduler(ctx context.Context, schedule func() Schedule, onBackup func(path string, err error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if s := schedule(); s.Due(time.Now()) {
+			path, err := RunScheduled(s)
+			onBackup(path, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunScheduled 在自动备份目录中创建备份并清理超出保留数量的旧备份
+func RunScheduled(s Schedule) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.Dir, ArchiveName(time.Now()))
+	if _, err := CreateArchive(path); err != nil {
+		return "", err
+	}
+
+	return path, Prune(s.Dir, s.Keep)
+}
+
+// Prune 只保留目录中最新的 keep 个备份文件，keep 为0时不删除
+func Prune(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, "my_portfolio-backup-") && strings.HasSuffix(name, ".zip") {
+			archives = append(archives, name)
+		}
+	}
+
+	// 文件名中的时间戳按字典序即为时间顺序
+	sort.Sort(sort.Reverse(sort.StringSlice(archives)))
+	for _, name := range archives[min(keep, len(archives)):] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
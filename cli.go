@@ -0,0 +1,741 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"my_portfolio/profit_calculator"
+	"my_portfolio/safe_file"
+	"my_portfolio/token_extractor"
+	"my_portfolio/weight_tracker"
+)
+
+// 命令行模式从环境变量读取的密码
+const (
+	passphraseEnv = "TOOLBOX_PASSPHRASE" // 加密数据文件的密码
+	passwordEnv   = "TOOLBOX_PASSWORD"   // Token提取使用的登录密码
+)
+
+const cliUsage = `用法: toolbox <命令> [参数]
+
+体重记录:
+  toolbox weight add [--date 时间] <体重kg>     添加体重记录，时间格式为 YYYY-MM-DD 或 "YYYY-MM-DD HH:MM"
+  toolbox weight list [--limit N] [--json]      列出体重记录（最新的在前）
+  toolbox weight stats [--json]                 显示体重统计
+
+收益计算:
+  toolbox profit add [--date YYYY-MM-DD] --amount 金额 [--json]   添加收益记录并按投资比例分配
+  toolbox profit list [--limit N] [--json]                       列出收益记录（最新的在前）
+  toolbox profit stats [--json]                                  显示整体和各投资者的统计
+
+Token提取:
+  toolbox tokens extract [--profile 站点] [--url 地址] --username 账号 [--password-stdin] [--timeout 2m] [--no-history] [--json]
+                                                登录并提取关键Token，默认使用图形界面中选中的站点配置，
+                                                密码从标准输入或环境变量 ` + passwordEnv + ` 读取，
+                                                都没有提供时使用图形界面中保存的账号；
+                                                进度输出到标准错误，按 Ctrl+C 取消
+  toolbox tokens history [--limit N] [--json]   列出提取历史
+
+已加密的数据需要通过环境变量 ` + passphraseEnv + ` 提供密码。
+不带参数启动时打开图形界面。
+`
+
+// errUsage 命令参数错误，退出码为 2
+type errUsage string
+
+func (e errUsage) Error() string {
+	return string(e)
+}
+
+// cliEnv 命令行命令的运行环境
+type cliEnv struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	data   *appData
+}
+
+// cliCommands 命令行模式的顶层命令
+var cliCommands = map[string]map[string]func(env *cliEnv, args []string) error{
+	"weight": {
+		"add":   runWeightAdd,
+		"list":  runWeightList,
+		"stats": runWeightStats,
+	},
+	"profit": {
+		"add":   runProfitAdd,
+		"list":  runProfitList,
+		"stats": runProfitStats,
+	},
+	"tokens": {
+		"extract": runTokensExtract,
+		"history": runTokensHistory,
+	},
+}
+
+// isCLI 判断启动参数是否要求以命令行模式运行
+func isCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return true
+	}
+	_, ok := cliCommands[args[0]]
+	return ok
+}
+
+// runCLI 以命令行模式运行，不创建窗口；返回进程退出码
+func runCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 || cliCommands[args[0]] == nil {
+		fmt.Fprint(stdout, cliUsage)
+		if len(args) > 0 && cliCommands[args[0]] == nil {
+			return 0
+		}
+		return 2
+	}
+
+	run, ok := cliCommands[args[0]][args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "未知命令: %s %s\n\n%s", args[0], args[1], cliUsage)
+		return 2
+	}
+
+	// 与图形界面使用同一个数据目录和存储
+	data := prepareAppData("")
+	if data.storages.db != nil {
+		defer data.storages.db.Close()
+	}
+	if data.dirErr != nil {
+		fmt.Fprintln(stderr, "警告: 数据目录准备失败:", data.dirErr)
+	}
+	if data.restoreErr != nil {
+		fmt.Fprintln(stderr, "警告: 从备份恢复失败:", data.restoreErr)
+	}
+	if data.exportErr != nil {
+		fmt.Fprintln(stderr, "警告: 从 SQLite 数据库导出到 JSON 文件失败，本次继续使用 SQLite 数据库:", data.exportErr)
+	}
+	if data.storageErr != nil {
+		fmt.Fprintln(stderr, "警告: 打开 SQLite 数据库失败，本次使用 JSON 文件存储:", data.storageErr)
+	}
+
+	env := &cliEnv{stdin: stdin, stdout: stdout, stderr: stderr, data: data}
+	if err := run(env, args[2:]); err != nil {
+		fmt.Fprintln(stderr, "错误:", err)
+		var usage errUsage
+		if errors.As(err, &usage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// parseFlags 解析参数，允许参数和位置参数混合出现，返回位置参数
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// newFlagSet 创建子命令的参数集合，错误信息输出到标准错误
+func (env *cliEnv) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("toolbox "+name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	return fs
+}
+
+// writeJSON 以缩进的 JSON 格式输出
+func (env *cliEnv) writeJSON(v any) error {
+	encoder := json.NewEncoder(env.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// warnRecovered 数据已从备份恢复时输出提示，其他错误原样返回
+func (env *cliEnv) warnRecovered(err error) error {
+	var recovered *safe_file.RecoveredError
+	if errors.As(err, &recovered) {
+		fmt.Fprintln(env.stderr, "警告:", recovered)
+		return nil
+	}
+	return err
+}
+
+// passphrase 返回加密数据的密码
+func passphrase(title string) (string, error) {
+	value := os.Getenv(passphraseEnv)
+	if value == "" {
+		return "", fmt.Errorf("%s已加密，请通过环境变量 %s 提供密码", title, passphraseEnv)
+	}
+	return value, nil
+}
+
+// weightStorage 按配置返回体重记录的存储
+func (env *cliEnv) weightStorage() (weight_tracker.Storage, error) {
+	path := filepath.Join(env.data.dir, weight_tracker.DefaultDataFile)
+	if env.data.config.IsEncrypted(weight_tracker.ToolID) {
+		pass, err := passphrase("体重记录")
+		if err != nil {
+			return nil, err
+		}
+		storage := weight_tracker.NewEncryptedStorage(path)
+		if err := storage.Unlock(pass); err != nil {
+			return nil, err
+		}
+		return storage, nil
+	}
+
+	if env.data.storages.weight != nil {
+		return env.data.storages.weight, nil
+	}
+	return weight_tracker.NewJSONStorage(path), nil
+}
+
+// loadWeightRecords 加载体重记录
+func (env *cliEnv) loadWeightRecords() (weight_tracker.Storage, []weight_tracker.WeightRecord, error) {
+	storage, err := env.weightStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := storage.Load()
+	if err := env.warnRecovered(err); err != nil {
+		return nil, nil, fmt.Errorf("加载体重记录失败: %w", err)
+	}
+	return storage, records, nil
+}
+
+// parseRecordTime 解析体重记录的时间，只有日期时使用当天的当前时刻
+func parseRecordTime(text string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02 15:04", text, time.Local); err == nil {
+		return date, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return time.Time{}, errUsage("时间格式无效，请使用 YYYY-MM-DD 或 \"YYYY-MM-DD HH:MM\"")
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local), nil
+}
+
+// runWeightAdd 添加体重记录
+func runWeightAdd(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("weight add")
+	dateText := fs.String("date", "", "记录时间，默认为当前时间")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage("用法: toolbox weight add [--date 时间] <体重kg>")
+	}
+
+	weight, err := strconv.ParseFloat(positional[0], 64)
+	if err != nil {
+		return errUsage("请输入有效的数字")
+	}
+	if err := weight_tracker.ValidateWeight(weight); err != nil {
+		return err
+	}
+
+	record := *weight_tracker.NewWeightRecord(weight, nil)
+	if *dateText != "" {
+		if record.Date, err = parseRecordTime(*dateText, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	storage, records, err := env.loadWeightRecords()
+	if err != nil {
+		return err
+	}
+	records = weight_tracker.InsertRecord(records, record)
+	if err := storage.Save(records); err != nil {
+		return fmt.Errorf("保存失败: %w", err)
+	}
+
+	// 返回计算过变化量的记录
+	for _, r := range records {
+		if r.ID == record.ID {
+			record = r
+		}
+	}
+
+	if *asJSON {
+		return env.writeJSON(record)
+	}
+	fmt.Fprintf(env.stdout, "体重记录已添加：%s  %.1f kg  %s\n", record.FormatDate(), record.Weight, record.FormatChange())
+	return nil
+}
+
+// runWeightList 列出体重记录
+func runWeightList(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("weight list")
+	limit := fs.Int("limit", 0, "最多显示的记录数，0 表示全部")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, records, err := env.loadWeightRecords()
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(records) > *limit {
+		records = records[:*limit]
+	}
+
+	if *asJSON {
+		return env.writeJSON(records)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(env.stdout, "暂无记录")
+		return nil
+	}
+	for _, record := range records {
+		fmt.Fprintf(env.stdout, "%s  %6.1f kg  %s\n", record.FormatDate(), record.Weight, record.FormatChange())
+	}
+	return nil
+}
+
+// runWeightStats 显示体重统计
+func runWeightStats(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("weight stats")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, records, err := env.loadWeightRecords()
+	if err != nil {
+		return err
+	}
+	stats := weight_tracker.CalculateStats(records)
+
+	if *asJSON {
+		return env.writeJSON(stats)
+	}
+	if stats.TotalRecords == 0 {
+		fmt.Fprintln(env.stdout, "暂无记录")
+		return nil
+	}
+	fmt.Fprintf(env.stdout, "记录数：%d\n当前体重：%.1f kg\n总变化：%+.1f kg\n最高：%.1f kg\n最低：%.1f kg\n",
+		stats.TotalRecords, stats.CurrentWeight, stats.TotalChange, stats.HighestWeight, stats.LowestWeight)
+	return nil
+}
+
+// profitStorage 按配置返回收益数据的存储和审计日志
+func (env *cliEnv) profitStorage() (profit_calculator.Storage, *profit_calculator.History, error) {
+	path := filepath.Join(env.data.dir, profit_calculator.DefaultDataFile)
+	auditPath := profit_calculator.AuditPathFor(path)
+
+	if env.data.config.IsEncrypted(profit_calculator.ToolID) {
+		pass, err := passphrase("收益数据")
+		if err != nil {
+			return nil, nil, err
+		}
+		storage := profit_calculator.NewEncryptedStorage(path)
+		if err := storage.Unlock(pass); err != nil {
+			return nil, nil, err
+		}
+		log := profit_calculator.NewEncryptedAuditLog(auditPath)
+		if err := log.Unlock(pass); err != nil {
+			return nil, nil, err
+		}
+		// 审计日志加载失败时仍可继续记录新的操作
+		history, _ := profit_calculator.NewHistory(log)
+		return storage, history, nil
+	}
+
+	var storage profit_calculator.Storage = profit_calculator.NewJSONStorage(path)
+	if env.data.storages.profit != nil {
+		storage = env.data.storages.profit
+	}
+	history, _ := profit_calculator.NewHistory(profit_calculator.NewJSONLAuditLog(auditPath))
+	return storage, history, nil
+}
+
+// loadProfitData 加载收益数据
+func (env *cliEnv) loadProfitData() (profit_calculator.Storage, *profit_calculator.History, *profit_calculator.ProfitCalculatorData, error) {
+	storage, history, err := env.profitStorage()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	data, err := storage.Load()
+	if err := env.warnRecovered(err); err != nil {
+		return nil, nil, nil, fmt.Errorf("加载收益数据失败: %w", err)
+	}
+	return storage, history, data, nil
+}
+
+// printProfit 输出一条收益记录及其分配
+func (env *cliEnv) printProfit(profit profit_calculator.ProfitDetail) {
+	fmt.Fprintf(env.stdout, "%s  ¥%.2f\n", profit.Date, profit.TotalProfit)
+	for _, distribution := range profit.Distributions {
+		name := distribution.InvestorName
+		if name == "" {
+			name = "（已删除的投资者）"
+		}
+		fmt.Fprintf(env.stdout, "    %s  ¥%.2f\n", name, distribution.Amount)
+	}
+}
+
+// runProfitAdd 添加收益记录
+func runProfitAdd(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("profit add")
+	dateText := fs.String("date", time.Now().Format("2006-01-02"), "收益日期（YYYY-MM-DD）")
+	amountText := fs.String("amount", "", "总收益金额")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *amountText == "" {
+		return errUsage("用法: toolbox profit add [--date YYYY-MM-DD] --amount 金额")
+	}
+
+	date, err := time.Parse("2006-01-02", *dateText)
+	if err != nil {
+		return errUsage("日期格式无效，请使用 YYYY-MM-DD 格式")
+	}
+	amount, err := strconv.ParseFloat(*amountText, 64)
+	if err != nil {
+		return errUsage("请输入有效的金额")
+	}
+
+	storage, history, data, err := env.loadProfitData()
+	if err != nil {
+		return err
+	}
+	if err := profit_calculator.ValidateProfit(data, date, amount, time.Now()); err != nil {
+		return err
+	}
+
+	before := profit_calculator.CloneData(data)
+	profit := profit_calculator.NewMonthlyProfit(date, amount, profit_calculator.DistributeProfit(amount, data.Investors))
+	data.MonthlyProfits = append(data.MonthlyProfits, *profit)
+
+	if err := storage.Save(data); err != nil {
+		return fmt.Errorf("保存失败: %w", err)
+	}
+	if history != nil {
+		summary := fmt.Sprintf("添加 %s 的收益记录（¥%.2f）", date.Format("2006-01-02"), amount)
+		if err := history.Record(profit_calculator.ActionAddProfit, summary, before, data); err != nil {
+			fmt.Fprintln(env.stderr, "警告: 写入审计日志失败:", err)
+		}
+	}
+
+	out := profit_calculator.NewProfitDetail(*profit, data.Investors)
+	if *asJSON {
+		return env.writeJSON(out)
+	}
+	fmt.Fprintln(env.stdout, "收益记录已添加：")
+	env.printProfit(out)
+	return nil
+}
+
+// runProfitList 列出收益记录
+func runProfitList(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("profit list")
+	limit := fs.Int("limit", 0, "最多显示的记录数，0 表示全部")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, _, data, err := env.loadProfitData()
+	if err != nil {
+		return err
+	}
+
+	profits := append([]profit_calculator.MonthlyProfit(nil), data.MonthlyProfits...)
+	sort.SliceStable(profits, func(i, j int) bool {
+		return profits[i].Date.After(profits[j].Date)
+	})
+	if *limit > 0 && len(profits) > *limit {
+		profits = profits[:*limit]
+	}
+
+	out := make([]profit_calculator.ProfitDetail, len(profits))
+	for i, profit := range profits {
+		out[i] = profit_calculator.NewProfitDetail(profit, data.Investors)
+	}
+
+	if *asJSON {
+		return env.writeJSON(out)
+	}
+	if len(out) == 0 {
+		fmt.Fprintln(env.stdout, "暂无收益记录")
+		return nil
+	}
+	for _, profit := range out {
+		env.printProfit(profit)
+	}
+	return nil
+}
+
+// runProfitStats 显示收益统计
+func runProfitStats(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("profit stats")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, _, data, err := env.loadProfitData()
+	if err != nil {
+		return err
+	}
+
+	summary := profit_calculator.CalculateStatsSummary(data)
+	if *asJSON {
+		return env.writeJSON(summary)
+	}
+
+	fmt.Fprintf(env.stdout, "总投资：¥%.2f\n累计收益：¥%.2f\n投资者：%d 位\n收益记录：%d 条\n",
+		summary.TotalInvestment, summary.TotalProfit, summary.InvestorCount, summary.ProfitRecordCount)
+	for _, investor := range summary.Investors {
+		fmt.Fprintf(env.stdout, "\n%s\n    投资 ¥%.2f（%.2f%%）  累计收益 ¥%.2f  最终金额 ¥%.2f\n",
+			investor.InvestorName, investor.InvestmentAmount, investor.InvestmentRatio*100, investor.TotalProfit, investor.FinalAmount)
+	}
+	return nil
+}
+
+// tokenStorage 按配置返回Token历史的存储
+func (env *cliEnv) tokenStorage() token_extractor.Storage {
+	if env.data.storages.tokens != nil {
+		return env.data.storages.tokens
+	}
+	return token_extractor.NewJSONStorage(filepath.Join(env.data.dir, token_extractor.DefaultHistoryFile))
+}
+
+// tokenProfile 按名称返回站点配置，名称为空时返回选中的配置
+func (env *cliEnv) tokenProfile(name string) (*token_extractor.ProfileSet, *token_extractor.SiteProfile, error) {
+	profiles, err := token_extractor.NewProfileStorage(filepath.Join(env.data.dir, token_extractor.DefaultProfilesFile)).Load()
+	if err := env.warnRecovered(err); err != nil {
+		return nil, nil, fmt.Errorf("读取站点配置失败: %w", err)
+	}
+
+	profile := profiles.Current()
+	if name != "" {
+		if profile = profiles.FindByName(name); profile == nil {
+			return nil, nil, errUsage("未找到站点配置: " + name)
+		}
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("站点配置 %s 无效: %w", profile.Name, err)
+	}
+	return profiles, profile, nil
+}
+
+// savedPassword 读取图形界面中为站点配置保存的账号密码；保存在加密文件中时使用环境变量中的主密码解锁
+func (env *cliEnv) savedPassword(profiles *token_extractor.ProfileSet, profile *token_extractor.SiteProfile, username string) (string, error) {
+	account, ok := profiles.FindAccount(profile.ID, username)
+	if !ok {
+		return "", errUsage("请通过 --password-stdin 或环境变量 " + passwordEnv + " 提供密码，或先在图形界面中保存该账号")
+	}
+
+	vault := token_extractor.NewVault(filepath.Join(env.data.dir, token_extractor.DefaultVaultFile))
+	if vault.NeedsUnlock(account.Backend) {
+		master, err := passphrase("保存的账号")
+		if err != nil {
+			return "", err
+		}
+		if err := vault.File().Unlock(master); err != nil {
+			return "", fmt.Errorf("解锁保存的账号失败: %w", err)
+		}
+	}
+
+	password, err := vault.Password(account)
+	if err != nil {
+		return "", fmt.Errorf("读取保存的密码失败: %w", err)
+	}
+	return password, nil
+}
+
+// readPassword 从环境变量或标准输入的第一行读取登录密码
+func (env *cliEnv) readPassword(fromStdin bool) (string, error) {
+	if !fromStdin {
+		if password := os.Getenv(passwordEnv); password != "" {
+			return password, nil
+		}
+		return "", errUsage("请通过 --password-stdin 或环境变量 " + passwordEnv + " 提供密码")
+	}
+
+	line, err := bufio.NewReader(env.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// runTokensExtract 登录目标网站并提取关键Token
+func runTokensExtract(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("tokens extract")
+	profileName := fs.String("profile", "", "站点配置名称，默认为图形界面中选中的配置")
+	targetURL := fs.String("url", "", "目标网站地址（HTTPS），默认为站点配置的登录地址")
+	username := fs.String("username", "", "登录账号")
+	passwordStdin := fs.Bool("password-stdin", false, "从标准输入读取密码")
+	timeout := fs.Duration("timeout", 0, "提取超时时间，默认使用站点配置的超时时间")
+	noHistory := fs.Bool("no-history", false, "不保存到提取历史")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	profiles, profile, err := env.tokenProfile(*profileName)
+	if err != nil {
+		return err
+	}
+
+	// 没有通过标准输入或环境变量提供密码时使用保存的账号
+	var password string
+	if !*passwordStdin && os.Getenv(passwordEnv) == "" {
+		password, err = env.savedPassword(profiles, profile, *username)
+	} else {
+		password, err = env.readPassword(*passwordStdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *targetURL == "" {
+		*targetURL = profile.TargetURL
+	}
+
+	req := token_extractor.LoginRequest{
+		Username:  *username,
+		Password:  password,
+		TargetURL: *targetURL,
+		Profile:   profile,
+		// 进度输出到标准错误，不影响 --json 的输出
+		OnProgress: func(event token_extractor.ProgressEvent) {
+			fmt.Fprintln(env.stderr, event.String())
+		},
+	}
+	if err := req.Validate(); err != nil {
+		return errUsage(err.Error())
+	}
+
+	extractor, err := token_extractor.NewExtractor()
+	if err != nil {
+		return err
+	}
+	defer extractor.Close()
+
+	// Ctrl+C 时取消提取（并关闭浏览器）
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	result, err := extractor.Extract(ctx, req)
+	if err != nil {
+		return fmt.Errorf("提取失败: %w", err)
+	}
+
+	if result.Success && !*noHistory {
+		if err := env.tokenStorage().SaveHistory(token_extractor.NewHistoryRecord(*username, result)); err != nil {
+			fmt.Fprintln(env.stderr, "警告: 保存历史记录失败:", err)
+		}
+	}
+
+	// 关键头部在前
+	sort.SliceStable(result.Headers, func(i, j int) bool {
+		return result.Headers[i].IsKey && !result.Headers[j].IsKey
+	})
+
+	if *asJSON {
+		if err := env.writeJSON(result); err != nil {
+			return err
+		}
+	} else if result.Success {
+		for _, header := range result.Headers {
+			marker := " "
+			if header.IsKey {
+				marker = "*"
+			}
+			name := header.Name
+			if header.Source != "" && header.Source != token_extractor.SourceHeader {
+				name += " [" + token_extractor.SourceLabel(header.Source) + "]"
+			}
+			value := header.Value
+			if !header.ExpiresAt.IsZero() {
+				value += "  (过期: " + header.ExpiresAt.Format("2006-01-02 15:04:05") + ")"
+			}
+			fmt.Fprintf(env.stdout, "%s %s: %s\n", marker, name, value)
+		}
+	}
+
+	if !result.Success {
+		return errors.New("提取失败: " + result.Error)
+	}
+	return nil
+}
+
+// runTokensHistory 列出提取历史
+func runTokensHistory(env *cliEnv, args []string) error {
+	fs := env.newFlagSet("tokens history")
+	limit := fs.Int("limit", 20, "最多显示的记录数，0 表示全部")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	history, err := env.tokenStorage().GetHistory(*limit)
+	if err := env.warnRecovered(err); err != nil {
+		return fmt.Errorf("读取历史记录失败: %w", err)
+	}
+
+	if *asJSON {
+		return env.writeJSON(history)
+	}
+	if len(history) == 0 {
+		fmt.Fprintln(env.stdout, "暂无历史记录")
+		return nil
+	}
+	for _, record := range history {
+		status := "成功"
+		if !record.Success {
+			status = "失败"
+		}
+		fmt.Fprintf(env.stdout, "%s  %s  %s\n", record.Timestamp.Format("2006-01-02 15:04:05"), record.Username, status)
+
+		names := make([]string, 0, len(record.KeyHeaders))
+		for name := range record.KeyHeaders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(env.stdout, "    %s: %s\n", name, record.KeyHeaders[name])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package file_watch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrChanged 文件在本程序上次读写之后被其他程序修改
+var ErrChanged = errors.New("数据文件已被其他程序修改")
+
+// 文件变化后等待写入完成的时间，编辑器和同步工具通常会连续产生多个事件
+const settleDelay = 500 * time.Millisecond
+
+// 无法使用系统文件通知时（例如部分网络文件系统）的轮询间隔
+const pollInterval = 2 * time.Second
+
+// fingerprint 文件内容的指纹，文件不存在时为 nil
+func fingerprint(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// Guard 记录本程序最后一次读写后文件的内容，用于发现其他程序的修改
+type Guard struct {
+	path  string
+	mu    sync.Mutex
+	known bool
+	sum   []byte
+}
+
+// NewGuard 为文件创建修改检查
+func NewGuard(path string) *Guard {
+	return &Guard{path: path}
+}
+
+// Path 返回被检查的文件路径
+func (g *Guard) Path() string {
+	return g.path
+}
+
+// Remember 记录文件当前的内容，在每次读取或写入文件之后调用
+func (g *Guard) Remember() {
+	sum := fingerprint(g.path)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.known = true
+	g.sum = sum
+}
+
+// Forget 忽略之前记录的内容，下一次保存时不再检查（用于用户确认覆盖之后）
+func (g *Guard) Forget() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.known = false
+	g.sum = nil
+}
+
+// Changed 文件是否在上次读写之后被其他程序修改
+func (g *Guard) Changed() bool {
+	sum := fingerprint(g.path)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.known && !bytes.Equal(sum, g.sum)
+}
+
+// Check 文件被其他程序修改时返回 ErrChanged，在保存之前调用
+func (g *Guard) Check() error {
+	if g.Changed() {
+		return ErrChanged
+	}
+	return nil
+}
+
+// Watch 在后台监视文件的变化（包括被替换和删除），变化稳定后在后台 goroutine 中调用 onChange。
+// 返回的函数用于停止监视。系统文件通知不可用时改为定时检查。
+func Watch(path string, onChange func()) (stop func()) {
+	path = filepath.Clean(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		// 监视所在目录：原子写入会用新文件替换原文件，直接监视文件会丢失后续事件
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+		}
+	}
+	if err != nil {
+		return poll(path, onChange)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case <-done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(settleDelay, onChange)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}
+}
+
+// poll 定时比较文件内容，发现变化时调用 onChange
+func poll(path string, onChange func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		last := fingerprint(path)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := fingerprint(path)
+				if !bytes.Equal(current, last) {
+					last = current
+					onChange()
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,320 @@
+package folder_sync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"my_portfolio/safe_file"
+)
+
+// Snapshot 某台设备上某个工具的全部记录，保存在同步文件夹的 <工具>/<设备ID>.json 中
+type Snapshot struct {
+	Device     string                     `json:"device"`
+	DeviceName string                     `json:"device_name"`
+	UpdatedAt  time.Time                  `json:"updated_at"`
+	Records    map[string]json.RawMessage `json:"records"` // 记录键（通常为UUID）-> 记录内容
+}
+
+// Conflict 本机和其他设备都修改了同一条记录
+type Conflict struct {
+	Key        string
+	Device     string          // 对方设备ID
+	DeviceName string          // 对方设备名称
+	Local      json.RawMessage // 本机版本，nil 表示本机已删除
+	Remote     json.RawMessage // 对方版本，nil 表示对方已删除
+}
+
+// state 本机的同步状态：每个工具、每台对方设备上次合并时看到的记录版本
+type state struct {
+	Tools map[string]map[string]map[string]string `json:"tools"` // 工具 -> 设备 -> 记录键 -> 内容哈希（包含本机上次写入的版本）
+}
+
+// Folder 通过共享文件夹（例如由网盘同步的目录）在多台设备之间同步数据
+//
+// 每台设备只写入自己的快照文件，避免网盘同步工具产生冲突副本；
+// 合并时以上次看到的对方版本为基准做三方合并，双方都修改过的记录交给用户选择。
+type Folder struct {
+	dir        string
+	statePath  string
+	device     string
+	deviceName string
+}
+
+// NewFolder 创建文件夹同步；statePath 为本机同步状态文件的路径
+func NewFolder(dir, statePath, device, deviceName string) *Folder {
+	return &Folder{
+		dir:        dir,
+		statePath:  statePath,
+		device:     device,
+		deviceName: deviceName,
+	}
+}
+
+// Plan 一次同步的合并结果，解决冲突后通过 Folder.Commit 提交
+type Plan struct {
+	Tool      string
+	Records   map[string]json.RawMessage // 合并后的记录，冲突默认保留本机版本
+	Conflicts []Conflict
+	local     map[string]string            // 合并前本机记录的哈希
+	seen      map[string]map[string]string // 对方设备 -> 本次看到的记录哈希
+}
+
+// Changed 合并结果是否与本机数据不同
+func (p *Plan) Changed() bool {
+	if len(p.Records) != len(p.local) {
+		return true
+	}
+	for key, data := range p.Records {
+		if hash, ok := p.local[key]; !ok || hash != hashRecord(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve 解决冲突：useRemote 为 true 时使用对方版本，否则保留本机版本
+func (p *Plan) Resolve(conflict Conflict, useRemote bool) {
+	data := conflict.Local
+	if useRemote {
+		data = conflict.Remote
+	}
+
+	if data == nil {
+		delete(p.Records, conflict.Key)
+	} else {
+		p.Records[conflict.Key] = data
+	}
+}
+
+// Prepare 读取其他设备的快照并与本机记录合并
+func (f *Folder) Prepare(tool string, local map[string]json.RawMessage) (*Plan, error) {
+	st, err := f.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	remotes, err := f.readSnapshots(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Tool:    tool,
+		Records: make(map[string]json.RawMessage, len(local)),
+		local:   make(map[string]string, len(local)),
+		seen:    make(map[string]map[string]string),
+	}
+	for key, data := range local {
+		plan.Records[key] = data
+		plan.local[key] = hashRecord(data)
+	}
+
+	for _, remote := range remotes {
+		base, ok := st.Tools[tool][remote.Device]
+		if !ok {
+			// 第一次看到该设备时，以本机上次写入的快照为基准：
+			// 对方如果只是同步了本机的数据，本机之后的修改和删除不会被旧版本覆盖
+			base = st.Tools[tool][f.device]
+		}
+		plan.seen[remote.Device] = hashRecords(remote.Records)
+		plan.Conflicts = append(plan.Conflicts, merge(plan.Records, base, remote)...)
+	}
+
+	return plan, nil
+}
+
+// merge 将对方快照合并到 records 中，返回双方都修改过的记录
+func merge(records map[string]json.RawMessage, base map[string]string, remote Snapshot) []Conflict {
+	keys := make(map[string]bool)
+	for key := range records {
+		keys[key] = true
+	}
+	for key := range remote.Records {
+		keys[key] = true
+	}
+	for key := range base {
+		keys[key] = true
+	}
+
+	var conflicts []Conflict
+	for _, key := range sortedKeys(keys) {
+		localData, remoteData := records[key], remote.Records[key]
+		localHash, remoteHash := hashOrEmpty(localData), hashOrEmpty(remoteData)
+		baseHash := base[key]
+
+		switch {
+		case localHash == remoteHash:
+			// 双方一致
+		case remoteHash == baseHash:
+			// 对方未修改，保留本机版本
+		case localHash == baseHash:
+			// 本机未修改，采用对方的修改（包括删除）
+			if remoteData == nil {
+				delete(records, key)
+			} else {
+				records[key] = remoteData
+			}
+		default:
+			conflicts = append(conflicts, Conflict{
+				Key:        key,
+				Device:     remote.Device,
+				DeviceName: remote.DeviceName,
+				Local:      localData,
+				Remote:     remoteData,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// Commit 将合并结果写入本机快照，并记录本次看到的对方版本作为下次合并的基准
+func (f *Folder) Commit(plan *Plan) error {
+	snapshot := Snapshot{
+		Device:     f.device,
+		DeviceName: f.deviceName,
+		UpdatedAt:  time.Now(),
+		Records:    plan.Records,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	toolDir := filepath.Join(f.dir, plan.Tool)
+	if err := os.MkdirAll(toolDir, 0700); err != nil {
+		return err
+	}
+	// 共享文件夹中不保留历史版本，避免同步工具复制多余的文件
+	if err := safe_file.WriteFileWithBackups(filepath.Join(toolDir, f.device+".json"), data, 0600, 0); err != nil {
+		return err
+	}
+
+	st, err := f.loadState()
+	if err != nil {
+		return err
+	}
+	if st.Tools[plan.Tool] == nil {
+		st.Tools[plan.Tool] = make(map[string]map[string]string)
+	}
+	for device, hashes := range plan.seen {
+		st.Tools[plan.Tool][device] = hashes
+	}
+	st.Tools[plan.Tool][f.device] = hashRecords(plan.Records)
+
+	stateData, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safe_file.WriteFile(f.statePath, stateData, 0600)
+}
+
+// readSnapshots 读取其他设备的快照；同一设备有多个快照（例如网盘生成的冲突副本）时使用最新的一个
+func (f *Folder) readSnapshots(tool string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, tool))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	latest := make(map[string]Snapshot)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(f.dir, tool, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var snapshot Snapshot
+		// 正在被同步工具写入的文件可能不完整，跳过，下次同步时再读取
+		if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Device == "" || snapshot.Device == f.device {
+			continue
+		}
+
+		if existing, ok := latest[snapshot.Device]; !ok || snapshot.UpdatedAt.After(existing.UpdatedAt) {
+			latest[snapshot.Device] = snapshot
+		}
+	}
+
+	devices := make([]string, 0, len(latest))
+	for device := range latest {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	snapshots := make([]Snapshot, 0, len(devices))
+	for _, device := range devices {
+		snapshots = append(snapshots, latest[device])
+	}
+	return snapshots, nil
+}
+
+// loadState 加载本机同步状态
+func (f *Folder) loadState() (*state, error) {
+	st := &state{Tools: make(map[string]map[string]map[string]string)}
+
+	data, err := os.ReadFile(f.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+	if st.Tools == nil {
+		st.Tools = make(map[string]map[string]map[string]string)
+	}
+	return st, nil
+}
+
+// hashRecords 计算所有记录的内容哈希
+func hashRecords(records map[string]json.RawMessage) map[string]string {
+	hashes := make(map[string]string, len(records))
+	for key, data := range records {
+		hashes[key] = hashRecord(data)
+	}
+	return hashes
+}
+
+// hashOrEmpty 计算记录的内容哈希，记录不存在时返回空字符串
+func hashOrEmpty(data json.RawMessage) string {
+	if data == nil {
+		return ""
+	}
+	return hashRecord(data)
+}
+
+// hashRecord 计算记录的内容哈希（忽略JSON格式差异）
+func hashRecord(data json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		buf.Reset()
+		buf.Write(data)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// sortedKeys 返回排序后的键，保证合并结果和冲突顺序稳定
+func sortedKeys(keys map[string]bool) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,317 @@
+package local_api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"my_portfolio/file_watch"
+)
+
+// DefaultPort 本地 API 的默认端口
+const DefaultPort = 8765
+
+// 请求体的最大长度
+const maxBodySize = 1 << 20
+
+var (
+	// ErrNotFound 要修改或删除的记录不存在
+	ErrNotFound = errors.New("记录不存在")
+	// ErrUnavailable 数据暂时不能访问（例如加密存储尚未解锁或数据加载失败）
+	ErrUnavailable = errors.New("数据暂时不可用")
+	// ErrSavePending 修改已生效但保存失败，正在后台自动重试
+	ErrSavePending = errors.New("保存失败，修改已保留，将在后台自动重试")
+)
+
+// InputError 请求内容无效，返回 400
+type InputError struct {
+	Err error
+}
+
+func (e *InputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// BadRequest 将校验错误包装为请求错误
+func BadRequest(err error) error {
+	return &InputError{Err: err}
+}
+
+// DecodeInput 解析请求体，未知字段或格式错误时返回请求错误
+func DecodeInput(body []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return BadRequest(fmt.Errorf("请求内容无效: %w", err))
+	}
+	return nil
+}
+
+// ParseDate 解析请求中的时间，支持 RFC 3339 和 YYYY-MM-DD（本地时间）
+func ParseDate(text string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, text); err == nil {
+		return date, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return time.Time{}, BadRequest(errors.New("日期格式无效，请使用 YYYY-MM-DD 或 RFC 3339 格式"))
+	}
+	return date, nil
+}
+
+// Collection 通过本地 API 读写的一类记录。
+// 所有方法都在 Options.Do 中调用，实现可以直接访问界面持有的数据并刷新界面。
+type Collection interface {
+	List() (any, error)
+	Create(body []byte) (any, error)
+	Update(id string, body []byte) (any, error)
+	Delete(id string) error
+	Stats() (any, error)
+}
+
+// Schema JSON Schema，用于生成 OpenAPI 描述
+type Schema map[string]any
+
+// Resource 注册到本地 API 的数据
+type Resource struct {
+	Name       string     // URL 中的名称，例如 "weight" 对应 /api/weight/records
+	Title      string     // 显示名称
+	Collection Collection // 记录的读写
+	Record     Schema     // 单条记录
+	Input      Schema     // 创建记录时的请求体；修改时所有字段均可省略
+	Stats      Schema     // 统计信息
+}
+
+var (
+	resourcesMu sync.Mutex
+	resources   []Resource
+)
+
+// Register 注册通过本地 API 提供的数据
+func Register(resource Resource) {
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	resources = append(resources, resource)
+}
+
+// registered 返回已注册的数据
+func registered() []Resource {
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	return append([]Resource(nil), resources...)
+}
+
+// NewToken 生成随机的访问令牌
+func NewToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Options 本地 API 服务的选项
+type Options struct {
+	Port  int          // 监听端口，只监听 127.0.0.1
+	Token string       // 访问令牌，请求需要携带 Authorization: Bearer <Token>
+	Do    func(func()) // 在界面线程中执行数据读写，为空时直接调用
+}
+
+// Server 只监听本机地址的 HTTP/JSON 服务
+type Server struct {
+	opts     Options
+	server   *http.Server
+	listener net.Listener
+}
+
+// Start 开始监听并在后台处理请求
+func Start(opts Options) (*Server, error) {
+	if opts.Token == "" {
+		return nil, errors.New("访问令牌不能为空")
+	}
+	if opts.Port <= 0 {
+		opts.Port = DefaultPort
+	}
+	if opts.Do == nil {
+		opts.Do = func(fn func()) { fn() }
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{opts: opts, listener: listener}
+	s.server = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// Addr 返回服务地址，例如 http://127.0.0.1:8765
+func (s *Server) Addr() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Stop 停止服务，等待正在处理的请求完成
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// routes 创建请求路由
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/openapi.json", s.handleOpenAPI)
+
+	for _, resource := range registered() {
+		base := "/api/" + resource.Name
+		collection := resource.Collection
+
+		mux.HandleFunc("GET "+base+"/records", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.respond(w, http.StatusOK, func() (any, error) { return collection.List() })
+		}))
+		mux.HandleFunc("POST "+base+"/records", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			body, ok := readBody(w, r)
+			if !ok {
+				return
+			}
+			s.respond(w, http.StatusCreated, func() (any, error) { return collection.Create(body) })
+		}))
+		mux.HandleFunc("PUT "+base+"/records/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			body, ok := readBody(w, r)
+			if !ok {
+				return
+			}
+			id := r.PathValue("id")
+			s.respond(w, http.StatusOK, func() (any, error) { return collection.Update(id, body) })
+		}))
+		mux.HandleFunc("DELETE "+base+"/records/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			id := r.PathValue("id")
+			s.respond(w, http.StatusNoContent, func() (any, error) { return nil, collection.Delete(id) })
+		}))
+		mux.HandleFunc("GET "+base+"/stats", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.respond(w, http.StatusOK, func() (any, error) { return collection.Stats() })
+		}))
+	}
+
+	return s.checkHost(mux)
+}
+
+// checkHost 只接受以本机地址访问的请求，防止网页通过 DNS 重绑定访问本地数据
+func (s *Server) checkHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if host != "127.0.0.1" && host != "localhost" {
+			writeError(w, http.StatusForbidden, errors.New("只允许通过 127.0.0.1 或 localhost 访问"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized 校验请求携带的访问令牌
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("访问令牌无效"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleOpenAPI 返回 OpenAPI 描述
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPI(s.Addr(), registered()))
+}
+
+// respond 在界面线程中执行读写并输出结果
+func (s *Server) respond(w http.ResponseWriter, status int, call func() (any, error)) {
+	var result any
+	var err error
+	s.opts.Do(func() {
+		result, err = call()
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSavePending) {
+			// 修改已生效，只是尚未写入存储
+			w.Header().Set("X-Save-Pending", "1")
+			writeJSON(w, http.StatusAccepted, result)
+			return
+		}
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+// statusOf 返回错误对应的 HTTP 状态码
+func statusOf(err error) int {
+	var input *InputError
+	switch {
+	case errors.As(err, &input):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, file_watch.ErrChanged):
+		return http.StatusConflict
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// readBody 读取请求体，过长或读取失败时直接返回错误响应
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("请求内容过长（最大 %d KB）", maxBodySize>>10))
+		return nil, false
+	}
+	return body, true
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeError 输出 {"error": "..."} 格式的错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
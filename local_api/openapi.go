@@ -0,0 +1,136 @@
+package local_api
+
+import "strings"
+
+// openAPI 生成已注册数据的 OpenAPI 3.0 描述
+func openAPI(serverURL string, resources []Resource) map[string]any {
+	paths := map[string]any{}
+	schemas := map[string]any{
+		"Error": Schema{
+			"type":       "object",
+			"properties": Schema{"error": Schema{"type": "string"}},
+		},
+	}
+
+	for _, resource := range resources {
+		prefix := schemaPrefix(resource.Name)
+		schemas[prefix+"Record"] = resource.Record
+		schemas[prefix+"Input"] = resource.Input
+		schemas[prefix+"Stats"] = resource.Stats
+
+		record := ref(prefix + "Record")
+		input := ref(prefix + "Input")
+		tags := []string{resource.Title}
+		idParam := []any{Schema{
+			"name":     "id",
+			"in":       "path",
+			"required": true,
+			"schema":   Schema{"type": "string"},
+		}}
+
+		paths["/api/"+resource.Name+"/records"] = Schema{
+			"get": Schema{
+				"tags":      tags,
+				"summary":   "列出" + resource.Title,
+				"responses": responses("200", Schema{"type": "array", "items": record}, false),
+			},
+			"post": Schema{
+				"tags":        tags,
+				"summary":     "添加" + resource.Title,
+				"requestBody": requestBody(input),
+				"responses":   responses("201", record, true),
+			},
+		}
+		paths["/api/"+resource.Name+"/records/{id}"] = Schema{
+			"put": Schema{
+				"tags":        tags,
+				"summary":     "修改" + resource.Title + "，省略的字段保持不变",
+				"parameters":  idParam,
+				"requestBody": requestBody(input),
+				"responses":   responses("200", record, true),
+			},
+			"delete": Schema{
+				"tags":       tags,
+				"summary":    "删除" + resource.Title,
+				"parameters": idParam,
+				"responses":  responses("204", nil, true),
+			},
+		}
+		paths["/api/"+resource.Name+"/stats"] = Schema{
+			"get": Schema{
+				"tags":      tags,
+				"summary":   resource.Title + "统计",
+				"responses": responses("200", ref(prefix+"Stats"), false),
+			},
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": Schema{
+			"title":       "我的超级工具箱本地 API",
+			"version":     "1.0.0",
+			"description": "只监听本机地址。除本文档外，所有请求都需要携带 Authorization: Bearer <访问令牌>，令牌在设置页面中查看。",
+		},
+		"servers": []any{Schema{"url": serverURL}},
+		"paths":   paths,
+		"components": Schema{
+			"schemas": schemas,
+			"securitySchemes": Schema{
+				"token": Schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{Schema{"token": []string{}}},
+	}
+}
+
+// responses 生成成功响应和可能的错误响应；write 为 true 时包含修改数据的错误
+func responses(status string, schema any, write bool) Schema {
+	success := Schema{"description": "成功"}
+	if schema != nil {
+		success["content"] = Schema{"application/json": Schema{"schema": schema}}
+	}
+
+	errorResponse := func(description string) Schema {
+		return Schema{
+			"description": description,
+			"content":     Schema{"application/json": Schema{"schema": ref("Error")}},
+		}
+	}
+
+	result := Schema{
+		status: success,
+		"401":  errorResponse("访问令牌无效"),
+		"503":  errorResponse("数据暂时不可用（加密存储尚未解锁或加载失败）"),
+	}
+	if write {
+		result["202"] = Schema{"description": "修改已生效但保存失败，正在后台自动重试（响应头 X-Save-Pending: 1）"}
+		result["400"] = errorResponse("请求内容无效")
+		result["409"] = errorResponse("数据文件已被其他程序修改，已重新加载，请重试")
+		if status != "201" {
+			result["404"] = errorResponse("记录不存在")
+		}
+	}
+	return result
+}
+
+// requestBody 生成 JSON 请求体描述
+func requestBody(schema any) Schema {
+	return Schema{
+		"required": true,
+		"content":  Schema{"application/json": Schema{"schema": schema}},
+	}
+}
+
+// ref 引用 components 中的 Schema
+func ref(name string) Schema {
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaPrefix 将名称转换为 Schema 名称前缀，例如 weight -> Weight
+func schemaPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
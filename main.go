@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"my_portfolio/local_api"
 	"my_portfolio/profit_calculator"
 	"my_portfolio/settings"
 	"my_portfolio/token_extractor"
@@ -14,7 +22,12 @@ import (
 )
 
 func main() {
-	myApp := app.New()
+	// 带命令参数时以命令行模式运行，不创建窗口
+	if isCLI(os.Args[1:]) {
+		os.Exit(runCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	myApp := app.NewWithID("com.my_portfolio.toolbox")
 	myWindow := myApp.NewWindow("我的超级工具箱")
 	myWindow.Resize(fyne.NewSize(400, 600))
 
@@ -55,35 +68,173 @@ func main() {
 		widget.NewLabel("功能开发中..."),
 	)
 
+	// 加载配置、准备数据目录并打开各工具的存储（与命令行模式共用）
+	data := prepareAppData(myApp.Storage().RootURI().Path())
+	config, dataDir, storages := data.config, data.dir, data.storages
+
+	registerBackupSources(dataDir, storages)
+
 	// 创建体重记录UI
-	weightTrackerUI := weight_tracker.NewWeightTrackerUI(myWindow)
+	weightTrackerUI := weight_tracker.NewWeightTrackerUIWithOptions(myWindow, weight_tracker.Options{
+		DataPath:  filepath.Join(dataDir, weight_tracker.DefaultDataFile),
+		Encrypted: config.IsEncrypted(weight_tracker.ToolID),
+		Storage:   storages.weight,
+	})
 	weightTrackerContent := weightTrackerUI.MakeUI()
+	settings.RegisterEncryptable(weight_tracker.ToolID, "体重记录", weightTrackerUI)
+	settings.RegisterSyncable(weight_tracker.ToolID, "体重记录", weightTrackerUI)
 
 	// 创建收益计算器UI
-	profitCalculatorUI := profit_calculator.NewProfitCalculatorUI(myWindow)
+	profitCalculatorUI := profit_calculator.NewProfitCalculatorUIWithOptions(myWindow, profit_calculator.Options{
+		DataPath:  filepath.Join(dataDir, profit_calculator.DefaultDataFile),
+		Encrypted: config.IsEncrypted(profit_calculator.ToolID),
+		Storage:   storages.profit,
+	})
 	profitCalculatorContent := profitCalculatorUI.MakeUI()
+	settings.RegisterEncryptable(profit_calculator.ToolID, "收益计算", profitCalculatorUI)
+	settings.RegisterSyncable(profit_calculator.ToolID, "收益计算", profitCalculatorUI)
+
+	// 加密存储尚未解锁时在页面顶部显示解锁提示，取消解锁对话框后可以从这里重新解锁
+	var unlockBars []*settings.UnlockBar
+	if profitCalculatorUI.Locked() {
+		bar := settings.NewUnlockBar("收益计算", myWindow, profitCalculatorUI.Unlock)
+		profitCalculatorContent = container.NewBorder(bar, nil, nil, nil, profitCalculatorContent)
+		unlockBars = append(unlockBars, bar)
+	}
+	if weightTrackerUI.Locked() {
+		bar := settings.NewUnlockBar("体重记录", myWindow, weightTrackerUI.Unlock)
+		weightTrackerContent = container.NewBorder(bar, nil, nil, nil, weightTrackerContent)
+		unlockBars = append(unlockBars, bar)
+	}
+
+	// 通过本地 API 提供的数据
+	local_api.Register(weightTrackerUI.APIResource())
+	local_api.Register(profitCalculatorUI.APIResource())
 
 	// 创建设置UI
 	settingsUI := settings.NewSettingsUI(myApp, myWindow)
 	settingsContent := settingsUI.MakeUI()
 
 	// 创建Token提取器UI
-	tokenExtractorUI := token_extractor.NewTokenExtractorUI(myWindow)
+	tokenExtractorUI := token_extractor.NewTokenExtractorUIWithOptions(myWindow, token_extractor.Options{
+		HistoryPath:  filepath.Join(dataDir, token_extractor.DefaultHistoryFile),
+		Storage:      storages.tokens,
+		ProfilesPath: filepath.Join(dataDir, token_extractor.DefaultProfilesFile),
+		VaultPath:    filepath.Join(dataDir, token_extractor.DefaultVaultFile),
+	})
 	tokenExtractorContent := tokenExtractorUI.MakeUI()
 
 	// 2. 使用 TabContainer 来组织页面
+	profitTab := container.NewTabItemWithIcon("收益计算", theme.ConfirmIcon(), profitCalculatorContent)
 	tabs := container.NewAppTabs(
 		container.NewTabItemWithIcon("首页", theme.HomeIcon(), homeContent),
 		container.NewTabItemWithIcon("周报工具", theme.DocumentIcon(), tool1Content),
 		container.NewTabItemWithIcon("文件去重", theme.FolderIcon(), tool2Content),
 		container.NewTabItemWithIcon("体重记录", theme.MediaRecordIcon(), weightTrackerContent),
-		container.NewTabItemWithIcon("收益计算", theme.ConfirmIcon(), profitCalculatorContent),
+		profitTab,
 		container.NewTabItemWithIcon("Token提取", theme.ComputerIcon(), tokenExtractorContent),
 		container.NewTabItemWithIcon("设置", theme.SettingsIcon(), settingsContent),
 	)
 
+	// 收益计算的撤销/重做快捷键只在该页面处于前台时生效
+	tabs.OnSelected = func(item *container.TabItem) {
+		profitCalculatorUI.SetActive(item == profitTab)
+	}
+
 	myWindow.SetContent(tabs)
+
+	if data.restoreErr != nil {
+		dialog.ShowError(errors.New("从备份恢复失败: "+data.restoreErr.Error()), myWindow)
+	} else if len(data.restored) > 0 {
+		dialog.ShowInformation("💾 已从备份恢复", "以下文件已恢复：\n"+strings.Join(data.restored, "\n"), myWindow)
+	}
+
+	if data.exportErr != nil {
+		dialog.ShowError(errors.New("从 SQLite 数据库导出到 JSON 文件失败，本次继续使用 SQLite 数据库: "+data.exportErr.Error()), myWindow)
+	} else if len(data.exported) > 0 {
+		dialog.ShowInformation("🗄️ 数据已导出", "已从 SQLite 数据库导出到 JSON 文件：\n"+strings.Join(data.exported, "\n"), myWindow)
+	}
+
+	if data.storageErr != nil {
+		dialog.ShowError(errors.New("打开 SQLite 数据库失败，本次使用 JSON 文件存储: "+data.storageErr.Error()), myWindow)
+	} else if len(storages.imported) > 0 {
+		dialog.ShowInformation("🗄️ 数据已导入", "已从 JSON 文件导入到 SQLite 数据库：\n"+strings.Join(storages.imported, "\n"), myWindow)
+	}
+
+	if data.dirErr != nil {
+		dialog.ShowError(errors.New("数据目录准备失败: "+data.dirErr.Error()), myWindow)
+	} else if len(data.migrated) > 0 {
+		dialog.ShowInformation("📁 数据已迁移",
+			"以下数据文件已迁移到 "+dataDir+"：\n"+strings.Join(data.migrated, "\n"), myWindow)
+	}
+
+	// 加密存储需要先解锁
+	for _, bar := range unlockBars {
+		bar.ShowDialog()
+	}
+
+	// 后台自动备份
+	ctx, cancel := context.WithCancel(context.Background())
+	settings.StartAutoBackup(ctx, func(err error) {
+		fyne.Do(func() {
+			dialog.ShowError(errors.New("自动备份失败: "+err.Error()), myWindow)
+		})
+	})
+
+	// 按设置启动本地 API
+	if err := settings.StartLocalAPI(); err != nil {
+		dialog.ShowError(errors.New("启动本地 API 失败: "+err.Error()), myWindow)
+	}
+
+	// 退出前重试保存失败的修改，仍未保存时让用户确认
+	myWindow.SetCloseIntercept(func() {
+		var unsaved []string
+		for _, tool := range []struct {
+			title string
+			flush func() error
+		}{
+			{"体重记录", weightTrackerUI.FlushPendingSaves},
+			{"收益计算", profitCalculatorUI.FlushPendingSaves},
+			{"Token提取历史", tokenExtractorUI.FlushPendingSaves},
+		} {
+			if err := tool.flush(); err != nil {
+				unsaved = append(unsaved, tool.title+"："+err.Error())
+			}
+		}
+
+		if len(unsaved) == 0 {
+			myWindow.Close()
+			return
+		}
+		dialog.ShowConfirm("⚠️ 有未保存的修改",
+			"以下数据仍然保存失败，退出后这些修改将丢失：\n"+strings.Join(unsaved, "\n")+"\n\n仍要退出吗？",
+			func(confirmed bool) {
+				if confirmed {
+					myWindow.Close()
+				}
+			}, myWindow)
+	})
+
 	myWindow.ShowAndRun()
+	cancel()
+	weightTrackerUI.StopWatching()
+	profitCalculatorUI.StopWatching()
+	tokenExtractorUI.StopWatching()
+	settings.StopLocalAPI()
+
+	if storages.db != nil {
+		storages.db.Close()
+	}
+}
+
+// dataFiles 需要迁移到数据目录的数据文件
+var dataFiles = []string{
+	weight_tracker.DefaultDataFile,
+	profit_calculator.DefaultDataFile,
+	filepath.Base(profit_calculator.AuditPathFor(profit_calculator.DefaultDataFile)),
+	token_extractor.DefaultHistoryFile,
+	token_extractor.DefaultProfilesFile,
+	token_extractor.DefaultVaultFile,
 }
 
 // 在main函数外面，定义我们需要的控件变量，以便在按钮函数里访问
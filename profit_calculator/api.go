@@ -0,0 +1,281 @@
+package profit_calculator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/local_api"
+	"my_portfolio/storage_recovery"
+)
+
+// apiInput 通过本地 API 添加或修改收益记录的请求体，修改时省略的字段保持不变
+type apiInput struct {
+	Date   *string  `json:"date"`
+	Amount *float64 `json:"amount"`
+}
+
+// apiProfits 通过本地 API 读写界面中的收益记录
+type apiProfits struct {
+	ui *ProfitCalculatorUI
+}
+
+// APIResource 返回通过本地 API 提供的收益记录
+func (ui *ProfitCalculatorUI) APIResource() local_api.Resource {
+	distribution := local_api.Schema{
+		"type": "object",
+		"properties": local_api.Schema{
+			"investor_id":   local_api.Schema{"type": "string"},
+			"investor_name": local_api.Schema{"type": "string", "description": "投资者已删除时为空"},
+			"amount":        local_api.Schema{"type": "number"},
+		},
+	}
+	investorStats := local_api.Schema{
+		"type": "object",
+		"properties": local_api.Schema{
+			"investor_id":       local_api.Schema{"type": "string"},
+			"investor_name":     local_api.Schema{"type": "string"},
+			"investment_amount": local_api.Schema{"type": "number"},
+			"investment_ratio":  local_api.Schema{"type": "number", "description": "投资比例（0-1）"},
+			"total_profit":      local_api.Schema{"type": "number"},
+			"final_amount":      local_api.Schema{"type": "number"},
+			"profit_count":      local_api.Schema{"type": "integer"},
+		},
+	}
+
+	return local_api.Resource{
+		Name:       "profit",
+		Title:      "收益记录",
+		Collection: apiProfits{ui: ui},
+		Record: local_api.Schema{
+			"type": "object",
+			"properties": local_api.Schema{
+				"id":            local_api.Schema{"type": "string"},
+				"date":          local_api.Schema{"type": "string", "format": "date"},
+				"total_profit":  local_api.Schema{"type": "number"},
+				"distributions": local_api.Schema{"type": "array", "items": distribution},
+				"recurring_id":  local_api.Schema{"type": "string", "description": "由定期收益生成时的模板ID"},
+			},
+		},
+		Input: local_api.Schema{
+			"type":     "object",
+			"required": []string{"amount"},
+			"properties": local_api.Schema{
+				"date":   local_api.Schema{"type": "string", "format": "date", "description": "YYYY-MM-DD，默认为今天"},
+				"amount": local_api.Schema{"type": "number", "description": "总收益，按当前投资比例分配", "minimum": -10000000, "maximum": 10000000},
+			},
+		},
+		Stats: local_api.Schema{
+			"type": "object",
+			"properties": local_api.Schema{
+				"total_investment":    local_api.Schema{"type": "number"},
+				"total_profit":        local_api.Schema{"type": "number"},
+				"investor_count":      local_api.Schema{"type": "integer"},
+				"profit_record_count": local_api.Schema{"type": "integer"},
+				"investors":           local_api.Schema{"type": "array", "items": investorStats},
+			},
+		},
+	}
+}
+
+// available 加密存储未解锁或加载失败时不能通过 API 访问
+func (a apiProfits) available() error {
+	if a.ui.Locked() {
+		return fmt.Errorf("%w: 收益数据已加密，请先在程序中解锁", local_api.ErrUnavailable)
+	}
+	if a.ui.loadErr != nil {
+		return fmt.Errorf("%w: 收益数据加载失败: %v", local_api.ErrUnavailable, a.ui.loadErr)
+	}
+	return nil
+}
+
+// List 返回所有收益记录及分配明细，最新的在前
+func (a apiProfits) List() (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+
+	profits := append([]MonthlyProfit(nil), a.ui.data.MonthlyProfits...)
+	sort.SliceStable(profits, func(i, j int) bool {
+		return profits[i].Date.After(profits[j].Date)
+	})
+
+	details := make([]ProfitDetail, len(profits))
+	for i, profit := range profits {
+		details[i] = NewProfitDetail(profit, a.ui.data.Investors)
+	}
+	return details, nil
+}
+
+// Create 添加收益记录并按当前投资比例分配
+func (a apiProfits) Create(body []byte) (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+
+	var input apiInput
+	if err := local_api.DecodeInput(body, &input); err != nil {
+		return nil, err
+	}
+	if input.Amount == nil {
+		return nil, local_api.BadRequest(errors.New("请输入总收益金额"))
+	}
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	date, err := parseAPIDate(input.Date, today)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateProfit(a.ui.data, date, *input.Amount, time.Now()); err != nil {
+		return nil, local_api.BadRequest(err)
+	}
+
+	before := CloneData(a.ui.data)
+	profit := NewMonthlyProfit(date, *input.Amount, DistributeProfit(*input.Amount, a.ui.data.Investors))
+	a.ui.data.MonthlyProfits = append(a.ui.data.MonthlyProfits, *profit)
+
+	summary := fmt.Sprintf("通过本地 API 添加 %s 的收益记录（%s）", date.Format("2006-01-02"), formatCurrency(*input.Amount))
+	err = a.commit(ActionAddProfit, summary, before)
+	return a.detail(profit.ID), err
+}
+
+// Update 修改收益记录的日期或金额，并按当前投资比例重新分配
+func (a apiProfits) Update(id string, body []byte) (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+
+	var input apiInput
+	if err := local_api.DecodeInput(body, &input); err != nil {
+		return nil, err
+	}
+
+	index := a.find(id)
+	if index < 0 {
+		return nil, local_api.ErrNotFound
+	}
+	profit := a.ui.data.MonthlyProfits[index]
+	if a.ui.data.IsLocked(profit.Date) {
+		return nil, local_api.BadRequest(ErrPeriodLocked)
+	}
+
+	date, err := parseAPIDate(input.Date, profit.Date)
+	if err != nil {
+		return nil, err
+	}
+	amount := profit.TotalProfit
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	if err := ValidateProfit(a.ui.data, date, amount, time.Now()); err != nil {
+		return nil, local_api.BadRequest(err)
+	}
+
+	before := CloneData(a.ui.data)
+	profit.Date = date
+	profit.TotalProfit = amount
+	profit.Distributions = DistributeProfit(amount, a.ui.data.Investors)
+	a.ui.data.MonthlyProfits[index] = profit
+
+	summary := fmt.Sprintf("通过本地 API 修改 %s 的收益记录（%s）", date.Format("2006-01-02"), formatCurrency(amount))
+	err = a.commit(ActionEditProfit, summary, before)
+	return a.detail(id), err
+}
+
+// Delete 删除收益记录
+func (a apiProfits) Delete(id string) error {
+	if err := a.available(); err != nil {
+		return err
+	}
+
+	index := a.find(id)
+	if index < 0 {
+		return local_api.ErrNotFound
+	}
+	profit := a.ui.data.MonthlyProfits[index]
+	if a.ui.data.IsLocked(profit.Date) {
+		return local_api.BadRequest(ErrPeriodLocked)
+	}
+
+	before := CloneData(a.ui.data)
+	a.ui.data.MonthlyProfits = append(a.ui.data.MonthlyProfits[:index:index], a.ui.data.MonthlyProfits[index+1:]...)
+
+	summary := fmt.Sprintf("通过本地 API 删除 %s 的收益记录（%s）", profit.Date.Format("2006-01-02"), formatCurrency(profit.TotalProfit))
+	return a.commit(ActionDeleteProfit, summary, before)
+}
+
+// Stats 返回整体和各投资者的统计
+func (a apiProfits) Stats() (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+	return CalculateStatsSummary(a.ui.data), nil
+}
+
+// find 返回收益记录的下标，不存在时返回 -1
+func (a apiProfits) find(id string) int {
+	for i, profit := range a.ui.data.MonthlyProfits {
+		if profit.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// detail 返回收益记录的分配明细，记录不存在（修改被撤销）时返回 nil
+func (a apiProfits) detail(id string) *ProfitDetail {
+	index := a.find(id)
+	if index < 0 {
+		return nil
+	}
+	detail := NewProfitDetail(a.ui.data.MonthlyProfits[index], a.ui.data.Investors)
+	return &detail
+}
+
+// commit 保存修改、记录审计日志并刷新界面。
+// 保存失败但可以重试时修改保留在队列中；无法重试时恢复修改前的数据，数据文件被其他程序修改时重新加载。
+func (a apiProfits) commit(action, summary string, before *ProfitCalculatorData) error {
+	ui := a.ui
+	storage := ui.storage
+	data := CloneData(ui.data)
+
+	err := ui.saveQueue.Save(func() error {
+		return storage.Save(data)
+	})
+	if err != nil && !storage_recovery.Retryable(err) {
+		ui.data = before
+		if errors.Is(err, file_watch.ErrChanged) {
+			ui.reloadIfChanged()
+			err = fmt.Errorf("%w，已重新加载，请重试", err)
+		}
+		ui.refreshUI()
+		return err
+	}
+
+	if ui.history != nil {
+		if err := ui.history.Record(action, summary, before, ui.data); err != nil {
+			dialog.ShowError(errors.New("写入审计日志失败: "+err.Error()), ui.window)
+		}
+	}
+
+	ui.refreshUI()
+	if err != nil {
+		return fmt.Errorf("%w: %v", local_api.ErrSavePending, err)
+	}
+	return nil
+}
+
+// parseAPIDate 解析请求中的日期（YYYY-MM-DD），省略时使用 fallback
+func parseAPIDate(text *string, fallback time.Time) (time.Time, error) {
+	if text == nil {
+		return fallback, nil
+	}
+	date, err := time.Parse("2006-01-02", *text)
+	if err != nil {
+		return time.Time{}, local_api.BadRequest(errors.New("日期格式无效，请使用 YYYY-MM-DD 格式"))
+	}
+	return date, nil
+}
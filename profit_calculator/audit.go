@@ -0,0 +1,533 @@
+package profit_calculator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"my_portfolio/secure_storage"
+)
+
+// 审计操作类型
+const (
+	ActionAddInvestor     = "add_investor"
+	ActionEditInvestor    = "edit_investor"
+	ActionDeleteInvestor  = "delete_investor"
+	ActionAddProfit       = "add_profit"
+	ActionEditProfit      = "edit_profit"
+	ActionDeleteProfit    = "delete_profit"
+	ActionAddRecurring    = "add_recurring"
+	ActionEditRecurring   = "edit_recurring"
+	ActionDeleteRecurring = "delete_recurring"
+	ActionRunRecurring    = "run_recurring"
+	ActionLockPeriod      = "lock_period"
+	ActionUnlockPeriod    = "unlock_period"
+	ActionSync            = "sync"
+	ActionExternalChange  = "external_change"
+	ActionUndo            = "undo"
+	ActionRedo            = "redo"
+)
+
+var (
+	ErrNothingToUndo = errors.New("没有可撤销的操作")
+	ErrNothingToRedo = errors.New("没有可重做的操作")
+)
+
+// AuditEntry 审计日志条目，记录一次数据变更中改动的记录
+type AuditEntry struct {
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Actor     string         `json:"actor"`               // 操作人
+	Action    string         `json:"action"`              // 操作类型
+	Summary   string         `json:"summary"`             // 操作描述
+	TargetID  string         `json:"target_id,omitempty"` // 撤销/重做所针对的条目ID
+	Changes   []RecordChange `json:"changes,omitempty"`
+
+	// 旧版本记录变更前后的完整快照，仅用于读取已有的审计日志
+	Before *ProfitCalculatorData `json:"before,omitempty"`
+	After  *ProfitCalculatorData `json:"after,omitempty"`
+}
+
+// RecordChange 一条记录的变更，键与同步记录相同（例如 "profit/<UUID>"）；
+// Before 为空表示新增，After 为空表示删除
+type RecordChange struct {
+	Key    string          `json:"key"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditLog 审计日志存储接口（仅追加）
+type AuditLog interface {
+	Append(entry AuditEntry) error
+	Entries() ([]AuditEntry, error)
+}
+
+// JSONLAuditLog 基于 JSON Lines 文件的审计日志，每行一条记录
+type JSONLAuditLog struct {
+	filepath string
+}
+
+// NewJSONLAuditLog 创建新的审计日志
+func NewJSONLAuditLog(filepath string) *JSONLAuditLog {
+	return &JSONLAuditLog{
+		filepath: filepath,
+	}
+}
+
+// Append 追加一条审计记录
+func (l *JSONLAuditLog) Append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.filepath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Entries 按写入顺序读取全部审计记录
+func (l *JSONLAuditLog) Entries() ([]AuditEntry, error) {
+	f, err := os.Open(l.filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AuditEntry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseAuditLines(f)
+}
+
+// EncryptedAuditLog 加密的审计日志，每条记录单独加密为一行，追加时不重写整个文件
+type EncryptedAuditLog struct {
+	file *secure_storage.LineFile
+}
+
+// NewEncryptedAuditLog 创建新的加密审计日志（需要先调用 Unlock）
+func NewEncryptedAuditLog(filepath string) *EncryptedAuditLog {
+	return &EncryptedAuditLog{
+		file: secure_storage.NewLineFile(filepath),
+	}
+}
+
+// Unlock 使用密码解锁审计日志
+func (l *EncryptedAuditLog) Unlock(passphrase string) error {
+	return l.file.Unlock(passphrase)
+}
+
+// Append 追加一条审计记录
+func (l *EncryptedAuditLog) Append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return l.file.Append(line)
+}
+
+// Entries 按写入顺序读取全部审计记录
+func (l *EncryptedAuditLog) Entries() ([]AuditEntry, error) {
+	lines, err := l.file.Lines()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AuditEntry{}, nil
+		}
+		return nil, err
+	}
+
+	return parseAuditLines(bytes.NewReader(bytes.Join(lines, []byte("\n"))))
+}
+
+// parseAuditLines 逐行解析 JSON Lines 格式的审计记录
+func parseAuditLines(r io.Reader) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// 跳过损坏的行（例如写入中途崩溃留下的半行）
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// History 基于审计日志的撤销/重做管理
+type History struct {
+	log     AuditLog
+	actor   string
+	entries []AuditEntry
+	undo    []AuditEntry // 可撤销的变更（栈顶在末尾）
+	redo    []AuditEntry // 可重做的变更（栈顶在末尾）
+}
+
+// NewHistory 创建历史管理器，并从审计日志重建撤销/重做栈
+func NewHistory(log AuditLog) (*History, error) {
+	h := &History{
+		log:   log,
+		actor: currentActor(),
+	}
+
+	entries, err := log.Entries()
+	if err != nil {
+		return h, err
+	}
+	for _, entry := range entries {
+		h.apply(entry)
+	}
+
+	return h, nil
+}
+
+// Record 记录一次数据变更，只保存改动的记录
+func (h *History) Record(action, summary string, before, after *ProfitCalculatorData) error {
+	changes, err := diffData(before, after)
+	if err != nil {
+		return err
+	}
+
+	return h.append(AuditEntry{
+		Action:  action,
+		Summary: summary,
+		Changes: changes,
+	})
+}
+
+// Undo 撤销最近一次变更，返回恢复后的数据
+func (h *History) Undo(current *ProfitCalculatorData) (*ProfitCalculatorData, error) {
+	if len(h.undo) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	target := h.undo[len(h.undo)-1]
+	restored, err := target.revert(current)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffData(current, restored)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLockedChanges(current, changes); err != nil {
+		return nil, err
+	}
+	if err := h.recordTarget(ActionUndo, "撤销："+target.Summary, target.ID, changes); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// Redo 重做最近一次撤销的变更，返回恢复后的数据
+func (h *History) Redo(current *ProfitCalculatorData) (*ProfitCalculatorData, error) {
+	if len(h.redo) == 0 {
+		return nil, ErrNothingToRedo
+	}
+
+	target := h.redo[len(h.redo)-1]
+	restored, err := target.reapply(current)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffData(current, restored)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLockedChanges(current, changes); err != nil {
+		return nil, err
+	}
+	if err := h.recordTarget(ActionRedo, "重做："+target.Summary, target.ID, changes); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// recordTarget 记录一次撤销或重做
+func (h *History) recordTarget(action, summary, targetID string, changes []RecordChange) error {
+	return h.append(AuditEntry{
+		Action:   action,
+		Summary:  summary,
+		TargetID: targetID,
+		Changes:  changes,
+	})
+}
+
+// revert 在当前数据上撤销这条记录的变更
+func (e AuditEntry) revert(current *ProfitCalculatorData) (*ProfitCalculatorData, error) {
+	if e.Changes == nil && e.Before != nil {
+		return CloneData(e.Before), nil
+	}
+	return applyChanges(current, e.Changes, true)
+}
+
+// reapply 在当前数据上重新应用这条记录的变更
+func (e AuditEntry) reapply(current *ProfitCalculatorData) (*ProfitCalculatorData, error) {
+	if e.Changes == nil && e.After != nil {
+		return CloneData(e.After), nil
+	}
+	return applyChanges(current, e.Changes, false)
+}
+
+// CanUndo 是否可以撤销
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo 是否可以重做
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Entries 返回全部审计记录（最新的在前）
+func (h *History) Entries() []AuditEntry {
+	entries := make([]AuditEntry, len(h.entries))
+	for i, entry := range h.entries {
+		entries[len(h.entries)-1-i] = entry
+	}
+	return entries
+}
+
+// append 写入审计日志并更新内存中的栈
+func (h *History) append(entry AuditEntry) error {
+	entry.ID = uuid.New().String()
+	entry.Timestamp = time.Now()
+	entry.Actor = h.actor
+
+	if err := h.log.Append(entry); err != nil {
+		return err
+	}
+	h.apply(entry)
+	return nil
+}
+
+// apply 根据一条审计记录更新撤销/重做栈
+func (h *History) apply(entry AuditEntry) {
+	h.entries = append(h.entries, entry)
+
+	switch entry.Action {
+	case ActionUndo:
+		if n := len(h.undo); n > 0 && h.undo[n-1].ID == entry.TargetID {
+			h.redo = append(h.redo, h.undo[n-1])
+			h.undo = h.undo[:n-1]
+		}
+	case ActionRedo:
+		if n := len(h.redo); n > 0 && h.redo[n-1].ID == entry.TargetID {
+			h.undo = append(h.undo, h.redo[n-1])
+			h.redo = h.redo[:n-1]
+		}
+	default:
+		h.undo = append(h.undo, entry)
+		h.redo = nil
+	}
+}
+
+// CloneData 深拷贝收益数据
+func CloneData(data *ProfitCalculatorData) *ProfitCalculatorData {
+	if data == nil {
+		return nil
+	}
+
+	clone := &ProfitCalculatorData{
+		Investors:      make([]Investor, len(data.Investors)),
+		MonthlyProfits: make([]MonthlyProfit, len(data.MonthlyProfits)),
+		LockedBefore:   data.LockedBefore,
+	}
+	copy(clone.Investors, data.Investors)
+	if data.RecurringProfits != nil {
+		clone.RecurringProfits = make([]RecurringProfit, len(data.RecurringProfits))
+		copy(clone.RecurringProfits, data.RecurringProfits)
+	}
+	for i, profit := range data.MonthlyProfits {
+		distributions := make(map[string]float64, len(profit.Distributions))
+		for id, amount := range profit.Distributions {
+			distributions[id] = amount
+		}
+		profit.Distributions = distributions
+		clone.MonthlyProfits[i] = profit
+	}
+
+	return clone
+}
+
+// diffData 比较两份数据，返回改动的记录（按键排序）
+func diffData(before, after *ProfitCalculatorData) ([]RecordChange, error) {
+	empty := &ProfitCalculatorData{}
+	if before == nil {
+		before = empty
+	}
+	if after == nil {
+		after = empty
+	}
+
+	beforeRecords, err := syncRecords(before)
+	if err != nil {
+		return nil, err
+	}
+	afterRecords, err := syncRecords(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []RecordChange
+	for key, old := range beforeRecords {
+		if raw := afterRecords[key]; !sameSyncRecord(old, raw) {
+			changes = append(changes, RecordChange{Key: key, Before: old, After: raw})
+		}
+	}
+	for key, raw := range afterRecords {
+		if _, ok := beforeRecords[key]; !ok {
+			changes = append(changes, RecordChange{Key: key, After: raw})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// applyChanges 在数据副本上应用变更；reverse 为 true 时恢复到变更前。
+// 恢复的记录按创建时间放回原来的位置
+func applyChanges(data *ProfitCalculatorData, changes []RecordChange, reverse bool) (*ProfitCalculatorData, error) {
+	result := CloneData(data)
+	if result == nil {
+		result = &ProfitCalculatorData{
+			Investors:      []Investor{},
+			MonthlyProfits: []MonthlyProfit{},
+		}
+	}
+
+	for _, change := range changes {
+		raw := change.After
+		if reverse {
+			raw = change.Before
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(change.Key, syncInvestorPrefix):
+			id := strings.TrimPrefix(change.Key, syncInvestorPrefix)
+			for i := range result.Investors {
+				if result.Investors[i].ID == id {
+					result.Investors = append(result.Investors[:i], result.Investors[i+1:]...)
+					break
+				}
+			}
+			var investor Investor
+			if raw != nil {
+				if err = json.Unmarshal(raw, &investor); err == nil {
+					i := sort.Search(len(result.Investors), func(i int) bool {
+						return result.Investors[i].CreatedAt.After(investor.CreatedAt)
+					})
+					result.Investors = append(result.Investors, Investor{})
+					copy(result.Investors[i+1:], result.Investors[i:])
+					result.Investors[i] = investor
+				}
+			}
+		case strings.HasPrefix(change.Key, syncProfitPrefix):
+			id := strings.TrimPrefix(change.Key, syncProfitPrefix)
+			for i := range result.MonthlyProfits {
+				if result.MonthlyProfits[i].ID == id {
+					result.MonthlyProfits = append(result.MonthlyProfits[:i], result.MonthlyProfits[i+1:]...)
+					break
+				}
+			}
+			var profit MonthlyProfit
+			if raw != nil {
+				if err = json.Unmarshal(raw, &profit); err == nil {
+					i := sort.Search(len(result.MonthlyProfits), func(i int) bool {
+						return result.MonthlyProfits[i].CreatedAt.After(profit.CreatedAt)
+					})
+					result.MonthlyProfits = append(result.MonthlyProfits, MonthlyProfit{})
+					copy(result.MonthlyProfits[i+1:], result.MonthlyProfits[i:])
+					result.MonthlyProfits[i] = profit
+				}
+			}
+		case strings.HasPrefix(change.Key, syncRecurringPrefix):
+			id := strings.TrimPrefix(change.Key, syncRecurringPrefix)
+			for i := range result.RecurringProfits {
+				if result.RecurringProfits[i].ID == id {
+					result.RecurringProfits = append(result.RecurringProfits[:i], result.RecurringProfits[i+1:]...)
+					break
+				}
+			}
+			var recurring RecurringProfit
+			if raw != nil {
+				if err = json.Unmarshal(raw, &recurring); err == nil {
+					i := sort.Search(len(result.RecurringProfits), func(i int) bool {
+						return result.RecurringProfits[i].CreatedAt.After(recurring.CreatedAt)
+					})
+					result.RecurringProfits = append(result.RecurringProfits, RecurringProfit{})
+					copy(result.RecurringProfits[i+1:], result.RecurringProfits[i:])
+					result.RecurringProfits[i] = recurring
+				}
+			}
+		case change.Key == syncLockedBeforeKey:
+			result.LockedBefore = time.Time{}
+			if raw != nil {
+				err = json.Unmarshal(raw, &result.LockedBefore)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析审计记录 %s 失败: %w", change.Key, err)
+		}
+	}
+
+	return result, nil
+}
+
+// checkLockedChanges 检查撤销或重做的变更是否涉及已锁定的期间：新增、修改或删除锁定期间内的收益记录，
+// 以及改变期间锁定本身（锁定和解锁只能通过期间锁定对话框进行，以便记入操作历史）
+func checkLockedChanges(data *ProfitCalculatorData, changes []RecordChange) error {
+	for _, change := range changes {
+		switch {
+		case strings.HasPrefix(change.Key, syncProfitPrefix):
+			for _, raw := range []json.RawMessage{change.Before, change.After} {
+				var profit MonthlyProfit
+				if raw != nil && json.Unmarshal(raw, &profit) == nil && data.IsLocked(profit.Date) {
+					return ErrPeriodLocked
+				}
+			}
+		case change.Key == syncLockedBeforeKey:
+			return ErrPeriodLocked
+		}
+	}
+	return nil
+}
+
+// currentActor 获取当前操作系统用户名
+func currentActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,282 @@
+package profit_calculator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/storage_recovery"
+)
+
+// 审计操作的中文名称
+var actionNames = map[string]string{
+	ActionAddInvestor:     "添加投资者",
+	ActionEditInvestor:    "编辑投资者",
+	ActionDeleteInvestor:  "删除投资者",
+	ActionAddProfit:       "添加收益",
+	ActionEditProfit:      "修改收益",
+	ActionDeleteProfit:    "删除收益",
+	ActionAddRecurring:    "添加定期收益",
+	ActionEditRecurring:   "修改定期收益",
+	ActionDeleteRecurring: "删除定期收益",
+	ActionRunRecurring:    "生成定期收益",
+	ActionLockPeriod:      "锁定期间",
+	ActionUnlockPeriod:    "解锁期间",
+	ActionSync:            "文件夹同步",
+	ActionExternalChange:  "外部修改",
+	ActionUndo:            "撤销",
+	ActionRedo:            "重做",
+}
+
+// commitChange 保存数据并记录审计日志。
+// 保存失败但可以重试时修改保留在队列中并照常记录；无法重试时恢复修改前的数据，不记入审计日志。
+// 数据文件被其他程序修改时，选择覆盖后才记录，选择重新加载则放弃本次修改
+func (ui *ProfitCalculatorUI) commitChange(action, summary string, before *ProfitCalculatorData) {
+	record := func() {
+		if ui.history == nil {
+			return
+		}
+		if err := ui.history.Record(action, summary, before, ui.data); err != nil {
+			dialog.ShowError(errors.New("写入审计日志失败: "+err.Error()), ui.window)
+		}
+	}
+
+	err := ui.saveChange(before, record)
+	switch {
+	case err == nil || storage_recovery.Retryable(err):
+		record()
+	case errors.Is(err, file_watch.ErrChanged):
+		// 等待用户选择覆盖还是重新加载
+	default:
+		ui.data = before
+		ui.refreshUI()
+	}
+}
+
+// undo 撤销最近一次操作
+func (ui *ProfitCalculatorUI) undo() {
+	if ui.history == nil {
+		return
+	}
+
+	restored, err := ui.history.Undo(ui.data)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	ui.data = restored
+	ui.saveData()
+	ui.refreshUI()
+}
+
+// redo 重做最近一次撤销的操作
+func (ui *ProfitCalculatorUI) redo() {
+	if ui.history == nil {
+		return
+	}
+
+	restored, err := ui.history.Redo(ui.data)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	ui.data = restored
+	ui.saveData()
+	ui.refreshUI()
+}
+
+// 撤销/重做快捷键（Ctrl/Cmd+Z、Ctrl/Cmd+Shift+Z、Ctrl/Cmd+Y）
+var (
+	undoShortcut = &desktop.CustomShortcut{
+		KeyName:  fyne.KeyZ,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}
+	redoShortcuts = []*desktop.CustomShortcut{
+		{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift},
+		{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault},
+	}
+)
+
+// SetActive 收益计算页面切换到前台或后台时调用：快捷键注册在整个窗口上，
+// 只在页面处于前台时注册撤销/重做快捷键，避免在其他页面按下时修改收益数据
+func (ui *ProfitCalculatorUI) SetActive(active bool) {
+	if active == ui.shortcutsActive {
+		return
+	}
+	ui.shortcutsActive = active
+
+	canvas := ui.window.Canvas()
+	if !active {
+		canvas.RemoveShortcut(undoShortcut)
+		for _, shortcut := range redoShortcuts {
+			canvas.RemoveShortcut(shortcut)
+		}
+		return
+	}
+
+	canvas.AddShortcut(undoShortcut, func(fyne.Shortcut) {
+		ui.undo()
+	})
+	for _, shortcut := range redoShortcuts {
+		canvas.AddShortcut(shortcut, func(fyne.Shortcut) {
+			ui.redo()
+		})
+	}
+}
+
+// showAuditHistoryDialog 显示操作历史
+func (ui *ProfitCalculatorUI) showAuditHistoryDialog() {
+	if ui.history == nil {
+		dialog.ShowError(errors.New("审计日志不可用"), ui.window)
+		return
+	}
+
+	entries := ui.history.Entries()
+	if len(entries) == 0 {
+		dialog.ShowInformation("操作历史", "还没有任何操作记录", ui.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			timeLabel := widget.NewLabel("时间")
+			actionLabel := widget.NewLabelWithStyle("操作", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			summaryLabel := widget.NewLabel("描述")
+			return container.NewVBox(
+				container.NewHBox(timeLabel, actionLabel),
+				summaryLabel,
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(entries) {
+				return
+			}
+
+			entry := entries[id]
+			vbox := obj.(*fyne.Container)
+			headerRow := vbox.Objects[0].(*fyne.Container)
+
+			headerRow.Objects[0].(*widget.Label).SetText(entry.Timestamp.Format("2006-01-02 15:04:05"))
+			headerRow.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%s · %s", actionNames[entry.Action], entry.Actor))
+			vbox.Objects[1].(*widget.Label).SetText(entry.Summary)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		ui.showAuditEntryDialog(entries[id])
+		list.UnselectAll()
+	}
+
+	undoButton := widget.NewButton("撤销", func() {
+		ui.undo()
+	})
+	redoButton := widget.NewButton("重做", func() {
+		ui.redo()
+	})
+	if !ui.history.CanUndo() {
+		undoButton.Disable()
+	}
+	if !ui.history.CanRedo() {
+		redoButton.Disable()
+	}
+
+	content := container.NewBorder(
+		widget.NewLabel("点击条目查看变更前后的数据"),
+		container.NewHBox(undoButton, redoButton),
+		nil, nil,
+		list,
+	)
+
+	d := dialog.NewCustom("操作历史", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(520, 600))
+	d.Show()
+}
+
+// showAuditEntryDialog 显示单条审计记录的变更前后数据
+func (ui *ProfitCalculatorUI) showAuditEntryDialog(entry AuditEntry) {
+	beforeEntry := widget.NewMultiLineEntry()
+	beforeEntry.SetText(ui.formatChanges(entry, true))
+	beforeEntry.Disable()
+
+	afterEntry := widget.NewMultiLineEntry()
+	afterEntry.SetText(ui.formatChanges(entry, false))
+	afterEntry.Disable()
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle(entry.Summary, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel(fmt.Sprintf("%s · %s", entry.Timestamp.Format("2006-01-02 15:04:05"), entry.Actor)),
+		),
+		nil, nil, nil,
+		container.NewGridWithColumns(2,
+			container.NewBorder(widget.NewLabel("变更前"), nil, nil, nil, beforeEntry),
+			container.NewBorder(widget.NewLabel("变更后"), nil, nil, nil, afterEntry),
+		),
+	)
+
+	d := dialog.NewCustom("变更详情", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(760, 560))
+	d.Show()
+}
+
+// formatChanges 将审计记录中改动的记录格式化为可读文本，before 为 true 时显示变更前的版本；
+// 旧版本的审计记录显示完整快照
+func (ui *ProfitCalculatorUI) formatChanges(entry AuditEntry, before bool) string {
+	if entry.Changes == nil && (entry.Before != nil || entry.After != nil) {
+		if before {
+			return formatSnapshot(entry.Before)
+		}
+		return formatSnapshot(entry.After)
+	}
+	if len(entry.Changes) == 0 {
+		return "(无变更)"
+	}
+
+	var parts []string
+	for _, change := range entry.Changes {
+		raw, other := change.After, change.Before
+		if before {
+			raw, other = change.Before, change.After
+		}
+
+		title := ui.DescribeSyncRecord(change.Key, raw)
+		text := "(无)"
+		if raw != nil {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, raw, "", "  "); err == nil {
+				text = buf.String()
+			} else {
+				text = string(raw)
+			}
+		} else {
+			title = ui.DescribeSyncRecord(change.Key, other)
+		}
+		parts = append(parts, "# "+title+"\n"+text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// formatSnapshot 将数据快照格式化为可读文本
+func formatSnapshot(data *ProfitCalculatorData) string {
+	if data == nil {
+		return "(无)"
+	}
+	text, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(text)
+}
@@ -0,0 +1,118 @@
+package profit_calculator
+
+import (
+	"errors"
+	"strings"
+
+	"my_portfolio/secure_storage"
+)
+
+const (
+	// ToolID 工具标识，用于配置文件
+	ToolID = "profit_calculator"
+
+	// DefaultDataFile 默认数据文件名
+	DefaultDataFile = "profit_records.json"
+)
+
+// AuditPathFor 返回数据文件对应的审计日志路径
+func AuditPathFor(dataPath string) string {
+	return strings.TrimSuffix(dataPath, ".json") + "_audit.jsonl"
+}
+
+// openHistory 打开审计日志；passphrase 非空时使用加密审计日志
+func (ui *ProfitCalculatorUI) openHistory(passphrase string) error {
+	auditPath := AuditPathFor(ui.storagePath)
+
+	var log AuditLog = NewJSONLAuditLog(auditPath)
+	if ui.EncryptionEnabled() {
+		encryptedLog := NewEncryptedAuditLog(auditPath)
+		if err := encryptedLog.Unlock(passphrase); err != nil {
+			return err
+		}
+		log = encryptedLog
+	}
+
+	// 加载失败时仍可继续记录新的操作
+	ui.history, _ = NewHistory(log)
+	return nil
+}
+
+// ErrEncryptionUnsupported 当前存储不支持加密
+var ErrEncryptionUnsupported = errors.New("SQLite 数据库存储暂不支持加密，请先在设置中切换回 JSON 文件存储")
+
+// Locked 加密存储是否尚未解锁
+func (ui *ProfitCalculatorUI) Locked() bool {
+	storage, ok := ui.storage.(*EncryptedStorage)
+	return ok && storage.Locked()
+}
+
+// Unlock 使用密码解锁加密存储并加载数据
+func (ui *ProfitCalculatorUI) Unlock(passphrase string) error {
+	storage, ok := ui.storage.(*EncryptedStorage)
+	if !ok {
+		return nil
+	}
+
+	if err := storage.Unlock(passphrase); err != nil {
+		return err
+	}
+	if err := ui.openHistory(passphrase); err != nil {
+		return err
+	}
+
+	ui.loadData()
+	ui.applyRecurringProfits()
+	if ui.mainContent != nil {
+		ui.refreshUI()
+	}
+	return nil
+}
+
+// EncryptionEnabled 当前是否使用加密存储
+func (ui *ProfitCalculatorUI) EncryptionEnabled() bool {
+	_, ok := ui.storage.(*EncryptedStorage)
+	return ok
+}
+
+// EnableEncryption 将数据文件和审计日志转换为加密格式并切换到加密存储
+func (ui *ProfitCalculatorUI) EnableEncryption(passphrase string) error {
+	if ui.EncryptionEnabled() {
+		return nil
+	}
+	if _, ok := ui.storage.(*SQLiteStorage); ok {
+		return ErrEncryptionUnsupported
+	}
+
+	if err := secure_storage.EncryptFile(ui.storagePath, passphrase); err != nil {
+		return err
+	}
+	if err := secure_storage.EncryptLines(AuditPathFor(ui.storagePath), passphrase); err != nil && err != secure_storage.ErrAlreadyEncrypted {
+		return err
+	}
+
+	storage := NewEncryptedStorage(ui.storagePath)
+	if err := storage.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	ui.storage = storage
+	return ui.openHistory(passphrase)
+}
+
+// DisableEncryption 将加密数据文件和审计日志转换回明文并切换到普通存储
+func (ui *ProfitCalculatorUI) DisableEncryption(passphrase string) error {
+	if !ui.EncryptionEnabled() {
+		return nil
+	}
+
+	if err := secure_storage.DecryptFile(ui.storagePath, passphrase); err != nil && err != secure_storage.ErrNotEncrypted {
+		return err
+	}
+	if err := secure_storage.DecryptLines(AuditPathFor(ui.storagePath), passphrase); err != nil && err != secure_storage.ErrNotEncrypted {
+		return err
+	}
+
+	ui.storage = NewJSONStorage(ui.storagePath)
+	return ui.openHistory("")
+}
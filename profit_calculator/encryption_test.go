@@ -0,0 +1,81 @@
+package profit_calculator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"my_portfolio/schema"
+	"my_portfolio/secure_storage"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+// TestEnableEncryptionLeavesNoPlaintext 旧版数据文件升级后启用加密，历史版本、升级前备份和损坏文件都不能留下明文
+func TestEnableEncryptionLeavesNoPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultDataFile)
+	secret := []byte("张三的投资")
+
+	legacy := `{"investors":[{"id":"a","name":"张三的投资","investment_amount":1000}],"monthly_profits":[]}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	corruptPath := path + ".corrupt-20260101-000000"
+	if err := os.WriteFile(corruptPath, []byte(legacy[:40]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := test.NewApp()
+	defer app.Quit()
+	ui := NewProfitCalculatorUIWithOptions(app.NewWindow("收益计算"), Options{DataPath: path})
+
+	migratedPath := schema.BackupPath(path, 0)
+	if _, err := os.Stat(migratedPath); err != nil {
+		t.Fatalf("升级前备份不存在: %v", err)
+	}
+
+	// 再保存一次，留下明文的历史版本
+	before := CloneData(ui.data)
+	ui.data.Investors[0].InvestmentAmount = 2000
+	ui.commitChange(ActionEditInvestor, "修改投资者 张三的投资", before)
+
+	if err := ui.EnableEncryption(testPassphrase); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, secret) {
+			t.Errorf("%s 启用加密后仍然是明文", entry.Name())
+		}
+	}
+
+	for _, copyPath := range []string{migratedPath, corruptPath} {
+		data, err := os.ReadFile(copyPath)
+		if err != nil {
+			t.Fatalf("%s 不应被删除: %v", filepath.Base(copyPath), err)
+		}
+		if _, err := secure_storage.Decrypt(data, testPassphrase); err != nil {
+			t.Errorf("%s 无法用数据文件的密码解密: %v", filepath.Base(copyPath), err)
+		}
+	}
+
+	if err := ui.DisableEncryption(testPassphrase); err != nil {
+		t.Fatalf("DisableEncryption() error = %v", err)
+	}
+	data, err := os.ReadFile(migratedPath)
+	if err != nil || !bytes.Contains(data, secret) {
+		t.Errorf("关闭加密后升级前备份应恢复为明文: %q, %v", data, err)
+	}
+}
@@ -1,6 +1,8 @@
 package profit_calculator
 
 import (
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,36 +18,61 @@ type Investor struct {
 
 // MonthlyProfit 月度收益记录结构
 type MonthlyProfit struct {
-	ID            string             `json:"id"`            // 唯一标识符 (UUID)
-	Date          time.Time          `json:"date"`          // 收益日期
-	TotalProfit   float64            `json:"total_profit"`  // 总收益金额
-	Distributions map[string]float64 `json:"distributions"` // 投资者ID -> 分配金额
-	CreatedAt     time.Time          `json:"created_at"`    // 创建时间
+	ID            string             `json:"id"`                     // 唯一标识符 (UUID)
+	Date          time.Time          `json:"date"`                   // 收益日期
+	TotalProfit   float64            `json:"total_profit"`           // 总收益金额
+	Distributions map[string]float64 `json:"distributions"`          // 投资者ID -> 分配金额
+	CreatedAt     time.Time          `json:"created_at"`             // 创建时间
+	RecurringID   string             `json:"recurring_id,omitempty"` // 生成该记录的定期收益模板ID
 }
 
 // ProfitCalculatorData 整体数据容器
 type ProfitCalculatorData struct {
-	Investors      []Investor      `json:"investors"`
-	MonthlyProfits []MonthlyProfit `json:"monthly_profits"`
+	Investors        []Investor        `json:"investors"`
+	MonthlyProfits   []MonthlyProfit   `json:"monthly_profits"`
+	RecurringProfits []RecurringProfit `json:"recurring_profits,omitempty"`
+	LockedBefore     time.Time         `json:"locked_before,omitzero"` // 早于该日期的收益记录已锁定，零值表示未锁定
 }
 
 // InvestorStats 投资者统计信息
 type InvestorStats struct {
-	InvestorID       string
-	InvestorName     string
-	InvestmentAmount float64
-	InvestmentRatio  float64 // 投资比例 (0-1)
-	TotalProfit      float64 // 累计收益
-	FinalAmount      float64 // 最终金额 (投资 + 收益)
-	ProfitCount      int     // 收益记录数
+	InvestorID       string  `json:"investor_id"`
+	InvestorName     string  `json:"investor_name"`
+	InvestmentAmount float64 `json:"investment_amount"`
+	InvestmentRatio  float64 `json:"investment_ratio"` // 投资比例 (0-1)
+	TotalProfit      float64 `json:"total_profit"`     // 累计收益
+	FinalAmount      float64 `json:"final_amount"`     // 最终金额 (投资 + 收益)
+	ProfitCount      int     `json:"profit_count"`     // 收益记录数
 }
 
 // OverallStats 整体统计信息
 type OverallStats struct {
-	TotalInvestment   float64
-	TotalProfit       float64
-	InvestorCount     int
-	ProfitRecordCount int
+	TotalInvestment   float64 `json:"total_investment"`
+	TotalProfit       float64 `json:"total_profit"`
+	InvestorCount     int     `json:"investor_count"`
+	ProfitRecordCount int     `json:"profit_record_count"`
+}
+
+// StatsSummary 整体统计和各投资者的统计，用于命令行和本地 API 输出
+type StatsSummary struct {
+	OverallStats
+	Investors []InvestorStats `json:"investors"`
+}
+
+// ProfitDetail 收益记录及其分配明细，分配结果附带投资者姓名，用于命令行和本地 API 输出
+type ProfitDetail struct {
+	ID            string               `json:"id"`
+	Date          string               `json:"date"` // YYYY-MM-DD
+	TotalProfit   float64              `json:"total_profit"`
+	Distributions []DistributionDetail `json:"distributions"`
+	RecurringID   string               `json:"recurring_id,omitempty"`
+}
+
+// DistributionDetail 单个投资者的分配金额
+type DistributionDetail struct {
+	InvestorID   string  `json:"investor_id"`
+	InvestorName string  `json:"investor_name"` // 投资者已删除时为空
+	Amount       float64 `json:"amount"`
 }
 
 // NewInvestor 创建新的投资者
@@ -69,6 +96,23 @@ func NewMonthlyProfit(date time.Time, totalProfit float64, distributions map[str
 	}
 }
 
+// ValidateProfit 校验是否可以在指定日期添加收益记录
+func ValidateProfit(data *ProfitCalculatorData, date time.Time, amount float64, now time.Time) error {
+	if len(data.Investors) == 0 {
+		return errors.New("请先添加投资者")
+	}
+	if date.After(now) {
+		return errors.New("日期不能为未来")
+	}
+	if data.IsLocked(date) {
+		return ErrPeriodLocked
+	}
+	if amount < -10000000 || amount > 10000000 {
+		return errors.New("收益金额必须在-10,000,000到10,000,000之间")
+	}
+	return nil
+}
+
 // CalculateTotalInvestment 计算总投资
 func CalculateTotalInvestment(investors []Investor) float64 {
 	total := 0.0
@@ -167,3 +211,42 @@ func CalculateOverallStats(data *ProfitCalculatorData) OverallStats {
 	
 	return stats
 }
+
+// CalculateStatsSummary 计算整体统计和每位投资者的统计
+func CalculateStatsSummary(data *ProfitCalculatorData) StatsSummary {
+	summary := StatsSummary{
+		OverallStats: CalculateOverallStats(data),
+		Investors:    make([]InvestorStats, 0, len(data.Investors)),
+	}
+	for _, investor := range data.Investors {
+		summary.Investors = append(summary.Investors, CalculateInvestorStats(investor.ID, data.Investors, data.MonthlyProfits))
+	}
+	return summary
+}
+
+// NewProfitDetail 生成收益记录的分配明细，按投资者姓名排序
+func NewProfitDetail(profit MonthlyProfit, investors []Investor) ProfitDetail {
+	names := make(map[string]string, len(investors))
+	for _, investor := range investors {
+		names[investor.ID] = investor.Name
+	}
+
+	detail := ProfitDetail{
+		ID:            profit.ID,
+		Date:          profit.Date.Format("2006-01-02"),
+		TotalProfit:   profit.TotalProfit,
+		Distributions: []DistributionDetail{},
+		RecurringID:   profit.RecurringID,
+	}
+	for id, amount := range profit.Distributions {
+		detail.Distributions = append(detail.Distributions, DistributionDetail{
+			InvestorID:   id,
+			InvestorName: names[id],
+			Amount:       amount,
+		})
+	}
+	sort.Slice(detail.Distributions, func(i, j int) bool {
+		return detail.Distributions[i].InvestorName < detail.Distributions[j].InvestorName
+	})
+	return detail
+}
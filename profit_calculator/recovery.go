@@ -0,0 +1,82 @@
+package profit_calculator
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/storage_recovery"
+)
+
+// showLoadError 显示加载失败的恢复对话框
+func (ui *ProfitCalculatorUI) showLoadError(err error) {
+	failure := storage_recovery.LoadFailure{
+		Title: "收益数据",
+		Err:   err,
+		Retry: func() error {
+			err := ui.tryLoadData()
+			ui.refreshUI()
+			return err
+		},
+	}
+	// 只有文件存储提供备份和原始文件
+	if _, ok := ui.storage.(watchedStorage); ok {
+		failure.Path = ui.storagePath
+	}
+
+	storage_recovery.ShowLoadError(ui.window, failure)
+}
+
+// createSaveStatus 创建保存失败提示栏，没有待重试的保存时隐藏
+func (ui *ProfitCalculatorUI) createSaveStatus() *fyne.Container {
+	ui.saveStatusLabel = widget.NewLabel("")
+	ui.saveStatusLabel.Wrapping = fyne.TextWrapWord
+
+	retryButton := widget.NewButtonWithIcon("立即重试", theme.ViewRefreshIcon(), func() {
+		ui.saveQueue.Flush()
+	})
+
+	status := container.NewBorder(nil, nil, nil, retryButton, ui.saveStatusLabel)
+	if pending, _ := ui.saveQueue.Pending(); pending == 0 {
+		status.Hide()
+	}
+	return status
+}
+
+// onSaveQueueChanged 更新保存失败提示栏；重试中的保存被放弃时提示用户
+func (ui *ProfitCalculatorUI) onSaveQueueChanged(pending int, err error) {
+	fyne.Do(func() {
+		if ui.saveStatus != nil {
+			if pending > 0 {
+				ui.saveStatusLabel.SetText(fmt.Sprintf("⚠️ 有未保存的修改，正在自动重试：%v", err))
+				ui.saveStatus.Show()
+			} else {
+				ui.saveStatus.Hide()
+			}
+		}
+
+		if pending > 0 || err == nil {
+			return
+		}
+		if errors.Is(err, file_watch.ErrChanged) {
+			ui.confirmOverwrite(nil, nil)
+			return
+		}
+		dialog.ShowError(errors.New("未能保存的修改已放弃: "+err.Error()), ui.window)
+	})
+}
+
+// FlushPendingSaves 立即重试所有保存失败的修改，仍未保存时返回错误
+func (ui *ProfitCalculatorUI) FlushPendingSaves() error {
+	ui.saveQueue.Flush()
+	if pending, err := ui.saveQueue.Pending(); pending > 0 {
+		return err
+	}
+	return nil
+}
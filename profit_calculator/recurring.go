@@ -0,0 +1,137 @@
+package profit_calculator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPeriodLocked = errors.New("该日期所在期间已锁定，请先解锁")
+)
+
+// RecurringProfit 定期收益模板，按月在指定日期生成收益记录
+type RecurringProfit struct {
+	ID            string    `json:"id"`                      // 唯一标识符 (UUID)
+	Name          string    `json:"name"`                    // 模板名称
+	Amount        float64   `json:"amount"`                  // 每期收益金额
+	DayOfMonth    int       `json:"day_of_month"`            // 每月生成日（1-31，超出当月天数时取月末）
+	StartDate     time.Time `json:"start_date"`              // 开始日期
+	EndDate       time.Time `json:"end_date,omitzero"`       // 结束日期，零值表示无限期
+	LastGenerated time.Time `json:"last_generated,omitzero"` // 最近一次生成的收益日期
+	Enabled       bool      `json:"enabled"`                 // 是否启用
+	CreatedAt     time.Time `json:"created_at"`              // 创建时间
+}
+
+// NewRecurringProfit 创建新的定期收益模板
+func NewRecurringProfit(name string, amount float64, dayOfMonth int, startDate time.Time) *RecurringProfit {
+	return &RecurringProfit{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Amount:     amount,
+		DayOfMonth: dayOfMonth,
+		StartDate:  startDate,
+		Enabled:    true,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// Validate 验证定期收益模板
+func (r *RecurringProfit) Validate() error {
+	if r.Name == "" {
+		return errors.New("模板名称不能为空")
+	}
+	if r.Amount < -10000000 || r.Amount > 10000000 {
+		return errors.New("收益金额必须在-10,000,000到10,000,000之间")
+	}
+	if r.DayOfMonth < 1 || r.DayOfMonth > 31 {
+		return errors.New("生成日必须在1到31之间")
+	}
+	if !r.EndDate.IsZero() && r.EndDate.Before(r.StartDate) {
+		return errors.New("结束日期不能早于开始日期")
+	}
+	return nil
+}
+
+// dueDates 返回 (after, now] 区间内应生成收益的日期
+func (r *RecurringProfit) dueDates(now time.Time) []time.Time {
+	var dates []time.Time
+
+	cursor := r.StartDate
+	if !r.LastGenerated.IsZero() {
+		cursor = r.LastGenerated.AddDate(0, 0, 1)
+	}
+
+	year, month, _ := cursor.Date()
+	for {
+		date := occurrence(year, month, r.DayOfMonth, cursor.Location())
+		if date.After(now) {
+			break
+		}
+		if !r.EndDate.IsZero() && date.After(r.EndDate) {
+			break
+		}
+		if !date.Before(cursor) {
+			dates = append(dates, date)
+		}
+
+		month++
+		if month > time.December {
+			month = time.January
+			year++
+		}
+	}
+
+	return dates
+}
+
+// occurrence 返回指定年月的生成日期，超出当月天数时取月末
+func occurrence(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// GenerateRecurringProfits 为所有启用的模板生成截至 now 的收益记录，返回生成的记录数
+func GenerateRecurringProfits(data *ProfitCalculatorData, now time.Time) int {
+	if len(data.Investors) == 0 {
+		return 0
+	}
+
+	generated := 0
+	for i := range data.RecurringProfits {
+		template := &data.RecurringProfits[i]
+		if !template.Enabled {
+			continue
+		}
+
+		for _, date := range template.dueDates(now) {
+			template.LastGenerated = date
+			if data.IsLocked(date) {
+				continue
+			}
+
+			profit := NewMonthlyProfit(date, template.Amount, DistributeProfit(template.Amount, data.Investors))
+			profit.RecurringID = template.ID
+			data.MonthlyProfits = append(data.MonthlyProfits, *profit)
+			generated++
+		}
+	}
+
+	return generated
+}
+
+// IsLocked 判断日期是否处于已锁定的期间。
+// 手动添加的收益日期是 UTC 零点，定期收益生成的日期是本地零点，所以只比较日历日期
+func (d *ProfitCalculatorData) IsLocked(date time.Time) bool {
+	return !d.LockedBefore.IsZero() && calendarDate(date).Before(calendarDate(d.LockedBefore))
+}
+
+// calendarDate 返回时间在其自身时区中的日历日期（UTC 零点），用于比较不同时区保存的日期
+func calendarDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,370 @@
+package profit_calculator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// applyRecurringProfits 启动时根据定期收益模板补齐应生成的收益记录
+func (ui *ProfitCalculatorUI) applyRecurringProfits() {
+	before := CloneData(ui.data)
+	count := GenerateRecurringProfits(ui.data, time.Now())
+	if count == 0 {
+		// 仅推进了生成进度（例如跳过锁定期间）时也需要保存
+		if !recurringProgressChanged(before, ui.data) {
+			return
+		}
+		ui.saveData()
+		return
+	}
+
+	ui.commitChange(ActionRunRecurring, fmt.Sprintf("自动生成 %d 条定期收益记录", count), before)
+}
+
+// recurringProgressChanged 判断模板生成进度是否发生变化
+func recurringProgressChanged(before, after *ProfitCalculatorData) bool {
+	for i := range after.RecurringProfits {
+		if !after.RecurringProfits[i].LastGenerated.Equal(before.RecurringProfits[i].LastGenerated) {
+			return true
+		}
+	}
+	return false
+}
+
+// showRecurringDialog 显示定期收益模板管理对话框
+func (ui *ProfitCalculatorUI) showRecurringDialog() {
+	var d dialog.Dialog
+	var list *widget.List
+
+	list = widget.NewList(
+		func() int {
+			return len(ui.data.RecurringProfits)
+		},
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabelWithStyle("名称", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			detailLabel := widget.NewLabel("详情")
+			editBtn := widget.NewButton("修改", nil)
+			toggleBtn := widget.NewButton("停用", nil)
+			deleteBtn := widget.NewButton("删除", nil)
+			return container.NewVBox(
+				nameLabel,
+				detailLabel,
+				container.NewHBox(editBtn, toggleBtn, deleteBtn),
+				widget.NewSeparator(),
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(ui.data.RecurringProfits) {
+				return
+			}
+
+			template := ui.data.RecurringProfits[id]
+			vbox := obj.(*fyne.Container)
+			btnRow := vbox.Objects[2].(*fyne.Container)
+
+			status := "✅"
+			if !template.Enabled {
+				status = "⏸"
+			}
+			vbox.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s %s", status, template.Name))
+
+			detail := fmt.Sprintf("每月 %d 日 %s，自 %s 起", template.DayOfMonth, formatCurrency(template.Amount), template.StartDate.Format("2006-01-02"))
+			if !template.EndDate.IsZero() {
+				detail += "，至 " + template.EndDate.Format("2006-01-02")
+			}
+			if !template.LastGenerated.IsZero() {
+				detail += "（最近生成：" + template.LastGenerated.Format("2006-01-02") + "）"
+			}
+			vbox.Objects[1].(*widget.Label).SetText(detail)
+
+			editBtn := btnRow.Objects[0].(*widget.Button)
+			toggleBtn := btnRow.Objects[1].(*widget.Button)
+			deleteBtn := btnRow.Objects[2].(*widget.Button)
+			if template.Enabled {
+				toggleBtn.SetText("停用")
+			} else {
+				toggleBtn.SetText("启用")
+			}
+
+			editBtn.OnTapped = func() {
+				ui.showRecurringFormDialog(&template, func() {
+					d.Hide()
+					ui.showRecurringDialog()
+				})
+			}
+			toggleBtn.OnTapped = func() {
+				ui.toggleRecurring(template.ID)
+				list.Refresh()
+			}
+			deleteBtn.OnTapped = func() {
+				ui.deleteRecurring(template.ID, func() {
+					d.Hide()
+					ui.showRecurringDialog()
+				})
+			}
+		},
+	)
+
+	addButton := widget.NewButton("添加模板", func() {
+		ui.showRecurringFormDialog(nil, func() {
+			d.Hide()
+			ui.showRecurringDialog()
+		})
+	})
+	runButton := widget.NewButton("立即生成到期记录", func() {
+		ui.applyRecurringProfits()
+		ui.refreshUI()
+		list.Refresh()
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("启用的模板会在应用启动时自动生成到期的收益记录"),
+		container.NewHBox(addButton, runButton),
+		nil, nil,
+		list,
+	)
+
+	d = dialog.NewCustom("定期收益", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(520, 520))
+	d.Show()
+}
+
+// showRecurringFormDialog 显示添加定期收益模板对话框；existing 不为空时修改该模板，
+// 已生成的收益记录和生成进度保持不变
+func (ui *ProfitCalculatorUI) showRecurringFormDialog(existing *RecurringProfit, onSaved func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("例如：房租收入")
+
+	amountEntry := widget.NewEntry()
+	amountEntry.SetPlaceHolder("每期收益金额")
+
+	dayEntry := widget.NewEntry()
+	dayEntry.SetText("1")
+
+	startEntry := widget.NewEntry()
+	startEntry.SetPlaceHolder("YYYY-MM-DD")
+	startEntry.SetText(time.Now().Format("2006-01-02"))
+
+	endEntry := widget.NewEntry()
+	endEntry.SetPlaceHolder("YYYY-MM-DD（可留空）")
+
+	items := []*widget.FormItem{
+		{Text: "名称", Widget: nameEntry},
+		{Text: "金额", Widget: amountEntry},
+		{Text: "每月生成日", Widget: dayEntry},
+		{Text: "开始日期", Widget: startEntry},
+		{Text: "结束日期", Widget: endEntry},
+	}
+
+	title, confirm := "添加定期收益", "添加"
+	if existing != nil {
+		title, confirm = "修改定期收益", "保存"
+		nameEntry.SetText(existing.Name)
+		amountEntry.SetText(fmt.Sprintf("%.2f", existing.Amount))
+		dayEntry.SetText(strconv.Itoa(existing.DayOfMonth))
+		startEntry.SetText(existing.StartDate.Format("2006-01-02"))
+		if !existing.EndDate.IsZero() {
+			endEntry.SetText(existing.EndDate.Format("2006-01-02"))
+		}
+	}
+
+	dialog.ShowForm(title, confirm, "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		amount, err := parseAmount(amountEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New("请输入有效的金额"), ui.window)
+			return
+		}
+
+		day, err := strconv.Atoi(strings.TrimSpace(dayEntry.Text))
+		if err != nil {
+			dialog.ShowError(errors.New("请输入有效的生成日"), ui.window)
+			return
+		}
+
+		startDate, err := time.ParseInLocation("2006-01-02", startEntry.Text, time.Local)
+		if err != nil {
+			dialog.ShowError(errors.New("开始日期格式无效，请使用 YYYY-MM-DD 格式"), ui.window)
+			return
+		}
+
+		template := NewRecurringProfit(strings.TrimSpace(nameEntry.Text), amount, day, startDate)
+		if existing != nil {
+			template.ID = existing.ID
+			template.LastGenerated = existing.LastGenerated
+			template.Enabled = existing.Enabled
+			template.CreatedAt = existing.CreatedAt
+		}
+		if strings.TrimSpace(endEntry.Text) != "" {
+			endDate, err := time.ParseInLocation("2006-01-02", endEntry.Text, time.Local)
+			if err != nil {
+				dialog.ShowError(errors.New("结束日期格式无效，请使用 YYYY-MM-DD 格式"), ui.window)
+				return
+			}
+			template.EndDate = endDate
+		}
+
+		if err := template.Validate(); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+
+		before := CloneData(ui.data)
+		if existing == nil {
+			ui.data.RecurringProfits = append(ui.data.RecurringProfits, *template)
+			ui.commitChange(ActionAddRecurring, fmt.Sprintf("添加定期收益 %s（每月 %d 日 %s）", template.Name, template.DayOfMonth, formatCurrency(template.Amount)), before)
+		} else {
+			found := false
+			for i := range ui.data.RecurringProfits {
+				if ui.data.RecurringProfits[i].ID == template.ID {
+					ui.data.RecurringProfits[i] = *template
+					found = true
+					break
+				}
+			}
+			if !found {
+				dialog.ShowError(errors.New("定期收益模板不存在，可能已被删除"), ui.window)
+				return
+			}
+			ui.commitChange(ActionEditRecurring, fmt.Sprintf("修改定期收益 %s（每月 %d 日 %s）", template.Name, template.DayOfMonth, formatCurrency(template.Amount)), before)
+		}
+
+		if onSaved != nil {
+			onSaved()
+		}
+	}, ui.window)
+}
+
+// toggleRecurring 启用或停用定期收益模板
+func (ui *ProfitCalculatorUI) toggleRecurring(templateID string) {
+	before := CloneData(ui.data)
+	for i := range ui.data.RecurringProfits {
+		template := &ui.data.RecurringProfits[i]
+		if template.ID != templateID {
+			continue
+		}
+
+		template.Enabled = !template.Enabled
+		state := "停用"
+		if template.Enabled {
+			state = "启用"
+		}
+		ui.commitChange(ActionEditRecurring, fmt.Sprintf("%s定期收益 %s", state, template.Name), before)
+		return
+	}
+}
+
+// deleteRecurring 删除定期收益模板（已生成的收益记录保留）
+func (ui *ProfitCalculatorUI) deleteRecurring(templateID string, onDeleted func()) {
+	var name string
+	for _, template := range ui.data.RecurringProfits {
+		if template.ID == templateID {
+			name = template.Name
+			break
+		}
+	}
+
+	dialog.ShowConfirm(
+		"确认删除",
+		fmt.Sprintf("确定要删除定期收益 %s 吗？\n\n已生成的收益记录将被保留。", name),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			before := CloneData(ui.data)
+			templates := []RecurringProfit{}
+			for _, template := range ui.data.RecurringProfits {
+				if template.ID != templateID {
+					templates = append(templates, template)
+				}
+			}
+			ui.data.RecurringProfits = templates
+			ui.commitChange(ActionDeleteRecurring, fmt.Sprintf("删除定期收益 %s", name), before)
+
+			if onDeleted != nil {
+				onDeleted()
+			}
+		},
+		ui.window,
+	)
+}
+
+// showPeriodLockDialog 显示期间锁定对话框
+func (ui *ProfitCalculatorUI) showPeriodLockDialog() {
+	status := "当前未锁定任何期间"
+	if !ui.data.LockedBefore.IsZero() {
+		status = fmt.Sprintf("🔒 %s 之前的收益记录已锁定", ui.data.LockedBefore.Format("2006-01-02"))
+	}
+
+	dateEntry := widget.NewEntry()
+	dateEntry.SetPlaceHolder("YYYY-MM-DD")
+	dateEntry.SetText(time.Now().AddDate(0, 0, 1-time.Now().Day()).Format("2006-01-02"))
+
+	var d dialog.Dialog
+
+	lockButton := widget.NewButton("锁定此日期之前的记录", func() {
+		// 与收益日期一样按 UTC 零点保存
+		date, err := time.Parse("2006-01-02", dateEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New("日期格式无效，请使用 YYYY-MM-DD 格式"), ui.window)
+			return
+		}
+		// 提前锁定日期相当于解锁其间的记录，需要先明确解锁
+		if ui.data.IsLocked(date) {
+			dialog.ShowError(fmt.Errorf("锁定日期不能早于当前的锁定日期 %s，如需缩小锁定范围请先解锁", ui.data.LockedBefore.Format("2006-01-02")), ui.window)
+			return
+		}
+
+		before := CloneData(ui.data)
+		ui.data.LockedBefore = date
+		ui.commitChange(ActionLockPeriod, fmt.Sprintf("锁定 %s 之前的收益记录", date.Format("2006-01-02")), before)
+		ui.refreshUI()
+		d.Hide()
+	})
+
+	unlockButton := widget.NewButton("解锁", func() {
+		lockedBefore := ui.data.LockedBefore
+		dialog.ShowConfirm(
+			"确认解锁",
+			fmt.Sprintf("确定要解锁 %s 之前的收益记录吗？\n\n解锁操作将记录在操作历史中。", lockedBefore.Format("2006-01-02")),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				before := CloneData(ui.data)
+				ui.data.LockedBefore = time.Time{}
+				ui.commitChange(ActionUnlockPeriod, fmt.Sprintf("解锁 %s 之前的收益记录", lockedBefore.Format("2006-01-02")), before)
+				ui.refreshUI()
+				d.Hide()
+			},
+			ui.window,
+		)
+	})
+	if ui.data.LockedBefore.IsZero() {
+		unlockButton.Disable()
+	}
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(status, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("锁定后，早于该日期的收益记录不能再添加或删除。"),
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, widget.NewLabel("锁定截止日期:"), nil, dateEntry),
+		container.NewHBox(lockButton, unlockButton),
+	)
+
+	d = dialog.NewCustom("期间锁定", "关闭", content, ui.window)
+	d.Show()
+}
@@ -0,0 +1,237 @@
+package profit_calculator
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HypotheticalContribution 假设的新增投资
+type HypotheticalContribution struct {
+	Name   string  // 投资者姓名（已存在的姓名表示追加投资）
+	Amount float64 // 投资金额
+}
+
+// SimulationParams 情景模拟参数
+type SimulationParams struct {
+	Contributions []HypotheticalContribution
+	MinReturn     float64 // 月收益率下限（如 0.01 表示 1%）
+	MaxReturn     float64 // 月收益率上限
+	Months        int     // 模拟月数
+	MonteCarlo    bool    // 是否使用蒙特卡洛模式
+	Volatility    float64 // 月收益率波动（标准差），仅蒙特卡洛模式使用
+	Runs          int     // 蒙特卡洛模拟次数
+	Seed          int64   // 随机种子，0 表示使用当前时间
+}
+
+// ProjectedInvestor 单个投资者的预测结果
+type ProjectedInvestor struct {
+	InvestorID       string
+	InvestorName     string
+	Hypothetical     bool    // 是否为假设的新投资者
+	InvestmentAmount float64 // 模拟使用的投资金额
+	StartBalance     float64 // 模拟起点余额（投资 + 已有收益）
+	Low              float64 // 悲观预测余额（下限收益率 / P10）
+	Expected         float64 // 预期余额（平均收益率 / P50）
+	High             float64 // 乐观预测余额（上限收益率 / P90）
+}
+
+// SimulationResult 情景模拟结果
+type SimulationResult struct {
+	Months     int
+	MonteCarlo bool
+	Runs       int
+	Investors  []ProjectedInvestor
+}
+
+// Validate 验证模拟参数
+func (p *SimulationParams) Validate() error {
+	if p.Months <= 0 || p.Months > 600 {
+		return errors.New("模拟月数必须在1到600之间")
+	}
+	if p.MinReturn > p.MaxReturn {
+		return errors.New("收益率下限不能大于上限")
+	}
+	if p.MinReturn <= -1 || p.MaxReturn > 10 {
+		return errors.New("月收益率超出合理范围")
+	}
+	for _, c := range p.Contributions {
+		if c.Name == "" {
+			return errors.New("假设投资者姓名不能为空")
+		}
+		if c.Amount <= 0 {
+			return errors.New("假设投资金额必须大于0")
+		}
+	}
+	if p.MonteCarlo {
+		if p.Volatility < 0 {
+			return errors.New("波动率不能为负数")
+		}
+		if p.Runs <= 0 || p.Runs > 100000 {
+			return errors.New("模拟次数必须在1到100,000之间")
+		}
+	}
+	return nil
+}
+
+// RunSimulation 基于现有数据运行情景模拟，不会修改传入的数据
+func RunSimulation(data *ProfitCalculatorData, params SimulationParams) (*SimulationResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	investors, hypothetical := applyContributions(data.Investors, params.Contributions)
+	if len(investors) == 0 {
+		return nil, errors.New("没有可用于模拟的投资者")
+	}
+
+	// 起点余额 = 当前投资 + 已有累计收益
+	start := make(map[string]float64, len(investors))
+	for _, investor := range investors {
+		stats := CalculateInvestorStats(investor.ID, investors, data.MonthlyProfits)
+		start[investor.ID] = stats.FinalAmount
+	}
+
+	result := &SimulationResult{
+		Months:     params.Months,
+		MonteCarlo: params.MonteCarlo,
+		Runs:       params.Runs,
+	}
+
+	var low, expected, high map[string]float64
+	if params.MonteCarlo {
+		low, expected, high = simulateMonteCarlo(investors, start, params)
+	} else {
+		mid := (params.MinReturn + params.MaxReturn) / 2
+		low = projectBalances(investors, start, constantRates(params.MinReturn, params.Months))
+		expected = projectBalances(investors, start, constantRates(mid, params.Months))
+		high = projectBalances(investors, start, constantRates(params.MaxReturn, params.Months))
+	}
+
+	for _, investor := range investors {
+		result.Investors = append(result.Investors, ProjectedInvestor{
+			InvestorID:       investor.ID,
+			InvestorName:     investor.Name,
+			Hypothetical:     hypothetical[investor.ID],
+			InvestmentAmount: investor.InvestmentAmount,
+			StartBalance:     start[investor.ID],
+			Low:              low[investor.ID],
+			Expected:         expected[investor.ID],
+			High:             high[investor.ID],
+		})
+	}
+
+	return result, nil
+}
+
+// applyContributions 复制投资者列表并叠加假设投资
+func applyContributions(existing []Investor, contributions []HypotheticalContribution) ([]Investor, map[string]bool) {
+	investors := make([]Investor, len(existing))
+	copy(investors, existing)
+	hypothetical := make(map[string]bool)
+
+	for _, c := range contributions {
+		found := false
+		for i := range investors {
+			if investors[i].Name == c.Name {
+				investors[i].InvestmentAmount += c.Amount
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		investor := Investor{
+			ID:               uuid.New().String(),
+			Name:             c.Name,
+			InvestmentAmount: c.Amount,
+			CreatedAt:        time.Now(),
+		}
+		investors = append(investors, investor)
+		hypothetical[investor.ID] = true
+	}
+
+	return investors, hypothetical
+}
+
+// projectBalances 按给定的逐月收益率向前推演，每月收益通过 DistributeProfit 分配
+func projectBalances(investors []Investor, start map[string]float64, rates []float64) map[string]float64 {
+	balances := make(map[string]float64, len(start))
+	for id, amount := range start {
+		balances[id] = amount
+	}
+
+	totalInvestment := CalculateTotalInvestment(investors)
+	for _, rate := range rates {
+		distributions := DistributeProfit(totalInvestment*rate, investors)
+		for id, amount := range distributions {
+			balances[id] += amount
+		}
+	}
+
+	return balances
+}
+
+// constantRates 生成固定收益率序列
+func constantRates(rate float64, months int) []float64 {
+	rates := make([]float64, months)
+	for i := range rates {
+		rates[i] = rate
+	}
+	return rates
+}
+
+// simulateMonteCarlo 蒙特卡洛模拟，返回每个投资者余额的 P10 / P50 / P90
+func simulateMonteCarlo(investors []Investor, start map[string]float64, params SimulationParams) (low, median, high map[string]float64) {
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	mean := (params.MinReturn + params.MaxReturn) / 2
+
+	outcomes := make(map[string][]float64, len(investors))
+	rates := make([]float64, params.Months)
+	for run := 0; run < params.Runs; run++ {
+		for i := range rates {
+			// 收益率服从正态分布，亏损不超过全部本金
+			rates[i] = math.Max(mean+rng.NormFloat64()*params.Volatility, -1)
+		}
+		balances := projectBalances(investors, start, rates)
+		for id, balance := range balances {
+			outcomes[id] = append(outcomes[id], balance)
+		}
+	}
+
+	low = make(map[string]float64, len(outcomes))
+	median = make(map[string]float64, len(outcomes))
+	high = make(map[string]float64, len(outcomes))
+	for id, values := range outcomes {
+		sort.Float64s(values)
+		low[id] = percentile(values, 0.10)
+		median[id] = percentile(values, 0.50)
+		high[id] = percentile(values, 0.90)
+	}
+
+	return low, median, high
+}
+
+// percentile 计算已排序切片的分位数（线性插值）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
@@ -0,0 +1,179 @@
+package profit_calculator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSimulatorDialog 显示情景模拟对话框
+func (ui *ProfitCalculatorUI) showSimulatorDialog() {
+	contributionsEntry := widget.NewMultiLineEntry()
+	contributionsEntry.SetPlaceHolder("每行一个：姓名,金额\n例如：张三,50000")
+	contributionsEntry.SetMinRowsVisible(3)
+
+	minEntry := widget.NewEntry()
+	minEntry.SetText("0.5")
+	maxEntry := widget.NewEntry()
+	maxEntry.SetText("2")
+	monthsEntry := widget.NewEntry()
+	monthsEntry.SetText("12")
+
+	volatilityEntry := widget.NewEntry()
+	volatilityEntry.SetText("1")
+	runsEntry := widget.NewEntry()
+	runsEntry.SetText("1000")
+	volatilityEntry.Disable()
+	runsEntry.Disable()
+
+	monteCarloCheck := widget.NewCheck("蒙特卡洛模式", func(checked bool) {
+		if checked {
+			volatilityEntry.Enable()
+			runsEntry.Enable()
+		} else {
+			volatilityEntry.Disable()
+			runsEntry.Disable()
+		}
+	})
+
+	items := []*widget.FormItem{
+		{Text: "假设投资", Widget: contributionsEntry, HintText: "已存在的姓名视为追加投资"},
+		{Text: "月收益率下限 (%)", Widget: minEntry},
+		{Text: "月收益率上限 (%)", Widget: maxEntry},
+		{Text: "模拟月数", Widget: monthsEntry},
+		{Text: "", Widget: monteCarloCheck},
+		{Text: "月波动率 (%)", Widget: volatilityEntry},
+		{Text: "模拟次数", Widget: runsEntry},
+	}
+
+	d := dialog.NewForm("情景模拟", "开始模拟", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		contributions, err := parseContributions(contributionsEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+
+		minReturn, err1 := parseAmount(minEntry.Text)
+		maxReturn, err2 := parseAmount(maxEntry.Text)
+		months, err3 := strconv.Atoi(strings.TrimSpace(monthsEntry.Text))
+		if err1 != nil || err2 != nil || err3 != nil {
+			dialog.ShowError(errors.New("请输入有效的收益率和月数"), ui.window)
+			return
+		}
+
+		params := SimulationParams{
+			Contributions: contributions,
+			MinReturn:     minReturn / 100,
+			MaxReturn:     maxReturn / 100,
+			Months:        months,
+			MonteCarlo:    monteCarloCheck.Checked,
+		}
+
+		if params.MonteCarlo {
+			volatility, err := parseAmount(volatilityEntry.Text)
+			if err != nil {
+				dialog.ShowError(errors.New("请输入有效的波动率"), ui.window)
+				return
+			}
+			runs, err := strconv.Atoi(strings.TrimSpace(runsEntry.Text))
+			if err != nil {
+				dialog.ShowError(errors.New("请输入有效的模拟次数"), ui.window)
+				return
+			}
+			params.Volatility = volatility / 100
+			params.Runs = runs
+		}
+
+		result, err := RunSimulation(ui.data, params)
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+
+		ui.showSimulationResult(result)
+	}, ui.window)
+	d.Resize(fyne.NewSize(480, 560))
+	d.Show()
+}
+
+// showSimulationResult 显示模拟结果
+func (ui *ProfitCalculatorUI) showSimulationResult(result *SimulationResult) {
+	var summary string
+	var lowTitle, midTitle, highTitle string
+	if result.MonteCarlo {
+		summary = fmt.Sprintf("蒙特卡洛模拟 %d 次，%d 个月后：", result.Runs, result.Months)
+		lowTitle, midTitle, highTitle = "P10", "P50", "P90"
+	} else {
+		summary = fmt.Sprintf("%d 个月后的预测余额：", result.Months)
+		lowTitle, midTitle, highTitle = "悲观", "预期", "乐观"
+	}
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(summary, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("⚠️ 模拟结果仅供参考，不会保存到记录中"),
+		widget.NewSeparator(),
+	)
+
+	for _, investor := range result.Investors {
+		name := "👤 " + investor.InvestorName
+		if investor.Hypothetical {
+			name += "（假设）"
+		}
+
+		content.Add(widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(widget.NewLabel(fmt.Sprintf(
+			"  投资金额: %s  |  当前余额: %s",
+			formatCurrency(investor.InvestmentAmount),
+			formatCurrency(investor.StartBalance),
+		)))
+		content.Add(widget.NewLabel(fmt.Sprintf(
+			"  %s: %s  |  %s: %s  |  %s: %s",
+			lowTitle, formatCurrency(investor.Low),
+			midTitle, formatCurrency(investor.Expected),
+			highTitle, formatCurrency(investor.High),
+		)))
+		content.Add(widget.NewSeparator())
+	}
+
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(460, 400))
+
+	dialog.ShowCustom("模拟结果", "关闭", scroll, ui.window)
+}
+
+// parseContributions 解析假设投资输入（每行 "姓名,金额"）
+func parseContributions(text string) ([]HypotheticalContribution, error) {
+	var contributions []HypotheticalContribution
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Split(strings.ReplaceAll(line, "，", ","), ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("第 %d 行格式错误，应为：姓名,金额", i+1)
+		}
+
+		amount, err := parseAmount(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行金额无效", i+1)
+		}
+
+		contributions = append(contributions, HypotheticalContribution{
+			Name:   strings.TrimSpace(parts[0]),
+			Amount: amount,
+		})
+	}
+	return contributions, nil
+}
@@ -0,0 +1,289 @@
+package profit_calculator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+
+	"my_portfolio/sqlite_storage"
+)
+
+// migrations 收益数据的数据库结构迁移
+var migrations = []sqlite_storage.Migration{
+	{
+		Version:     1,
+		Description: "创建投资者、收益记录、定期收益模板和设置表",
+		SQL: `CREATE TABLE profit_investors (
+			id                TEXT    PRIMARY KEY,
+			position          INTEGER NOT NULL,
+			name              TEXT    NOT NULL,
+			investment_amount REAL    NOT NULL,
+			created_at        TEXT    NOT NULL
+		);
+		CREATE TABLE profit_monthly_profits (
+			id            TEXT    PRIMARY KEY,
+			position      INTEGER NOT NULL,
+			date          TEXT    NOT NULL,
+			total_profit  REAL    NOT NULL,
+			distributions TEXT    NOT NULL,
+			created_at    TEXT    NOT NULL,
+			recurring_id  TEXT    NOT NULL DEFAULT ''
+		);
+		CREATE TABLE profit_recurring (
+			id             TEXT    PRIMARY KEY,
+			position       INTEGER NOT NULL,
+			name           TEXT    NOT NULL,
+			amount         REAL    NOT NULL,
+			day_of_month   INTEGER NOT NULL,
+			start_date     TEXT    NOT NULL,
+			end_date       TEXT    NOT NULL DEFAULT '',
+			last_generated TEXT    NOT NULL DEFAULT '',
+			enabled        INTEGER NOT NULL,
+			created_at     TEXT    NOT NULL
+		);
+		CREATE TABLE profit_settings (
+			key   TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);`,
+	},
+}
+
+// SQLiteStorage SQLite数据库存储实现
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage 创建SQLite存储并执行数据库结构迁移
+func NewSQLiteStorage(db *sql.DB) (*SQLiteStorage, error) {
+	if err := sqlite_storage.Migrate(db, ToolID, migrations); err != nil {
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Load 从数据库加载数据
+func (s *SQLiteStorage) Load() (*ProfitCalculatorData, error) {
+	data, _ := decodeData(nil)
+
+	investors, err := s.loadInvestors()
+	if err != nil {
+		return nil, err
+	}
+	data.Investors = investors
+
+	profits, err := s.loadProfits()
+	if err != nil {
+		return nil, err
+	}
+	data.MonthlyProfits = profits
+
+	if data.RecurringProfits, err = s.loadRecurring(); err != nil {
+		return nil, err
+	}
+
+	var lockedBefore string
+	err = s.db.QueryRow(`SELECT value FROM profit_settings WHERE key = 'locked_before'`).Scan(&lockedBefore)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if data.LockedBefore, err = sqlite_storage.ParseTime(lockedBefore); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// loadInvestors 加载投资者列表
+func (s *SQLiteStorage) loadInvestors() ([]Investor, error) {
+	rows, err := s.db.Query(`SELECT id, name, investment_amount, created_at FROM profit_investors ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	investors := []Investor{}
+	for rows.Next() {
+		var investor Investor
+		var createdAt string
+		if err := rows.Scan(&investor.ID, &investor.Name, &investor.InvestmentAmount, &createdAt); err != nil {
+			return nil, err
+		}
+		if investor.CreatedAt, err = sqlite_storage.ParseTime(createdAt); err != nil {
+			return nil, err
+		}
+		investors = append(investors, investor)
+	}
+
+	return investors, rows.Err()
+}
+
+// loadProfits 加载收益记录
+func (s *SQLiteStorage) loadProfits() ([]MonthlyProfit, error) {
+	rows, err := s.db.Query(`SELECT id, date, total_profit, distributions, created_at, recurring_id
+		FROM profit_monthly_profits ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profits := []MonthlyProfit{}
+	for rows.Next() {
+		var profit MonthlyProfit
+		var date, distributions, createdAt string
+		if err := rows.Scan(&profit.ID, &date, &profit.TotalProfit, &distributions, &createdAt, &profit.RecurringID); err != nil {
+			return nil, err
+		}
+		if profit.Date, err = sqlite_storage.ParseTime(date); err != nil {
+			return nil, err
+		}
+		if profit.CreatedAt, err = sqlite_storage.ParseTime(createdAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(distributions), &profit.Distributions); err != nil {
+			return nil, err
+		}
+		profits = append(profits, profit)
+	}
+
+	return profits, rows.Err()
+}
+
+// loadRecurring 加载定期收益模板，没有模板时返回nil（与JSON存储一致）
+func (s *SQLiteStorage) loadRecurring() ([]RecurringProfit, error) {
+	rows, err := s.db.Query(`SELECT id, name, amount, day_of_month, start_date, end_date, last_generated, enabled, created_at
+		FROM profit_recurring ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurring []RecurringProfit
+	for rows.Next() {
+		var r RecurringProfit
+		var startDate, endDate, lastGenerated, createdAt string
+		if err := rows.Scan(&r.ID, &r.Name, &r.Amount, &r.DayOfMonth, &startDate, &endDate, &lastGenerated, &r.Enabled, &createdAt); err != nil {
+			return nil, err
+		}
+		if r.StartDate, err = sqlite_storage.ParseTime(startDate); err != nil {
+			return nil, err
+		}
+		if r.EndDate, err = sqlite_storage.ParseTime(endDate); err != nil {
+			return nil, err
+		}
+		if r.LastGenerated, err = sqlite_storage.ParseTime(lastGenerated); err != nil {
+			return nil, err
+		}
+		if r.CreatedAt, err = sqlite_storage.ParseTime(createdAt); err != nil {
+			return nil, err
+		}
+		recurring = append(recurring, r)
+	}
+
+	return recurring, rows.Err()
+}
+
+// Save 在一个事务中保存数据：只写入新增或变化的行，并删除已不存在的行
+func (s *SQLiteStorage) Save(data *ProfitCalculatorData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	investors := make([][]any, 0, len(data.Investors))
+	for i, investor := range data.Investors {
+		investors = append(investors, []any{
+			investor.ID, i, investor.Name, investor.InvestmentAmount, sqlite_storage.FormatTime(investor.CreatedAt),
+		})
+	}
+	if err := sqlite_storage.SyncRows(tx, "profit_investors",
+		[]string{"id", "position", "name", "investment_amount", "created_at"}, investors); err != nil {
+		return err
+	}
+
+	profits := make([][]any, 0, len(data.MonthlyProfits))
+	for i, profit := range data.MonthlyProfits {
+		distributions, err := json.Marshal(profit.Distributions)
+		if err != nil {
+			return err
+		}
+		profits = append(profits, []any{
+			profit.ID, i, sqlite_storage.FormatTime(profit.Date), profit.TotalProfit, string(distributions),
+			sqlite_storage.FormatTime(profit.CreatedAt), profit.RecurringID,
+		})
+	}
+	if err := sqlite_storage.SyncRows(tx, "profit_monthly_profits",
+		[]string{"id", "position", "date", "total_profit", "distributions", "created_at", "recurring_id"}, profits); err != nil {
+		return err
+	}
+
+	recurring := make([][]any, 0, len(data.RecurringProfits))
+	for i, r := range data.RecurringProfits {
+		recurring = append(recurring, []any{
+			r.ID, i, r.Name, r.Amount, r.DayOfMonth,
+			sqlite_storage.FormatTime(r.StartDate), sqlite_storage.FormatTime(r.EndDate),
+			sqlite_storage.FormatTime(r.LastGenerated), r.Enabled, sqlite_storage.FormatTime(r.CreatedAt),
+		})
+	}
+	if err := sqlite_storage.SyncRows(tx, "profit_recurring",
+		[]string{"id", "position", "name", "amount", "day_of_month", "start_date", "end_date", "last_generated", "enabled", "created_at"},
+		recurring); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO profit_settings (key, value) VALUES ('locked_before', ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, sqlite_storage.FormatTime(data.LockedBefore)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ExportJSON 将数据库中的数据导出到JSON数据文件并清空数据库中的数据，返回导出的投资者和收益记录总数。
+// 数据库中没有数据时不修改JSON文件
+func (s *SQLiteStorage) ExportJSON(path string) (int, error) {
+	data, err := s.Load()
+	if err != nil {
+		return 0, err
+	}
+	if len(data.Investors) == 0 && len(data.MonthlyProfits) == 0 && len(data.RecurringProfits) == 0 && data.LockedBefore.IsZero() {
+		return 0, nil
+	}
+
+	if err := NewJSONStorage(path).Save(data); err != nil {
+		return 0, err
+	}
+	if err := s.Save(&ProfitCalculatorData{}); err != nil {
+		return 0, err
+	}
+	return len(data.Investors) + len(data.MonthlyProfits), nil
+}
+
+// ImportJSON 将JSON数据文件导入数据库。
+// 只在数据库中还没有投资者和收益记录时导入，返回导入的投资者和收益记录总数；JSON文件保持不变。
+func (s *SQLiteStorage) ImportJSON(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT (SELECT COUNT(*) FROM profit_investors) + (SELECT COUNT(*) FROM profit_monthly_profits)`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	data, err := NewJSONStorage(path).Load()
+	if err != nil && !isRecovered(err) {
+		return 0, err
+	}
+
+	if err := s.Save(data); err != nil {
+		return 0, err
+	}
+	return len(data.Investors) + len(data.MonthlyProfits), nil
+}
@@ -2,9 +2,22 @@ package profit_calculator
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/safe_file"
+	"my_portfolio/schema"
+	"my_portfolio/secure_storage"
 )
 
+// CurrentSchemaVersion 收益数据文件的当前结构版本
+const CurrentSchemaVersion = 1
+
+// dataSchema 收益数据文件的迁移注册表，修改 ProfitCalculatorData 的结构时在这里注册新的迁移
+var dataSchema = schema.NewRegistry("收益数据", CurrentSchemaVersion).
+	Register(0, "为旧版数据文件添加版本信息", schema.Keep)
+
 // Storage 存储接口
 type Storage interface {
 	Load() (*ProfitCalculatorData, error)
@@ -14,12 +27,14 @@ type Storage interface {
 // JSONStorage JSON文件存储实现
 type JSONStorage struct {
 	filepath string
+	guard    *file_watch.Guard
 }
 
 // NewJSONStorage 创建新的JSON存储
 func NewJSONStorage(filepath string) *JSONStorage {
 	return &JSONStorage{
 		filepath: filepath,
+		guard:    file_watch.NewGuard(filepath),
 	}
 }
 
@@ -28,19 +43,42 @@ func (s *JSONStorage) Load() (*ProfitCalculatorData, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(s.filepath); os.IsNotExist(err) {
 		// 文件不存在，返回空数据
+		s.guard.Remember()
 		return &ProfitCalculatorData{
 			Investors:      []Investor{},
 			MonthlyProfits: []MonthlyProfit{},
 		}, nil
 	}
 
-	// 读取文件
-	data, err := os.ReadFile(s.filepath)
-	if err != nil {
+	// 读取文件（主文件损坏时自动回退到最近的可用备份）
+	data, err := safe_file.ReadFile(s.filepath, validateData)
+	if err != nil && !isRecovered(err) {
 		return nil, err
 	}
 
-	// 如果文件为空，返回空数据
+	// 加密文件不能按明文读取
+	if secure_storage.IsEncrypted(data) {
+		return nil, secure_storage.ErrLocked
+	}
+
+	// 旧版本文件先备份再升级到当前版本
+	payload, upgradeErr := dataSchema.UpgradeFile(s.filepath, data, 0644)
+	if upgradeErr != nil {
+		return nil, upgradeErr
+	}
+
+	profitData, decodeErr := decodeData(payload)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	s.guard.Remember()
+	return profitData, err
+}
+
+// decodeData 解析已升级到当前版本的收益数据
+func decodeData(data []byte) (*ProfitCalculatorData, error) {
+	// 如果内容为空，返回空数据
 	if len(data) == 0 {
 		return &ProfitCalculatorData{
 			Investors:      []Investor{},
@@ -50,7 +88,7 @@ func (s *JSONStorage) Load() (*ProfitCalculatorData, error) {
 
 	// 解析JSON
 	var profitData ProfitCalculatorData
-	err = json.Unmarshal(data, &profitData)
+	err := json.Unmarshal(data, &profitData)
 	if err != nil {
 		return nil, err
 	}
@@ -68,17 +106,138 @@ func (s *JSONStorage) Load() (*ProfitCalculatorData, error) {
 
 // Save 保存数据到JSON文件
 func (s *JSONStorage) Save(data *ProfitCalculatorData) error {
-	// 序列化为JSON
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	// 不覆盖由更新版本程序写入的文件
+	if err := dataSchema.CheckWritable(s.filepath); err != nil {
+		return err
+	}
+
+	// 不覆盖读取之后被其他程序修改的文件
+	if err := s.guard.Check(); err != nil {
+		return err
+	}
+
+	// 序列化为带版本信息的JSON
+	jsonData, err := dataSchema.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	// 原子写入文件，并保留历史版本
+	err = safe_file.WriteFile(s.filepath, jsonData, 0644)
+	if err != nil {
+		return err
+	}
+
+	s.guard.Remember()
+	return nil
+}
+
+// Guard 返回数据文件的外部修改检查
+func (s *JSONStorage) Guard() *file_watch.Guard {
+	return s.guard
+}
+
+// validateData 校验数据文件内容是否可用
+func validateData(data []byte) error {
+	if secure_storage.IsEncrypted(data) {
+		return nil
+	}
+	var profitData ProfitCalculatorData
+	return dataSchema.Decode(data, &profitData)
+}
+
+// isRecovered 判断错误是否表示已从备份恢复（数据仍可使用）
+func isRecovered(err error) bool {
+	var recovered *safe_file.RecoveredError
+	return errors.As(err, &recovered)
+}
+
+// EncryptedStorage 加密JSON文件存储实现
+type EncryptedStorage struct {
+	file  *secure_storage.File
+	guard *file_watch.Guard
+}
+
+// NewEncryptedStorage 创建新的加密存储（需要先调用 Unlock）
+func NewEncryptedStorage(filepath string) *EncryptedStorage {
+	return &EncryptedStorage{
+		file:  secure_storage.NewFile(filepath),
+		guard: file_watch.NewGuard(filepath),
+	}
+}
+
+// Unlock 使用密码解锁存储
+func (s *EncryptedStorage) Unlock(passphrase string) error {
+	return s.file.Unlock(passphrase)
+}
+
+// Locked 是否尚未解锁
+func (s *EncryptedStorage) Locked() bool {
+	return s.file.Locked()
+}
+
+// Load 解密并加载数据
+func (s *EncryptedStorage) Load() (*ProfitCalculatorData, error) {
+	data, err := s.file.Read()
+	if err != nil && !isRecovered(err) {
+		if os.IsNotExist(err) {
+			s.guard.Remember()
+			return decodeData(nil)
+		}
+		return nil, err
+	}
+
+	payload, upgradeErr := s.upgrade(data)
+	if upgradeErr != nil {
+		return nil, upgradeErr
+	}
+
+	profitData, decodeErr := decodeData(payload)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	s.guard.Remember()
+	return profitData, err
+}
+
+// upgrade 将旧版本的数据升级到当前版本，升级前写入加密备份，升级后立即保存
+func (s *EncryptedStorage) upgrade(data []byte) ([]byte, error) {
+	payload, migrated, err := dataSchema.Upgrade(data, func(version int, original []byte) error {
+		return s.file.WriteCopy(schema.BackupPath(s.file.Path(), version), original)
+	})
+	if err != nil || !migrated {
+		return payload, err
+	}
+
+	upgraded, err := dataSchema.Wrap(payload)
 	if err != nil {
+		return nil, err
+	}
+	return payload, s.file.Write(upgraded)
+}
+
+// Save 加密并保存数据
+func (s *EncryptedStorage) Save(data *ProfitCalculatorData) error {
+	// 不覆盖读取之后被其他程序修改的文件
+	if err := s.guard.Check(); err != nil {
 		return err
 	}
 
-	// 写入文件
-	err = os.WriteFile(s.filepath, jsonData, 0644)
+	jsonData, err := dataSchema.Encode(data)
 	if err != nil {
 		return err
 	}
 
+	if err := s.file.Write(jsonData); err != nil {
+		return err
+	}
+
+	s.guard.Remember()
 	return nil
 }
+
+// Guard 返回数据文件的外部修改检查
+func (s *EncryptedStorage) Guard() *file_watch.Guard {
+	return s.guard
+}
@@ -0,0 +1,266 @@
+package profit_calculator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"my_portfolio/storage_recovery"
+)
+
+// ErrSyncEncrypted 加密数据不能以明文同步
+var ErrSyncEncrypted = errors.New("收益数据已加密，不能通过文件夹同步")
+
+// 同步记录键的前缀，键的格式为 "<前缀>/<UUID>"
+const (
+	syncInvestorPrefix  = "investor/"
+	syncProfitPrefix    = "profit/"
+	syncRecurringPrefix = "recurring/"
+	syncLockedBeforeKey = "settings/locked_before"
+)
+
+// SyncRecords 返回用于同步的记录：投资者、收益记录和定期收益模板各自按ID同步，期间锁定作为单独的一条记录
+func (ui *ProfitCalculatorUI) SyncRecords() (map[string]json.RawMessage, error) {
+	if ui.EncryptionEnabled() {
+		return nil, ErrSyncEncrypted
+	}
+	// 加载失败时的空数据不能参与同步，否则会删除其他设备上的记录
+	if ui.loadErr != nil {
+		return nil, errors.New("收益数据加载失败，修复后才能同步: " + ui.loadErr.Error())
+	}
+
+	return syncRecords(ui.data)
+}
+
+// syncRecords 将收益数据转换为同步记录
+func syncRecords(data *ProfitCalculatorData) (map[string]json.RawMessage, error) {
+	records := make(map[string]json.RawMessage)
+	add := func(key string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		records[key] = data
+		return nil
+	}
+
+	for _, investor := range data.Investors {
+		if err := add(syncInvestorPrefix+investor.ID, investor); err != nil {
+			return nil, err
+		}
+	}
+	for _, profit := range data.MonthlyProfits {
+		if err := add(syncProfitPrefix+profit.ID, profit); err != nil {
+			return nil, err
+		}
+	}
+	for _, recurring := range data.RecurringProfits {
+		if err := add(syncRecurringPrefix+recurring.ID, recurring); err != nil {
+			return nil, err
+		}
+	}
+	if !data.LockedBefore.IsZero() {
+		if err := add(syncLockedBeforeKey, data.LockedBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// ProtectedSyncRecords 返回合并结果中不能采用的记录键，同步时这些记录保留本机版本并作为冲突报告
+func (ui *ProfitCalculatorUI) ProtectedSyncRecords(local, merged map[string]json.RawMessage) []string {
+	return lockedSyncKeys(ui.data, local, merged)
+}
+
+// lockedSyncKeys 返回合并结果中违反期间锁定的记录键：修改或删除已锁定期间的收益记录、
+// 在已锁定期间新增收益记录（按本机的锁定日期判断），以及提前或取消期间锁定
+func lockedSyncKeys(data *ProfitCalculatorData, local, merged map[string]json.RawMessage) []string {
+	if data.LockedBefore.IsZero() {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for key := range local {
+		keys[key] = true
+	}
+	for key := range merged {
+		keys[key] = true
+	}
+
+	var locked []string
+	for key := range keys {
+		localData, mergedData := local[key], merged[key]
+		if sameSyncRecord(localData, mergedData) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(key, syncProfitPrefix):
+			for _, raw := range []json.RawMessage{localData, mergedData} {
+				var profit MonthlyProfit
+				if raw != nil && json.Unmarshal(raw, &profit) == nil && data.IsLocked(profit.Date) {
+					locked = append(locked, key)
+					break
+				}
+			}
+		case key == syncLockedBeforeKey:
+			var lockedBefore time.Time
+			if mergedData == nil || json.Unmarshal(mergedData, &lockedBefore) != nil || data.IsLocked(lockedBefore) {
+				locked = append(locked, key)
+			}
+		}
+	}
+
+	sort.Strings(locked)
+	return locked
+}
+
+// sameSyncRecord 判断两个版本的记录是否相同（忽略JSON格式差异），nil 表示记录不存在
+func sameSyncRecord(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var bufA, bufB bytes.Buffer
+	if json.Compact(&bufA, a) != nil || json.Compact(&bufB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
+// ApplySyncRecords 用同步合并后的记录替换本地数据，保存、记录审计日志并刷新界面。
+// 已锁定期间的记录保留本机版本（通常已由 ProtectedSyncRecords 在合并时排除）
+func (ui *ProfitCalculatorUI) ApplySyncRecords(records map[string]json.RawMessage) error {
+	local, err := syncRecords(ui.data)
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]json.RawMessage, len(records))
+	for key, raw := range records {
+		merged[key] = raw
+	}
+	for _, key := range lockedSyncKeys(ui.data, local, merged) {
+		if raw, ok := local[key]; ok {
+			merged[key] = raw
+		} else {
+			delete(merged, key)
+		}
+	}
+
+	added, changed, deleted := 0, 0, 0
+	for key, raw := range merged {
+		if old, ok := local[key]; !ok {
+			added++
+		} else if !sameSyncRecord(old, raw) {
+			changed++
+		}
+	}
+	for key := range local {
+		if _, ok := merged[key]; !ok {
+			deleted++
+		}
+	}
+	if added+changed+deleted == 0 {
+		return nil
+	}
+
+	data := &ProfitCalculatorData{
+		Investors:      []Investor{},
+		MonthlyProfits: []MonthlyProfit{},
+	}
+
+	for key, raw := range merged {
+		var err error
+		switch {
+		case strings.HasPrefix(key, syncInvestorPrefix):
+			var investor Investor
+			if err = json.Unmarshal(raw, &investor); err == nil {
+				data.Investors = append(data.Investors, investor)
+			}
+		case strings.HasPrefix(key, syncProfitPrefix):
+			var profit MonthlyProfit
+			if err = json.Unmarshal(raw, &profit); err == nil {
+				data.MonthlyProfits = append(data.MonthlyProfits, profit)
+			}
+		case strings.HasPrefix(key, syncRecurringPrefix):
+			var recurring RecurringProfit
+			if err = json.Unmarshal(raw, &recurring); err == nil {
+				data.RecurringProfits = append(data.RecurringProfits, recurring)
+			}
+		case key == syncLockedBeforeKey:
+			err = json.Unmarshal(raw, &data.LockedBefore)
+		}
+		if err != nil {
+			return fmt.Errorf("解析同步记录 %s 失败: %w", key, err)
+		}
+	}
+
+	// 按创建时间排列，与在单台设备上依次添加的顺序一致
+	sort.Slice(data.Investors, func(i, j int) bool {
+		return data.Investors[i].CreatedAt.Before(data.Investors[j].CreatedAt)
+	})
+	sort.Slice(data.MonthlyProfits, func(i, j int) bool {
+		return data.MonthlyProfits[i].CreatedAt.Before(data.MonthlyProfits[j].CreatedAt)
+	})
+	sort.Slice(data.RecurringProfits, func(i, j int) bool {
+		return data.RecurringProfits[i].CreatedAt.Before(data.RecurringProfits[j].CreatedAt)
+	})
+
+	// 通过保存队列写入，避免仍在重试的旧数据覆盖合并结果；可以重试的失败保留在队列中
+	storage := ui.storage
+	saved := CloneData(data)
+	if err := ui.saveQueue.Save(func() error {
+		return storage.Save(saved)
+	}); err != nil && !storage_recovery.Retryable(err) {
+		return err
+	}
+
+	before := ui.data
+	ui.data = data
+	if ui.history != nil {
+		summary := fmt.Sprintf("从同步文件夹合并数据：新增 %d 条、修改 %d 条、删除 %d 条记录", added, changed, deleted)
+		if err := ui.history.Record(ActionSync, summary, before, ui.data); err != nil {
+			return errors.New("写入审计日志失败: " + err.Error())
+		}
+	}
+
+	ui.refreshUI()
+	return nil
+}
+
+// DescribeSyncRecord 返回记录的简短描述，用于同步冲突对话框
+func (ui *ProfitCalculatorUI) DescribeSyncRecord(key string, data json.RawMessage) string {
+	if data == nil {
+		return "（已删除）"
+	}
+
+	switch {
+	case strings.HasPrefix(key, syncInvestorPrefix):
+		var investor Investor
+		if json.Unmarshal(data, &investor) == nil {
+			return fmt.Sprintf("投资者 %s：投资 %.2f", investor.Name, investor.InvestmentAmount)
+		}
+	case strings.HasPrefix(key, syncProfitPrefix):
+		var profit MonthlyProfit
+		if json.Unmarshal(data, &profit) == nil {
+			return fmt.Sprintf("收益 %s：%.2f", profit.Date.Format("2006-01-02"), profit.TotalProfit)
+		}
+	case strings.HasPrefix(key, syncRecurringPrefix):
+		var recurring RecurringProfit
+		if json.Unmarshal(data, &recurring) == nil {
+			return fmt.Sprintf("定期收益 %s：每月%d日 %.2f", recurring.Name, recurring.DayOfMonth, recurring.Amount)
+		}
+	case key == syncLockedBeforeKey:
+		var lockedBefore time.Time
+		if json.Unmarshal(data, &lockedBefore) == nil {
+			return "锁定 " + lockedBefore.Format("2006-01-02") + " 之前的记录"
+		}
+	}
+
+	return key
+}
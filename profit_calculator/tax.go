@@ -0,0 +1,218 @@
+package profit_calculator
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// TaxYearConfig 税务年度配置
+type TaxYearConfig struct {
+	StartMonth      time.Month // 税务年度起始月份，1 表示自然年
+	WithholdingRate float64    // 预扣税率（0-1），0 表示不预扣
+}
+
+// TaxSummary 单个投资者在一个税务年度内的收益汇总
+type TaxSummary struct {
+	TaxYear      int    // 税务年度起始的自然年
+	Label        string // 税务年度显示名称，如 "2025" 或 "2025/26"
+	InvestorID   string
+	InvestorName string
+	Gains        float64 // 正收益合计
+	Losses       float64 // 亏损合计（负数）
+	Net          float64 // 净收益
+	Withholding  float64 // 预扣税额
+	NetAfterTax  float64 // 扣税后净收益
+	RecordCount  int     // 收益记录数
+}
+
+// Validate 验证税务年度配置
+func (c *TaxYearConfig) Validate() error {
+	if c.StartMonth < time.January || c.StartMonth > time.December {
+		return errors.New("税务年度起始月份必须在1到12之间")
+	}
+	if c.WithholdingRate < 0 || c.WithholdingRate > 1 {
+		return errors.New("预扣税率必须在0到100%之间")
+	}
+	return nil
+}
+
+// TaxYearOf 返回日期所属税务年度（以税务年度起始的自然年表示）
+func TaxYearOf(date time.Time, startMonth time.Month) int {
+	if date.Month() < startMonth {
+		return date.Year() - 1
+	}
+	return date.Year()
+}
+
+// TaxYearLabel 返回税务年度的显示名称
+func TaxYearLabel(year int, startMonth time.Month) string {
+	if startMonth == time.January {
+		return fmt.Sprintf("%d", year)
+	}
+	return fmt.Sprintf("%d/%02d", year, (year+1)%100)
+}
+
+// CalculateTaxSummaries 按税务年度和投资者汇总收益分配
+func CalculateTaxSummaries(data *ProfitCalculatorData, config TaxYearConfig) ([]TaxSummary, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(data.Investors))
+	for _, investor := range data.Investors {
+		names[investor.ID] = investor.Name
+	}
+
+	type key struct {
+		year       int
+		investorID string
+	}
+	summaries := make(map[key]*TaxSummary)
+
+	for _, profit := range data.MonthlyProfits {
+		year := TaxYearOf(profit.Date, config.StartMonth)
+		for investorID, amount := range profit.Distributions {
+			k := key{year: year, investorID: investorID}
+			summary, exists := summaries[k]
+			if !exists {
+				name, ok := names[investorID]
+				if !ok {
+					// 投资者已删除，历史收益仍需计入
+					name = "已删除投资者 " + shortID(investorID)
+				}
+				summary = &TaxSummary{
+					TaxYear:      year,
+					Label:        TaxYearLabel(year, config.StartMonth),
+					InvestorID:   investorID,
+					InvestorName: name,
+				}
+				summaries[k] = summary
+			}
+
+			if amount >= 0 {
+				summary.Gains += amount
+			} else {
+				summary.Losses += amount
+			}
+			summary.RecordCount++
+		}
+	}
+
+	result := make([]TaxSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		summary.Net = summary.Gains + summary.Losses
+		if summary.Net > 0 {
+			summary.Withholding = summary.Net * config.WithholdingRate
+		}
+		summary.NetAfterTax = summary.Net - summary.Withholding
+		result = append(result, *summary)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TaxYear != result[j].TaxYear {
+			return result[i].TaxYear > result[j].TaxYear
+		}
+		return result[i].InvestorName < result[j].InvestorName
+	})
+
+	return result, nil
+}
+
+// ExportTaxCSV 将税务汇总导出为CSV
+func ExportTaxCSV(w io.Writer, summaries []TaxSummary) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"税务年度", "投资者", "收益", "亏损", "净收益", "预扣税", "税后净收益", "记录数"}); err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		row := []string{
+			s.Label,
+			s.InvestorName,
+			fmt.Sprintf("%.2f", s.Gains),
+			fmt.Sprintf("%.2f", s.Losses),
+			fmt.Sprintf("%.2f", s.Net),
+			fmt.Sprintf("%.2f", s.Withholding),
+			fmt.Sprintf("%.2f", s.NetAfterTax),
+			fmt.Sprintf("%d", s.RecordCount),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// taxHTMLTemplate 可打印的税务汇总页面
+var taxHTMLTemplate = template.Must(template.New("tax").Funcs(template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("¥%.2f", v) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>税务年度收益汇总</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 2em; border-bottom: 1px solid #999; }
+table { border-collapse: collapse; width: 100%; margin-top: 0.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.meta { color: #666; font-size: 0.9em; }
+@media print { body { margin: 0; } h2 { page-break-before: auto; } }
+</style>
+</head>
+<body>
+<h1>税务年度收益汇总</h1>
+<p class="meta">生成时间：{{.GeneratedAt}}　税务年度起始月：{{.StartMonth}} 月　预扣税率：{{.WithholdingRate}}</p>
+{{range .Years}}
+<h2>税务年度 {{.Label}}</h2>
+<table>
+<tr><th>投资者</th><th>收益</th><th>亏损</th><th>净收益</th><th>预扣税</th><th>税后净收益</th><th>记录数</th></tr>
+{{range .Rows}}<tr><td>{{.InvestorName}}</td><td>{{money .Gains}}</td><td>{{money .Losses}}</td><td>{{money .Net}}</td><td>{{money .Withholding}}</td><td>{{money .NetAfterTax}}</td><td>{{.RecordCount}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>没有收益记录。</p>
+{{end}}
+</body>
+</html>
+`))
+
+// ExportTaxHTML 将税务汇总导出为可打印的HTML页面
+func ExportTaxHTML(w io.Writer, summaries []TaxSummary, config TaxYearConfig) error {
+	type yearGroup struct {
+		Label string
+		Rows  []TaxSummary
+	}
+
+	var years []yearGroup
+	for _, s := range summaries {
+		if len(years) == 0 || years[len(years)-1].Label != s.Label {
+			years = append(years, yearGroup{Label: s.Label})
+		}
+		years[len(years)-1].Rows = append(years[len(years)-1].Rows, s)
+	}
+
+	return taxHTMLTemplate.Execute(w, map[string]interface{}{
+		"GeneratedAt":     time.Now().Format("2006-01-02 15:04"),
+		"StartMonth":      int(config.StartMonth),
+		"WithholdingRate": formatPercentage(config.WithholdingRate),
+		"Years":           years,
+	})
+}
+
+// shortID 截取ID前8位用于显示
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,154 @@
+package profit_calculator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showTaxSummaryDialog 显示税务年度汇总对话框
+func (ui *ProfitCalculatorUI) showTaxSummaryDialog() {
+	monthOptions := make([]string, 12)
+	for i := range monthOptions {
+		monthOptions[i] = fmt.Sprintf("%d 月", i+1)
+	}
+	startMonthSelect := widget.NewSelect(monthOptions, nil)
+	startMonthSelect.SetSelectedIndex(0)
+
+	rateEntry := widget.NewEntry()
+	rateEntry.SetPlaceHolder("0")
+	rateEntry.SetText("0")
+
+	var summaries []TaxSummary
+	var config TaxYearConfig
+
+	resultBox := container.NewVBox(widget.NewLabel("选择税务年度起始月份后点击“生成汇总”"))
+
+	generate := func() bool {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateEntry.Text), 64)
+		if err != nil {
+			dialog.ShowError(errors.New("请输入有效的预扣税率"), ui.window)
+			return false
+		}
+
+		config = TaxYearConfig{
+			StartMonth:      time.Month(startMonthSelect.SelectedIndex() + 1),
+			WithholdingRate: rate / 100,
+		}
+
+		summaries, err = CalculateTaxSummaries(ui.data, config)
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return false
+		}
+
+		ui.renderTaxSummaries(resultBox, summaries)
+		return true
+	}
+
+	generateButton := widget.NewButton("生成汇总", func() {
+		generate()
+	})
+
+	exportCSVButton := widget.NewButton("导出CSV", func() {
+		if !generate() {
+			return
+		}
+		ui.saveExport("tax_summary.csv", func(w io.Writer) error {
+			return ExportTaxCSV(w, summaries)
+		})
+	})
+
+	exportHTMLButton := widget.NewButton("导出打印页 (HTML)", func() {
+		if !generate() {
+			return
+		}
+		ui.saveExport("tax_summary.html", func(w io.Writer) error {
+			return ExportTaxHTML(w, summaries, config)
+		})
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("税务年度起始月", startMonthSelect),
+		widget.NewFormItem("预扣税率 (%)", rateEntry),
+	)
+
+	scroll := container.NewVScroll(resultBox)
+	scroll.SetMinSize(fyne.NewSize(500, 320))
+
+	content := container.NewBorder(
+		container.NewVBox(
+			form,
+			container.NewHBox(generateButton, exportCSVButton, exportHTMLButton),
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		scroll,
+	)
+
+	d := dialog.NewCustom("税务年度汇总", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(560, 560))
+	d.Show()
+}
+
+// renderTaxSummaries 在容器中显示税务汇总
+func (ui *ProfitCalculatorUI) renderTaxSummaries(box *fyne.Container, summaries []TaxSummary) {
+	box.Objects = nil
+
+	if len(summaries) == 0 {
+		box.Add(widget.NewLabel("没有收益记录"))
+		box.Refresh()
+		return
+	}
+
+	currentLabel := ""
+	for _, s := range summaries {
+		if s.Label != currentLabel {
+			currentLabel = s.Label
+			box.Add(widget.NewLabelWithStyle("📅 税务年度 "+s.Label, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+
+		box.Add(widget.NewLabel(fmt.Sprintf(
+			"  👤 %s：收益 %s  亏损 %s  净收益 %s  预扣 %s  税后 %s",
+			s.InvestorName,
+			formatCurrency(s.Gains),
+			formatCurrency(s.Losses),
+			formatCurrency(s.Net),
+			formatCurrency(s.Withholding),
+			formatCurrency(s.NetAfterTax),
+		)))
+	}
+
+	box.Refresh()
+}
+
+// saveExport 弹出保存文件对话框并写入导出内容
+func (ui *ProfitCalculatorUI) saveExport(fileName string, write func(w io.Writer) error) {
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := write(writer); err != nil {
+			dialog.ShowError(errors.New("导出失败: "+err.Error()), ui.window)
+			return
+		}
+
+		dialog.ShowInformation("成功", "已导出到 "+writer.URI().Path(), ui.window)
+	}, ui.window)
+	d.SetFileName(fileName)
+	d.Show()
+}
@@ -9,14 +9,21 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/safe_file"
+	"my_portfolio/storage_recovery"
 )
 
 // ProfitCalculatorUI 收益计算器UI
 type ProfitCalculatorUI struct {
-	storage Storage
-	data    *ProfitCalculatorData
-	window  fyne.Window
+	storage     Storage
+	storagePath string
+	data        *ProfitCalculatorData
+	window      fyne.Window
+	history     *History
 
 	// UI 组件
 	mainContent  *fyne.Container
@@ -27,18 +34,62 @@ type ProfitCalculatorUI struct {
 	totalInvestmentText *canvas.Text
 	totalProfitText     *canvas.Text
 	investorCountText   *canvas.Text
+
+	// 加载失败时的错误，修复前不保存，避免空数据覆盖原文件
+	loadErr error
+	// 保存失败后在后台重试
+	saveQueue       *storage_recovery.SaveQueue
+	saveStatus      *fyne.Container
+	saveStatusLabel *widget.Label
+
+	// 停止监视数据文件，未监视时为 nil
+	stopWatch func()
+
+	// 撤销/重做快捷键注册在整个窗口上，只在收益计算页面处于前台时注册
+	shortcutsActive bool
+}
+
+// Options 收益计算器UI的创建选项
+type Options struct {
+	DataPath  string  // 数据文件路径，为空时使用默认文件名
+	Encrypted bool    // 是否使用加密存储（需要解锁后才能加载数据）
+	Storage   Storage // 自定义存储（例如SQLite），非空且未启用加密时使用
 }
 
 // NewProfitCalculatorUI 创建新的收益计算器UI
 func NewProfitCalculatorUI(window fyne.Window) *ProfitCalculatorUI {
+	return NewProfitCalculatorUIWithOptions(window, Options{})
+}
+
+// NewProfitCalculatorUIWithOptions 按选项创建收益计算器UI
+func NewProfitCalculatorUIWithOptions(window fyne.Window, opts Options) *ProfitCalculatorUI {
+	if opts.DataPath == "" {
+		opts.DataPath = DefaultDataFile
+	}
+
+	var storage Storage = NewJSONStorage(opts.DataPath)
+	if opts.Encrypted {
+		storage = NewEncryptedStorage(opts.DataPath)
+	} else if opts.Storage != nil {
+		storage = opts.Storage
+	}
+
 	ui := &ProfitCalculatorUI{
-		storage: NewJSONStorage("profit_records.json"),
-		window:  window,
+		storage:     storage,
+		storagePath: opts.DataPath,
+		window:      window,
 	}
+	ui.saveQueue = storage_recovery.NewSaveQueue(true, ui.onSaveQueueChanged)
 
 	// 加载现有数据
 	ui.loadData()
 
+	// 加密存储需要解锁后才能打开审计日志和生成定期收益
+	if !ui.Locked() {
+		ui.openHistory("")
+		ui.applyRecurringProfits()
+	}
+
 	return ui
 }
 
@@ -53,8 +104,12 @@ func (ui *ProfitCalculatorUI) MakeUI() fyne.CanvasObject {
 	// 创建收益管理区域
 	profitSection := ui.createProfitSection()
 
+	// 保存失败提示
+	ui.saveStatus = ui.createSaveStatus()
+
 	// 组合布局
 	ui.mainContent = container.NewVBox(
+		ui.saveStatus,
 		statsCard,
 		widget.NewSeparator(),
 		investorSection,
@@ -62,37 +117,92 @@ func (ui *ProfitCalculatorUI) MakeUI() fyne.CanvasObject {
 		profitSection,
 	)
 
+	// 数据文件被外部修改后自动重新加载
+	ui.watchDataFile()
+
 	return container.NewScroll(ui.mainContent)
 }
 
-// loadData 从存储加载数据
+// loadData 从存储加载数据，失败时显示恢复对话框
 func (ui *ProfitCalculatorUI) loadData() {
+	if err := ui.tryLoadData(); err != nil {
+		ui.showLoadError(err)
+	}
+}
+
+// tryLoadData 从存储加载数据；失败时使用空数据，并在重新加载成功前阻止保存
+func (ui *ProfitCalculatorUI) tryLoadData() error {
+	ui.loadErr = nil
+
+	// 加密存储尚未解锁时等待解锁后再加载
+	if ui.Locked() {
+		ui.data = &ProfitCalculatorData{
+			Investors:      []Investor{},
+			MonthlyProfits: []MonthlyProfit{},
+		}
+		return nil
+	}
+
 	data, err := ui.storage.Load()
 	if err != nil {
+		// 主文件损坏但已从备份恢复，提示用户后继续使用
+		var recovered *safe_file.RecoveredError
+		if errors.As(err, &recovered) {
+			ui.data = data
+			dialog.ShowInformation("⚠️ 数据已从备份恢复", recovered.Error(), ui.window)
+			return nil
+		}
+
 		// 如果加载失败，使用空数据
 		ui.data = &ProfitCalculatorData{
 			Investors:      []Investor{},
 			MonthlyProfits: []MonthlyProfit{},
 		}
-		dialog.ShowError(
-			errors.New("加载数据失败: "+err.Error()),
-			ui.window,
-		)
-		return
+		ui.loadErr = err
+		return err
 	}
 
 	ui.data = data
+	return nil
 }
 
-// saveData 保存数据到存储
-func (ui *ProfitCalculatorUI) saveData() {
-	err := ui.storage.Save(ui.data)
-	if err != nil {
-		dialog.ShowError(
-			errors.New("保存失败: "+err.Error()),
-			ui.window,
-		)
+// saveData 保存数据到存储，失败时保留在队列中自动重试；返回保存的错误，已在重试队列中时返回 nil
+func (ui *ProfitCalculatorUI) saveData() error {
+	return ui.saveChange(nil, nil)
+}
+
+// saveChange 与 saveData 相同；数据文件被其他程序修改时询问覆盖还是重新加载：
+// 覆盖成功后调用 onOverwritten，重新加载时先恢复为 before（不为 nil 时）再读取文件
+func (ui *ProfitCalculatorUI) saveChange(before *ProfitCalculatorData, onOverwritten func()) error {
+	// 加载失败时不保存，避免用空数据覆盖原文件
+	if ui.loadErr != nil {
+		ui.showLoadError(ui.loadErr)
+		return ui.loadErr
+	}
+
+	storage := ui.storage
+	data := CloneData(ui.data)
+	alreadyPending, _ := ui.saveQueue.Pending()
+
+	err := ui.saveQueue.Save(func() error {
+		return storage.Save(data)
+	})
+	// 之前的保存仍在重试时，结果由 onSaveQueueChanged 处理
+	if err == nil || alreadyPending > 0 {
+		return nil
+	}
+
+	if errors.Is(err, file_watch.ErrChanged) {
+		ui.confirmOverwrite(before, onOverwritten)
+		return err
 	}
+
+	message := "保存失败: " + err.Error()
+	if storage_recovery.Retryable(err) {
+		message += "\n修改已保留，将在后台自动重试"
+	}
+	dialog.ShowError(errors.New(message), ui.window)
+	return err
 }
 
 // refreshUI 刷新整个UI
@@ -151,10 +261,32 @@ func (ui *ProfitCalculatorUI) createStatsCard() fyne.CanvasObject {
 		container.NewVBox(investorCountLabel, ui.investorCountText),
 	)
 
+	// 情景模拟按钮
+	simulateButton := widget.NewButton("情景模拟", func() {
+		ui.showSimulatorDialog()
+	})
+
+	// 撤销/重做/历史按钮
+	undoButton := widget.NewButtonWithIcon("撤销", theme.ContentUndoIcon(), func() {
+		ui.undo()
+	})
+	redoButton := widget.NewButtonWithIcon("重做", theme.ContentRedoIcon(), func() {
+		ui.redo()
+	})
+	historyButton := widget.NewButtonWithIcon("操作历史", theme.HistoryIcon(), func() {
+		ui.showAuditHistoryDialog()
+	})
+
+	// 税务年度汇总按钮
+	taxButton := widget.NewButtonWithIcon("税务汇总", theme.DocumentIcon(), func() {
+		ui.showTaxSummaryDialog()
+	})
+
 	return container.NewVBox(
 		title,
 		widget.NewSeparator(),
 		statsRow,
+		container.NewHBox(simulateButton, taxButton, undoButton, redoButton, historyButton),
 	)
 }
 
@@ -379,11 +511,12 @@ func (ui *ProfitCalculatorUI) showAddInvestorDialog() {
 			}
 
 			// 创建新投资者
+			before := CloneData(ui.data)
 			newInvestor := NewInvestor(name, amount)
 			ui.data.Investors = append(ui.data.Investors, *newInvestor)
 
-			// 保存数据
-			ui.saveData()
+			// 保存数据并记录审计日志
+			ui.commitChange(ActionAddInvestor, fmt.Sprintf("添加投资者 %s（%s）", name, formatCurrency(amount)), before)
 
 			// 刷新UI
 			ui.refreshUI()
@@ -459,6 +592,7 @@ func (ui *ProfitCalculatorUI) showEditInvestorDialog(investor *Investor) {
 			}
 
 			// 更新投资者信息
+			before := CloneData(ui.data)
 			for i := range ui.data.Investors {
 				if ui.data.Investors[i].ID == investor.ID {
 					ui.data.Investors[i].Name = name
@@ -467,8 +601,8 @@ func (ui *ProfitCalculatorUI) showEditInvestorDialog(investor *Investor) {
 				}
 			}
 
-			// 保存数据
-			ui.saveData()
+			// 保存数据并记录审计日志
+			ui.commitChange(ActionEditInvestor, fmt.Sprintf("编辑投资者 %s（%s）", name, formatCurrency(amount)), before)
 
 			// 刷新UI
 			ui.refreshUI()
@@ -506,6 +640,7 @@ func (ui *ProfitCalculatorUI) deleteInvestor(investorID string) {
 			}
 
 			// 删除投资者
+			before := CloneData(ui.data)
 			newInvestors := []Investor{}
 			for _, investor := range ui.data.Investors {
 				if investor.ID != investorID {
@@ -514,8 +649,8 @@ func (ui *ProfitCalculatorUI) deleteInvestor(investorID string) {
 			}
 			ui.data.Investors = newInvestors
 
-			// 保存数据
-			ui.saveData()
+			// 保存数据并记录审计日志
+			ui.commitChange(ActionDeleteInvestor, fmt.Sprintf("删除投资者 %s", investorName), before)
 
 			// 刷新UI
 			ui.refreshUI()
@@ -536,6 +671,14 @@ func (ui *ProfitCalculatorUI) createProfitSection() fyne.CanvasObject {
 		ui.showAddProfitDialog()
 	})
 
+	// 定期收益和期间锁定按钮
+	recurringButton := widget.NewButton("定期收益", func() {
+		ui.showRecurringDialog()
+	})
+	lockButton := widget.NewButton("期间锁定", func() {
+		ui.showPeriodLockDialog()
+	})
+
 	// 创建收益列表
 	ui.createProfitList()
 
@@ -552,7 +695,7 @@ func (ui *ProfitCalculatorUI) createProfitSection() fyne.CanvasObject {
 
 	return container.NewBorder(
 		container.NewVBox(
-			container.NewHBox(title, addButton),
+			container.NewHBox(title, addButton, recurringButton, lockButton),
 			widget.NewSeparator(),
 		),
 		nil, nil, nil,
@@ -605,7 +748,14 @@ func (ui *ProfitCalculatorUI) createProfitList() {
 			dateLabel := infoRow.Objects[0].(*widget.Label)
 			amountLabel := infoRow.Objects[1].(*widget.Label)
 
-			dateLabel.SetText(profit.Date.Format("2006-01-02"))
+			dateText := profit.Date.Format("2006-01-02")
+			if profit.RecurringID != "" {
+				dateText = "🔁 " + dateText
+			}
+			if ui.data.IsLocked(profit.Date) {
+				dateText = "🔒 " + dateText
+			}
+			dateLabel.SetText(dateText)
 			amountLabel.SetText(formatCurrency(profit.TotalProfit))
 
 			// 更新按钮
@@ -651,12 +801,6 @@ func (ui *ProfitCalculatorUI) showAddProfitDialog() {
 				return
 			}
 
-			// 检查日期不能为未来
-			if date.After(time.Now()) {
-				dialog.ShowError(errors.New("日期不能为未来"), ui.window)
-				return
-			}
-
 			// 验证金额
 			amount, err := parseAmount(amountEntry.Text)
 			if err != nil {
@@ -664,8 +808,9 @@ func (ui *ProfitCalculatorUI) showAddProfitDialog() {
 				return
 			}
 
-			if amount < -10000000 || amount > 10000000 {
-				dialog.ShowError(errors.New("收益金额必须在-10,000,000到10,000,000之间"), ui.window)
+			// 检查日期不能为未来、不在锁定期间，金额在允许范围内
+			if err := ValidateProfit(ui.data, date, amount, time.Now()); err != nil {
+				dialog.ShowError(err, ui.window)
 				return
 			}
 
@@ -673,11 +818,12 @@ func (ui *ProfitCalculatorUI) showAddProfitDialog() {
 			distributions := DistributeProfit(amount, ui.data.Investors)
 
 			// 创建新收益记录
+			before := CloneData(ui.data)
 			newProfit := NewMonthlyProfit(date, amount, distributions)
 			ui.data.MonthlyProfits = append(ui.data.MonthlyProfits, *newProfit)
 
-			// 保存数据
-			ui.saveData()
+			// 保存数据并记录审计日志
+			ui.commitChange(ActionAddProfit, fmt.Sprintf("添加 %s 的收益记录（%s）", date.Format("2006-01-02"), formatCurrency(amount)), before)
 
 			// 刷新UI
 			ui.refreshUI()
@@ -746,6 +892,10 @@ func (ui *ProfitCalculatorUI) deleteProfitRecord(profitID string) {
 	var profitAmount float64
 	for _, profit := range ui.data.MonthlyProfits {
 		if profit.ID == profitID {
+			if ui.data.IsLocked(profit.Date) {
+				dialog.ShowError(ErrPeriodLocked, ui.window)
+				return
+			}
 			profitDate = profit.Date.Format("2006-01-02")
 			profitAmount = profit.TotalProfit
 			break
@@ -762,6 +912,7 @@ func (ui *ProfitCalculatorUI) deleteProfitRecord(profitID string) {
 			}
 
 			// 删除收益记录
+			before := CloneData(ui.data)
 			newProfits := []MonthlyProfit{}
 			for _, profit := range ui.data.MonthlyProfits {
 				if profit.ID != profitID {
@@ -770,8 +921,8 @@ func (ui *ProfitCalculatorUI) deleteProfitRecord(profitID string) {
 			}
 			ui.data.MonthlyProfits = newProfits
 
-			// 保存数据
-			ui.saveData()
+			// 保存数据并记录审计日志
+			ui.commitChange(ActionDeleteProfit, fmt.Sprintf("删除 %s 的收益记录（%s）", profitDate, formatCurrency(profitAmount)), before)
 
 			// 刷新UI
 			ui.refreshUI()
@@ -0,0 +1,86 @@
+package profit_calculator
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/storage_recovery"
+)
+
+// watchedStorage 可以发现数据文件外部修改的存储（JSON文件和加密文件）
+type watchedStorage interface {
+	Guard() *file_watch.Guard
+}
+
+// watchDataFile 监视数据文件，被其他程序修改或从备份恢复后重新加载并刷新界面；再次调用时先停止之前的监视
+func (ui *ProfitCalculatorUI) watchDataFile() {
+	ui.StopWatching()
+	if _, ok := ui.storage.(watchedStorage); !ok {
+		return
+	}
+
+	ui.stopWatch = file_watch.Watch(ui.storagePath, func() {
+		fyne.Do(ui.reloadIfChanged)
+	})
+}
+
+// StopWatching 停止监视数据文件，在窗口关闭时调用
+func (ui *ProfitCalculatorUI) StopWatching() {
+	if ui.stopWatch != nil {
+		ui.stopWatch()
+		ui.stopWatch = nil
+	}
+}
+
+// reloadIfChanged 数据文件内容与上次读写时不同时重新加载
+func (ui *ProfitCalculatorUI) reloadIfChanged() {
+	storage, ok := ui.storage.(watchedStorage)
+	if !ok || ui.Locked() || !storage.Guard().Changed() {
+		return
+	}
+
+	ui.reloadData()
+}
+
+// reloadData 重新读取数据文件，并将外部修改记入审计日志以便撤销
+func (ui *ProfitCalculatorUI) reloadData() {
+	before := ui.data
+	ui.loadData()
+
+	if ui.history != nil && ui.loadErr == nil {
+		if err := ui.history.Record(ActionExternalChange, "重新加载被其他程序修改的数据文件", before, ui.data); err != nil {
+			dialog.ShowError(errors.New("写入审计日志失败: "+err.Error()), ui.window)
+		}
+	}
+
+	ui.refreshUI()
+}
+
+// confirmOverwrite 数据文件在读取后被其他程序修改时，询问覆盖还是重新加载。
+// before 为未保存的修改之前的数据（可以为 nil），重新加载时以它为基准记录外部修改；覆盖成功后调用 onOverwritten
+func (ui *ProfitCalculatorUI) confirmOverwrite(before *ProfitCalculatorData, onOverwritten func()) {
+	message := widget.NewLabel("收益数据文件在本程序读取之后被其他程序修改。\n" +
+		"覆盖：用当前显示的数据覆盖文件中的内容\n" +
+		"重新加载：读取文件中的最新内容（本次修改将丢失）")
+
+	dialog.NewCustomConfirm("⚠️ 数据文件已被修改", "覆盖", "重新加载", message, func(overwrite bool) {
+		if !overwrite {
+			if before != nil {
+				ui.data = before
+			}
+			ui.reloadData()
+			return
+		}
+
+		if storage, ok := ui.storage.(watchedStorage); ok {
+			storage.Guard().Forget()
+		}
+		if err := ui.saveData(); (err == nil || storage_recovery.Retryable(err)) && onOverwritten != nil {
+			onOverwritten()
+		}
+	}, ui.window).Show()
+}
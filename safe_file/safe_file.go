@@ -0,0 +1,203 @@
+package safe_file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultBackups 默认保留的历史版本数量
+const DefaultBackups = 3
+
+// RecoveredError 主文件损坏并已从备份恢复，返回的数据可以正常使用
+type RecoveredError struct {
+	Path        string // 数据文件路径
+	BackupPath  string // 用于恢复的备份文件
+	CorruptPath string // 损坏文件的保留位置
+	Err         error  // 主文件的原始错误
+}
+
+func (e *RecoveredError) Error() string {
+	return fmt.Sprintf("数据文件 %s 已损坏（%v），已从备份 %s 恢复，损坏的文件保存为 %s",
+		filepath.Base(e.Path), e.Err, filepath.Base(e.BackupPath), filepath.Base(e.CorruptPath))
+}
+
+func (e *RecoveredError) Unwrap() error {
+	return e.Err
+}
+
+// BackupPath 返回第 n 个历史版本的路径（1 为最新）
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak%d", path, n)
+}
+
+// WriteFile 原子地写入文件：先写临时文件并 fsync，再重命名覆盖目标文件。
+// 覆盖前会将旧内容轮换保存为 path.bak1 ... path.bakN。
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFileWithBackups(path, data, perm, DefaultBackups)
+}
+
+// WriteFileWithBackups 与 WriteFile 相同，但可以指定保留的历史版本数量
+func WriteFileWithBackups(path string, data []byte, perm os.FileMode, backups int) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	if backups > 0 {
+		if err := rotateBackups(path, backups); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// rotateBackups 轮换历史版本，并将当前文件保存为 .bak1
+func rotateBackups(path string, backups int) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	os.Remove(BackupPath(path, backups))
+	for i := backups - 1; i >= 1; i-- {
+		if _, err := os.Stat(BackupPath(path, i)); err == nil {
+			if err := os.Rename(BackupPath(path, i), BackupPath(path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 优先使用硬链接，目标文件在整个过程中始终存在
+	if err := os.Link(path, BackupPath(path, 1)); err == nil {
+		return nil
+	}
+	return copyFile(path, BackupPath(path, 1))
+}
+
+// RemoveBackups 删除文件的所有历史版本
+func RemoveBackups(path string) error {
+	for i := 1; ; i++ {
+		err := os.Remove(BackupPath(path, i))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadFile 读取文件并用 validate 校验内容。
+// 主文件损坏时按从新到旧的顺序尝试历史版本，找到可用版本后将其恢复为主文件，
+// 并返回恢复的数据和 *RecoveredError。没有可用版本时返回主文件的错误。
+func ReadFile(path string, validate func([]byte) error) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mainErr := validate(data)
+	if mainErr == nil {
+		return data, nil
+	}
+
+	for i := 1; ; i++ {
+		backupPath := BackupPath(path, i)
+		backup, err := os.ReadFile(backupPath)
+		if err != nil {
+			break
+		}
+		if validate(backup) != nil {
+			continue
+		}
+
+		// 保留损坏的文件以便排查，然后用备份恢复主文件
+		corruptPath := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+		if err := os.Rename(path, corruptPath); err != nil {
+			return nil, mainErr
+		}
+		info, statErr := os.Stat(backupPath)
+		perm := os.FileMode(0600)
+		if statErr == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := WriteFileWithBackups(path, backup, perm, 0); err != nil {
+			return nil, err
+		}
+
+		return backup, &RecoveredError{
+			Path:        path,
+			BackupPath:  backupPath,
+			CorruptPath: corruptPath,
+			Err:         mainErr,
+		}
+	}
+
+	return nil, mainErr
+}
+
+// copyFile 复制文件内容
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir 将目录项的修改落盘（部分平台不支持，忽略错误）
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
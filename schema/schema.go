@@ -0,0 +1,217 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"my_portfolio/safe_file"
+)
+
+// ErrUnsupportedVersion 数据文件的版本高于当前程序支持的版本
+var ErrUnsupportedVersion = errors.New("数据文件版本高于当前程序支持的版本，请升级应用")
+
+// envelope 带版本信息的数据文件格式
+type envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// MigrateFunc 将数据从某个版本升级到下一个版本
+type MigrateFunc func(payload json.RawMessage) (json.RawMessage, error)
+
+// BackupFunc 在迁移前保存原始文件内容，version 为原始数据的版本
+type BackupFunc func(version int, original []byte) error
+
+// migration 已注册的迁移步骤
+type migration struct {
+	description string
+	migrate     MigrateFunc
+}
+
+// Registry 数据格式的迁移注册表
+//
+// 没有版本信息的旧文件视为版本0，加载时按 0→1→2… 的顺序逐步升级到当前版本。
+type Registry struct {
+	name       string
+	current    int
+	migrations map[int]migration
+}
+
+// NewRegistry 创建迁移注册表，current 为当前程序写入的数据版本
+func NewRegistry(name string, current int) *Registry {
+	return &Registry{
+		name:       name,
+		current:    current,
+		migrations: make(map[int]migration),
+	}
+}
+
+// Register 注册从 from 版本升级到 from+1 版本的迁移
+func (r *Registry) Register(from int, description string, fn MigrateFunc) *Registry {
+	r.migrations[from] = migration{
+		description: description,
+		migrate:     fn,
+	}
+	return r
+}
+
+// Current 返回当前数据版本
+func (r *Registry) Current() int {
+	return r.current
+}
+
+// Keep 只添加版本信息、不修改内容的迁移
+func Keep(payload json.RawMessage) (json.RawMessage, error) {
+	return payload, nil
+}
+
+// Encode 将数据序列化为带当前版本信息的JSON
+func (r *Registry) Encode(v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return r.Wrap(payload)
+}
+
+// Wrap 用当前版本信息包装已序列化的数据（例如迁移后写回文件）
+func (r *Registry) Wrap(payload []byte) ([]byte, error) {
+	return json.MarshalIndent(envelope{
+		SchemaVersion: r.current,
+		Data:          payload,
+	}, "", "  ")
+}
+
+// Version 解析文件内容的版本和数据部分；没有版本信息的旧格式返回版本0和原始内容
+func Version(data []byte) (int, json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return 0, trimmed, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return 0, nil, err
+	}
+	if _, ok := fields["schema_version"]; !ok {
+		return 0, trimmed, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(trimmed, &env); err != nil {
+		return 0, nil, err
+	}
+	if env.SchemaVersion < 1 {
+		return 0, nil, fmt.Errorf("无效的数据版本: %d", env.SchemaVersion)
+	}
+	return env.SchemaVersion, env.Data, nil
+}
+
+// Upgrade 将文件内容升级到当前版本并返回数据部分。
+// 需要迁移时先调用 backup 保存原始内容（backup 可以为nil），migrated 表示是否执行了迁移。
+func (r *Registry) Upgrade(data []byte, backup BackupFunc) (payload []byte, migrated bool, err error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, false, nil
+	}
+
+	version, payload, err := Version(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > r.current {
+		return nil, false, fmt.Errorf("%w（%s 的版本为 %d，当前支持 %d）", ErrUnsupportedVersion, r.name, version, r.current)
+	}
+	if version == r.current {
+		return payload, false, nil
+	}
+
+	if backup != nil {
+		if err := backup(version, data); err != nil {
+			return nil, false, fmt.Errorf("迁移前备份失败: %w", err)
+		}
+	}
+
+	for v := version; v < r.current; v++ {
+		m, ok := r.migrations[v]
+		if !ok {
+			return nil, false, fmt.Errorf("%s 缺少从版本 %d 升级到 %d 的迁移", r.name, v, v+1)
+		}
+		if payload, err = m.migrate(payload); err != nil {
+			return nil, false, fmt.Errorf("%s 从版本 %d 升级失败（%s）: %w", r.name, v, m.description, err)
+		}
+	}
+
+	return payload, true, nil
+}
+
+// Decode 将文件内容升级到当前版本（不写入备份）并解析到 v，用于校验文件内容。
+// 版本高于当前程序时只检查格式，不视为文件损坏。
+func (r *Registry) Decode(data []byte, v any) error {
+	version, _, err := Version(data)
+	if err != nil {
+		return err
+	}
+	if version > r.current {
+		return nil
+	}
+
+	payload, _, err := r.Upgrade(data, nil)
+	if err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// BackupPath 返回迁移前备份文件的路径
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// UpgradeFile 升级明文数据文件的内容：需要迁移时先将原文件备份为 path.vN.bak，
+// 迁移后立即以当前版本写回文件，返回升级后的数据部分。
+func (r *Registry) UpgradeFile(path string, data []byte, perm os.FileMode) ([]byte, error) {
+	payload, migrated, err := r.Upgrade(data, func(version int, original []byte) error {
+		return safe_file.WriteFileWithBackups(BackupPath(path, version), original, perm, 0)
+	})
+	if err != nil || !migrated {
+		return payload, err
+	}
+
+	upgraded, err := r.Wrap(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := safe_file.WriteFile(path, upgraded, perm); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// CheckWritable 检查是否可以用当前版本覆盖明文数据文件。
+// 文件由更新版本的程序写入时返回 ErrUnsupportedVersion，避免旧程序覆盖新格式的数据。
+func (r *Registry) CheckWritable(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	version, _, err := Version(data)
+	if err != nil {
+		// 内容已损坏，允许用新数据覆盖（旧内容会保留在历史版本中）
+		return nil
+	}
+	if version > r.current {
+		return fmt.Errorf("%w（%s 的版本为 %d，当前支持 %d）", ErrUnsupportedVersion, r.name, version, r.current)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package secure_storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"my_portfolio/safe_file"
+)
+
+// LineFile 逐行加密的追加式文件（例如 JSON Lines 格式的审计日志）
+//
+// 每一行是一个独立的单行加密块，追加时只写入新的一行，不需要解密和重写整个文件。
+// 同一文件中的各行使用相同的盐和密钥，解锁时只需派生一次密钥。
+type LineFile struct {
+	path       string
+	mu         sync.Mutex
+	passphrase string
+	key        *derivedKey
+	keys       map[string]*derivedKey // 其他盐派生的密钥，按盐缓存
+}
+
+// NewLineFile 创建逐行加密文件访问器（初始为锁定状态）
+func NewLineFile(path string) *LineFile {
+	return &LineFile{
+		path: path,
+		keys: make(map[string]*derivedKey),
+	}
+}
+
+// Path 返回文件路径
+func (f *LineFile) Path() string {
+	return f.path
+}
+
+// Unlock 使用密码解锁；文件中已有加密行时使用第一行验证密码并沿用它的盐。
+// 旧版本整个文件作为一个加密块保存，解锁时转换为逐行加密
+func (f *LineFile) Unlock(passphrase string) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var legacy []byte
+	var key *derivedKey
+	if IsEncrypted(data) {
+		if legacy, err = Decrypt(data, passphrase); err != nil {
+			return err
+		}
+	} else if env := firstEnvelope(data); env != nil {
+		if key, err = newKey(passphrase, env.Salt, env.Iterations); err != nil {
+			return err
+		}
+		if _, err := key.open(env); err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		if key, err = newKey(passphrase, nil, iterations); err != nil {
+			return err
+		}
+	}
+
+	if legacy != nil {
+		converted, err := sealLines(key, legacy)
+		if err != nil {
+			return err
+		}
+		if err := replaceLines(f.path, converted); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	f.passphrase = passphrase
+	f.key = key
+	f.mu.Unlock()
+	return nil
+}
+
+// Append 加密并在文件末尾追加一行（权限 0600）
+func (f *LineFile) Append(plaintext []byte) error {
+	f.mu.Lock()
+	key := f.key
+	f.mu.Unlock()
+
+	if key == nil {
+		return ErrLocked
+	}
+
+	line, err := sealLine(key, plaintext)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	// 上次写入中途崩溃留下的半行不能和新的一行连在一起
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Lines 按写入顺序解密全部行；文件不存在时返回 os.ErrNotExist。
+// 无法解析或解密的行（例如写入中途崩溃留下的半行）会被跳过，明文行返回 ErrUnexpectedPlaintext
+func (f *LineFile) Lines() ([][]byte, error) {
+	f.mu.Lock()
+	key := f.key
+	f.mu.Unlock()
+
+	if key == nil {
+		return nil, ErrLocked
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if !IsEncrypted(data) {
+			if json.Valid(data) {
+				return nil, ErrUnexpectedPlaintext
+			}
+			continue
+		}
+
+		env, err := parseEnvelope(data)
+		if err != nil {
+			continue
+		}
+		k, err := f.keyFor(env)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := k.open(env)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, plaintext)
+	}
+
+	return lines, scanner.Err()
+}
+
+// keyFor 返回能解密该行的密钥；其他进程用不同的盐写入时重新派生并缓存
+func (f *LineFile) keyFor(env *envelope) (*derivedKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.key.matches(env) {
+		return f.key, nil
+	}
+	if k, ok := f.keys[string(env.Salt)]; ok && k.matches(env) {
+		return k, nil
+	}
+
+	k, err := newKey(f.passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	f.keys[string(env.Salt)] = k
+	return k, nil
+}
+
+// EncryptLines 将明文的逐行文件原地转换为逐行加密格式；文件不存在时直接返回
+func EncryptLines(path, passphrase string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if IsEncrypted(data) || firstEnvelope(data) != nil {
+		return ErrAlreadyEncrypted
+	}
+
+	key, err := newKey(passphrase, nil, iterations)
+	if err != nil {
+		return err
+	}
+	encrypted, err := sealLines(key, data)
+	if err != nil {
+		return err
+	}
+	return replaceLines(path, encrypted)
+}
+
+// DecryptLines 将逐行加密的文件（或旧版本整个加密的文件）原地转换回明文；文件不存在时直接返回
+func DecryptLines(path, passphrase string) error {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	f := NewLineFile(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !IsEncrypted(data) && firstEnvelope(data) == nil {
+		return ErrNotEncrypted
+	}
+	if err := f.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	lines, err := f.Lines()
+	if err != nil {
+		return err
+	}
+	var plaintext []byte
+	for _, line := range lines {
+		plaintext = append(append(plaintext, line...), '\n')
+	}
+	return replaceLines(path, plaintext)
+}
+
+// replaceLines 原子地重写整个文件；转换前的内容格式不同，不保留历史版本
+func replaceLines(path string, data []byte) error {
+	if err := safe_file.WriteFileWithBackups(path, data, 0600, 0); err != nil {
+		return err
+	}
+	return safe_file.RemoveBackups(path)
+}
+
+// firstEnvelope 返回第一个可解析的加密行，没有时返回 nil
+func firstEnvelope(data []byte) *envelope {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !IsEncrypted(line) {
+			continue
+		}
+		if env, err := parseEnvelope(line); err == nil {
+			return env
+		}
+	}
+	return nil
+}
+
+// sealLine 加密一行内容，返回以换行结尾的单行加密块
+func sealLine(k *derivedKey, plaintext []byte) ([]byte, error) {
+	env, err := k.sealEnvelope(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// sealLines 逐行加密明文内容，跳过空行
+func sealLines(k *derivedKey, data []byte) ([]byte, error) {
+	var encrypted []byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		sealed, err := sealLine(k, line)
+		if err != nil {
+			return nil, err
+		}
+		encrypted = append(encrypted, sealed...)
+	}
+	return encrypted, nil
+}
@@ -0,0 +1,458 @@
+package secure_storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"my_portfolio/safe_file"
+)
+
+const (
+	formatName = "my_portfolio-encrypted"
+	version    = 1
+	iterations = 600000
+	keyLength  = 32
+	saltLength = 16
+)
+
+var (
+	ErrLocked           = errors.New("数据已加密，请先输入密码解锁")
+	ErrWrongPassphrase  = errors.New("密码错误或数据已损坏")
+	ErrEmptyPassphrase  = errors.New("密码不能为空")
+	ErrNotEncrypted     = errors.New("文件未加密")
+	ErrAlreadyEncrypted = errors.New("文件已经是加密格式")
+
+	ErrUnexpectedPlaintext = errors.New("文件不是加密格式，可能已被替换为明文")
+)
+
+// envelope 加密文件格式
+type envelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt 使用由密码派生的密钥（PBKDF2-SHA256）和 AES-256-GCM 加密数据
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	k, err := newKey(passphrase, nil, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return k.seal(plaintext)
+}
+
+// Decrypt 解密由 Encrypt 生成的数据
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	env, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := newKey(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	return k.open(env)
+}
+
+// parseEnvelope 解析加密文件头
+func parseEnvelope(data []byte) (*envelope, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Format != formatName {
+		return nil, ErrNotEncrypted
+	}
+	if env.Version != version || env.KDF != "pbkdf2-sha256" {
+		return nil, errors.New("不支持的加密文件版本")
+	}
+	return &env, nil
+}
+
+// derivedKey 由密码派生的密钥及其参数
+type derivedKey struct {
+	salt       []byte
+	iterations int
+	gcm        cipher.AEAD
+}
+
+// newKey 根据密码派生密钥；salt 为空时生成新的随机盐
+func newKey(passphrase string, salt []byte, iter int) (*derivedKey, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+
+	if salt == nil {
+		salt = make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(passphrase, salt, iter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &derivedKey{
+		salt:       salt,
+		iterations: iter,
+		gcm:        gcm,
+	}, nil
+}
+
+// seal 使用随机 nonce 加密数据并生成完整的加密文件内容
+func (k *derivedKey) seal(plaintext []byte) ([]byte, error) {
+	env, err := k.sealEnvelope(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// sealEnvelope 使用随机 nonce 加密数据
+func (k *derivedKey) sealEnvelope(plaintext []byte) (*envelope, error) {
+	nonce := make([]byte, k.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	env := envelope{
+		Format:     formatName,
+		Version:    version,
+		KDF:        "pbkdf2-sha256",
+		Iterations: k.iterations,
+		Salt:       k.salt,
+		Nonce:      nonce,
+	}
+	// 将文件头作为附加认证数据，防止参数被篡改
+	env.Ciphertext = k.gcm.Seal(nil, nonce, plaintext, additionalData(env))
+
+	return &env, nil
+}
+
+// open 解密加密文件内容
+func (k *derivedKey) open(env *envelope) ([]byte, error) {
+	if len(env.Nonce) != k.gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	plaintext, err := k.gcm.Open(nil, env.Nonce, env.Ciphertext, additionalData(*env))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return plaintext, nil
+}
+
+// matches 判断加密文件是否使用相同的密钥参数
+func (k *derivedKey) matches(env *envelope) bool {
+	return k.iterations == env.Iterations && bytes.Equal(k.salt, env.Salt)
+}
+
+// IsEncrypted 判断数据是否为加密格式
+func IsEncrypted(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+
+	var header struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Format == formatName
+}
+
+// newGCM 根据密码和盐派生密钥并创建 AES-GCM
+func newGCM(passphrase string, salt []byte, iter int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iter, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData 构造附加认证数据
+func additionalData(env envelope) []byte {
+	header, _ := json.Marshal(struct {
+		Format     string `json:"format"`
+		Version    int    `json:"version"`
+		KDF        string `json:"kdf"`
+		Iterations int    `json:"iterations"`
+		Salt       []byte `json:"salt"`
+	}{env.Format, env.Version, env.KDF, env.Iterations, env.Salt})
+	return header
+}
+
+// File 加密文件，解锁后可透明读写明文内容
+//
+// 解锁时派生一次密钥并缓存，之后的读写复用同一个盐和密钥，
+// 每次写入使用新的随机 nonce，避免每次保存都重新进行耗时的密钥派生。
+type File struct {
+	path       string
+	mu         sync.Mutex
+	passphrase string
+	key        *derivedKey
+}
+
+// NewFile 创建加密文件访问器（初始为锁定状态）
+func NewFile(path string) *File {
+	return &File{
+		path: path,
+	}
+}
+
+// Path 返回文件路径
+func (f *File) Path() string {
+	return f.path
+}
+
+// Locked 是否尚未解锁
+func (f *File) Locked() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.key == nil
+}
+
+// Unlock 使用密码解锁；文件已存在时会验证密码是否正确
+func (f *File) Unlock(passphrase string) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var key *derivedKey
+	if IsEncrypted(data) {
+		env, err := parseEnvelope(data)
+		if err != nil {
+			return err
+		}
+		if key, err = newKey(passphrase, env.Salt, env.Iterations); err != nil {
+			return err
+		}
+		if _, err := key.open(env); err != nil {
+			return err
+		}
+	} else if key, err = newKey(passphrase, nil, iterations); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.passphrase = passphrase
+	f.key = key
+	f.mu.Unlock()
+	return nil
+}
+
+// Read 读取并解密文件内容；文件不存在时返回 os.ErrNotExist，文件是明文时返回 ErrUnexpectedPlaintext。
+// 主文件损坏、无法解密或是明文时会回退到最近的可用备份，此时同时返回数据和 *safe_file.RecoveredError。
+func (f *File) Read() ([]byte, error) {
+	f.mu.Lock()
+	passphrase, key := f.passphrase, f.key
+	f.mu.Unlock()
+
+	if key == nil {
+		return nil, ErrLocked
+	}
+
+	var plaintext []byte
+	_, err := safe_file.ReadFile(f.path, func(data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
+		if !IsEncrypted(data) {
+			// 启用加密时已经转换过，明文内容说明文件被替换，不能当作加密数据使用
+			return ErrUnexpectedPlaintext
+		}
+
+		env, err := parseEnvelope(data)
+		if err != nil {
+			return err
+		}
+		k := key
+		if !k.matches(env) {
+			// 文件被其他进程用不同的盐重写过
+			if k, err = newKey(passphrase, env.Salt, env.Iterations); err != nil {
+				return err
+			}
+		}
+
+		plaintext, err = k.open(env)
+		return err
+	})
+	if err != nil {
+		var recovered *safe_file.RecoveredError
+		if errors.As(err, &recovered) {
+			return plaintext, err
+		}
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+// Write 加密并写入文件（权限 0600）
+func (f *File) Write(plaintext []byte) error {
+	f.mu.Lock()
+	key := f.key
+	f.mu.Unlock()
+
+	if key == nil {
+		return ErrLocked
+	}
+
+	data, err := key.seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	return writePrivate(f.path, data)
+}
+
+// WriteCopy 使用相同的密钥加密内容并写入另一个文件（不保留历史版本），用于保存加密备份
+func (f *File) WriteCopy(path string, plaintext []byte) error {
+	f.mu.Lock()
+	key := f.key
+	f.mu.Unlock()
+
+	if key == nil {
+		return ErrLocked
+	}
+
+	data, err := key.seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	return safe_file.WriteFileWithBackups(path, data, 0600, 0)
+}
+
+// EncryptFile 将明文文件原地转换为加密格式；文件不存在时直接返回。
+// 明文的历史版本一并删除，升级前备份和损坏文件等其他副本使用相同的密钥加密
+func EncryptFile(path, passphrase string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if IsEncrypted(data) {
+		return ErrAlreadyEncrypted
+	}
+
+	key, err := newKey(passphrase, nil, iterations)
+	if err != nil {
+		return err
+	}
+	encrypted, err := key.seal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := safe_file.WriteFileWithBackups(path, encrypted, 0600, 0); err != nil {
+		return err
+	}
+	if err := safe_file.RemoveBackups(path); err != nil {
+		return err
+	}
+	return convertCopies(path, func(data []byte) ([]byte, error) {
+		if IsEncrypted(data) {
+			return nil, nil
+		}
+		return key.seal(data)
+	})
+}
+
+// DecryptFile 将加密文件原地转换回明文格式；文件不存在时直接返回。
+// 加密的历史版本一并删除，其他副本转换回明文以便从中恢复
+func DecryptFile(path, passphrase string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !IsEncrypted(data) {
+		return ErrNotEncrypted
+	}
+
+	plaintext, err := Decrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := safe_file.WriteFileWithBackups(path, plaintext, 0600, 0); err != nil {
+		return err
+	}
+	if err := safe_file.RemoveBackups(path); err != nil {
+		return err
+	}
+	return convertCopies(path, func(data []byte) ([]byte, error) {
+		if !IsEncrypted(data) {
+			return nil, nil
+		}
+		// 使用其他密码加密的副本保持原样
+		if plaintext, err := Decrypt(data, passphrase); err == nil {
+			return plaintext, nil
+		}
+		return nil, nil
+	})
+}
+
+// copyPatterns 数据文件除历史版本以外的副本：升级前备份（path.vN.bak）和损坏文件（path.corrupt-<时间>）
+var copyPatterns = []string{".v*.bak", ".corrupt-*"}
+
+// convertCopies 用 convert 转换数据文件的所有副本，convert 返回 nil 时保持该副本不变
+func convertCopies(path string, convert func([]byte) ([]byte, error)) error {
+	for _, pattern := range copyPatterns {
+		matches, err := filepath.Glob(path + pattern)
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return err
+			}
+			converted, err := convert(data)
+			if err != nil {
+				return err
+			}
+			if converted == nil {
+				continue
+			}
+			if err := safe_file.WriteFileWithBackups(match, converted, 0600, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writePrivate 原子地写入文件并确保只有当前用户可读写
+func writePrivate(path string, data []byte) error {
+	return safe_file.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,202 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/local_api"
+)
+
+var (
+	apiMu       sync.Mutex
+	apiServer   *local_api.Server
+	apiStartErr error // 最近一次启动失败的原因
+)
+
+// StartLocalAPI 按设置启动本地 API；已在运行时先停止，设置中未启用时只停止。
+// 数据读写在界面线程中执行，不能在界面线程中调用（停止时会等待正在处理的请求）。
+func StartLocalAPI() error {
+	apiMu.Lock()
+	defer apiMu.Unlock()
+
+	stopLocalAPILocked()
+	apiStartErr = startLocalAPILocked()
+	return apiStartErr
+}
+
+func startLocalAPILocked() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.APIEnabled {
+		return nil
+	}
+
+	server, err := local_api.Start(local_api.Options{
+		Port:  cfg.APIPort,
+		Token: cfg.APIToken,
+		Do:    fyne.DoAndWait,
+	})
+	if err != nil {
+		return err
+	}
+	apiServer = server
+	return nil
+}
+
+// StopLocalAPI 停止本地 API
+func StopLocalAPI() {
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	stopLocalAPILocked()
+}
+
+func stopLocalAPILocked() {
+	if apiServer != nil {
+		apiServer.Stop()
+		apiServer = nil
+	}
+}
+
+// localAPIState 返回本地 API 的地址（未运行时为空）和最近一次启动失败的原因
+func localAPIState() (string, error) {
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	if apiServer == nil {
+		return "", apiStartErr
+	}
+	return apiServer.Addr(), nil
+}
+
+// createAPICard 创建本地 API 设置卡片
+func (s *SettingsUI) createAPICard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("🌐 本地 API", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("供其他程序通过 HTTP/JSON 读写体重和收益数据，只接受本机访问")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+	cardDesc.Wrapping = fyne.TextWrapWord
+
+	cfg, _ := LoadConfig()
+
+	enableCheck := widget.NewCheck("启用本地 API", nil)
+	enableCheck.SetChecked(cfg.APIEnabled)
+
+	portEntry := widget.NewEntry()
+	portEntry.SetPlaceHolder(strconv.Itoa(local_api.DefaultPort))
+	if cfg.APIPort > 0 {
+		portEntry.SetText(strconv.Itoa(cfg.APIPort))
+	}
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapBreak
+	updateStatus := func() {
+		cfg, _ := LoadConfig()
+		addr, startErr := localAPIState()
+		statusLabel.SetText(apiStatusText(cfg, addr, startErr))
+	}
+	updateStatus()
+
+	// restart 在后台重新启动服务，完成后刷新状态
+	restart := func() {
+		go func() {
+			StartLocalAPI()
+			fyne.Do(updateStatus)
+		}()
+	}
+
+	applyButton := widget.NewButton("保存并应用", func() {
+		port := 0
+		if text := strings.TrimSpace(portEntry.Text); text != "" {
+			value, err := strconv.Atoi(text)
+			if err != nil || value < 1024 || value > 65535 {
+				dialog.ShowError(errors.New("端口必须是 1024-65535 之间的数字"), s.window)
+				return
+			}
+			port = value
+		}
+
+		var tokenErr error
+		err := UpdateConfig(func(cfg *Config) error {
+			cfg.APIEnabled = enableCheck.Checked
+			cfg.APIPort = port
+			if cfg.APIEnabled && cfg.APIToken == "" {
+				cfg.APIToken, tokenErr = local_api.NewToken()
+			}
+			return tokenErr
+		})
+		if tokenErr != nil {
+			dialog.ShowError(errors.New("生成访问令牌失败: "+tokenErr.Error()), s.window)
+			return
+		}
+		if err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+			return
+		}
+		restart()
+	})
+
+	copyButton := widget.NewButton("复制访问令牌", func() {
+		cfg, _ := LoadConfig()
+		if cfg.APIToken == "" {
+			dialog.ShowInformation("本地 API", "启用本地 API 后会生成访问令牌", s.window)
+			return
+		}
+		s.window.Clipboard().SetContent(cfg.APIToken)
+		dialog.ShowInformation("本地 API", "访问令牌已复制到剪贴板", s.window)
+	})
+
+	regenerateButton := widget.NewButton("重新生成令牌", func() {
+		dialog.ShowConfirm("重新生成令牌", "使用旧令牌的程序将无法继续访问，确定重新生成吗？", func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			token, err := local_api.NewToken()
+			if err != nil {
+				dialog.ShowError(errors.New("生成访问令牌失败: "+err.Error()), s.window)
+				return
+			}
+			err = UpdateConfig(func(cfg *Config) error {
+				cfg.APIToken = token
+				return nil
+			})
+			if err != nil {
+				dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+				return
+			}
+			restart()
+		}, s.window)
+	})
+
+	return container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+		enableCheck,
+		container.NewBorder(nil, nil, widget.NewLabel("端口"), nil, portEntry),
+		statusLabel,
+		container.NewHBox(applyButton, copyButton, regenerateButton),
+	)
+}
+
+// apiStatusText 返回本地 API 的运行状态说明
+func apiStatusText(cfg *Config, addr string, startErr error) string {
+	switch {
+	case startErr != nil:
+		return "启动失败：" + startErr.Error()
+	case !cfg.APIEnabled:
+		return "未启用"
+	case addr == "":
+		return "未运行"
+	}
+
+	return fmt.Sprintf("正在运行：%s\nOpenAPI 描述：%s/api/openapi.json\n请求需要携带 Authorization: Bearer <访问令牌>", addr, addr)
+}
@@ -0,0 +1,372 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/backup"
+)
+
+// backupIntervals 自动备份间隔选项（小时）
+var backupIntervals = []struct {
+	name  string
+	hours int
+}{
+	{"关闭", 0},
+	{"每天", 24},
+	{"每周", 24 * 7},
+}
+
+// BackupSource 返回应用设置的备份来源。
+// 恢复设置时保留当前的数据目录，避免恢复后找不到数据；
+// 同时保留本机的设备ID，避免从其他设备的备份恢复后两台设备的同步快照互相覆盖。
+func BackupSource() (backup.Source, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return backup.Source{}, err
+	}
+
+	return backup.Source{
+		ID:    "settings",
+		Title: "应用设置",
+		Files: []string{path},
+		Transform: func(name string, data []byte) ([]byte, error) {
+			var restored Config
+			if err := json.Unmarshal(data, &restored); err != nil {
+				return nil, err
+			}
+
+			current, err := LoadConfig()
+			if err != nil {
+				return nil, err
+			}
+			restored.DataDir = current.DataDir
+			restored.PreviousDataDir = current.PreviousDataDir
+			restored.DeviceID = current.DeviceID
+			// 本地 API 只对本机有效，恢复备份不应打开端口或更换令牌
+			restored.APIEnabled = current.APIEnabled
+			restored.APIPort = current.APIPort
+			restored.APIToken = current.APIToken
+
+			return json.MarshalIndent(&restored, "", "  ")
+		},
+	}, nil
+}
+
+// PendingRestorePath 返回待恢复文件的暂存目录
+func PendingRestorePath() string {
+	return filepath.Join(activeDataDir, backup.PendingRestoreDir)
+}
+
+// StartAutoBackup 在后台按设置定期执行自动备份，直到 ctx 被取消；备份失败时调用 onError
+func StartAutoBackup(ctx context.Context, onError func(error)) {
+	go backup.RunScheduler(ctx, func() backup.Schedule {
+		cfg, err := LoadConfig()
+		if err != nil {
+			return backup.Schedule{}
+		}
+		return cfg.BackupSchedule()
+	}, func(path string, err error) {
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		err = UpdateConfig(func(cfg *Config) error {
+			cfg.LastBackup = time.Now()
+			return nil
+		})
+		if err != nil {
+			onError(err)
+		}
+	})
+}
+
+// createBackupCard 创建备份与恢复设置卡片
+func (s *SettingsUI) createBackupCard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("💾 备份与恢复", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("将所有工具数据、历史和设置打包为一个备份文件")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+
+	backupButton := widget.NewButton("立即备份", func() {
+		s.showBackupDialog()
+	})
+	restoreButton := widget.NewButton("从备份恢复", func() {
+		s.showRestoreFileDialog()
+	})
+
+	autoLabel := widget.NewLabel("")
+	autoLabel.Wrapping = fyne.TextWrapWord
+	updateAutoLabel := func() {
+		cfg, _ := LoadConfig()
+		autoLabel.SetText(autoBackupText(cfg))
+	}
+	updateAutoLabel()
+
+	autoButton := widget.NewButton("自动备份设置", func() {
+		s.showAutoBackupDialog(updateAutoLabel)
+	})
+
+	card := container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+		container.NewHBox(backupButton, restoreButton),
+		autoLabel,
+		autoButton,
+	)
+
+	if backup.HasPendingRestore(PendingRestorePath()) {
+		pendingLabel := widget.NewLabel("⏳ 有待完成的恢复，重启应用后生效")
+		var cancelButton *widget.Button
+		cancelButton = widget.NewButton("取消恢复", func() {
+			if err := backup.CancelPendingRestore(PendingRestorePath()); err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			pendingLabel.SetText("已取消恢复")
+			cancelButton.Hide()
+		})
+		card.Add(container.NewHBox(pendingLabel, cancelButton))
+	}
+
+	return card
+}
+
+// autoBackupText 返回自动备份状态说明
+func autoBackupText(cfg *Config) string {
+	schedule := cfg.BackupSchedule()
+	if !schedule.Enabled() {
+		return "自动备份：关闭"
+	}
+
+	text := fmt.Sprintf("自动备份：%s → %s", intervalName(cfg.BackupIntervalHours), cfg.BackupDir)
+	if cfg.BackupKeep > 0 {
+		text += fmt.Sprintf("（保留最近 %d 个）", cfg.BackupKeep)
+	}
+	if !cfg.LastBackup.IsZero() {
+		text += "\n上次备份：" + cfg.LastBackup.Format("2006-01-02 15:04")
+	}
+	return text
+}
+
+// intervalName 返回备份间隔的显示名称
+func intervalName(hours int) string {
+	for _, interval := range backupIntervals {
+		if interval.hours == hours {
+			return interval.name
+		}
+	}
+	return fmt.Sprintf("每 %d 小时", hours)
+}
+
+// showBackupDialog 选择保存位置并创建完整备份
+func (s *SettingsUI) showBackupDialog() {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, s.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		manifest, err := backup.WriteArchive(writer)
+		if err != nil {
+			dialog.ShowError(errors.New("备份失败: "+err.Error()), s.window)
+			return
+		}
+
+		dialog.ShowInformation("✅ 备份完成",
+			fmt.Sprintf("已备份 %d 个文件到 %s", len(manifest.Entries), writer.URI().Name()), s.window)
+	}, s.window)
+	saveDialog.SetFileName(backup.ArchiveName(time.Now()))
+	saveDialog.Show()
+}
+
+// showRestoreFileDialog 选择并校验备份文件
+func (s *SettingsUI) showRestoreFileDialog() {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, s.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		archive, err := backup.OpenArchive(reader.URI().Path())
+		if err != nil {
+			dialog.ShowError(err, s.window)
+			return
+		}
+
+		s.showRestoreDialog(archive)
+	}, s.window)
+	openDialog.Show()
+}
+
+// showRestoreDialog 显示备份内容和将被替换的文件，选择要恢复的部分
+func (s *SettingsUI) showRestoreDialog(archive *backup.Archive) {
+	manifest := archive.Manifest
+	content := container.NewVBox(
+		widget.NewLabel("备份时间："+manifest.CreatedAt.Format("2006-01-02 15:04:05")),
+		widget.NewLabel("选择要恢复的内容（将替换以下文件）："),
+		widget.NewSeparator(),
+	)
+
+	checks := make(map[string]*widget.Check)
+	for _, id := range manifest.SourceIDs() {
+		plans := archive.Plan([]string{id})
+
+		check := widget.NewCheck(plans[0].Entry.Title, nil)
+		check.SetChecked(true)
+		checks[id] = check
+		content.Add(check)
+
+		for _, plan := range plans {
+			target := plan.Target
+			if target == "" {
+				target = "（当前版本无法恢复）"
+				check.SetChecked(false)
+				check.Disable()
+			}
+			line := widget.NewLabel(fmt.Sprintf("    %s（%s）→ %s", plan.Entry.Name, formatSize(plan.Entry.Size), target))
+			line.Wrapping = fyne.TextWrapBreak
+			content.Add(line)
+		}
+	}
+
+	d := dialog.NewCustomConfirm("从备份恢复", "恢复所选", "取消", container.NewVScroll(content), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		var selected []string
+		for _, id := range manifest.SourceIDs() {
+			if checks[id].Checked {
+				selected = append(selected, id)
+			}
+		}
+		if len(selected) == 0 {
+			dialog.ShowError(errors.New("请至少选择一项要恢复的内容"), s.window)
+			return
+		}
+
+		targets, err := archive.StageRestore(selected, PendingRestorePath())
+		if err != nil {
+			dialog.ShowError(errors.New("准备恢复失败: "+err.Error()), s.window)
+			return
+		}
+
+		dialog.ShowInformation("✅ 恢复已准备好",
+			fmt.Sprintf("将在下次启动时恢复 %d 个文件，被替换的文件会保留为 .bak1。\n请重启应用完成恢复。", len(targets)), s.window)
+	}, s.window)
+	d.Resize(fyne.NewSize(560, 420))
+	d.Show()
+}
+
+// showAutoBackupDialog 显示自动备份设置对话框
+func (s *SettingsUI) showAutoBackupDialog(onSaved func()) {
+	cfg, _ := LoadConfig()
+
+	dirEntry := widget.NewEntry()
+	dirEntry.SetText(cfg.BackupDir)
+	dirEntry.SetPlaceHolder("备份保存目录")
+	browseButton := widget.NewButton("浏览", func() {
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				dirEntry.SetText(uri.Path())
+			}
+		}, s.window)
+		folderDialog.Show()
+	})
+
+	var intervalNames []string
+	for _, interval := range backupIntervals {
+		intervalNames = append(intervalNames, interval.name)
+	}
+	intervalSelect := widget.NewSelect(intervalNames, nil)
+	intervalSelect.SetSelected(intervalName(cfg.BackupIntervalHours))
+
+	keepEntry := widget.NewEntry()
+	keepEntry.SetPlaceHolder("0 表示全部保留")
+	if cfg.BackupKeep > 0 {
+		keepEntry.SetText(strconv.Itoa(cfg.BackupKeep))
+	}
+
+	items := []*widget.FormItem{
+		{Text: "备份目录", Widget: container.NewBorder(nil, nil, nil, browseButton, dirEntry)},
+		{Text: "备份频率", Widget: intervalSelect},
+		{Text: "保留数量", Widget: keepEntry},
+	}
+
+	d := dialog.NewForm("自动备份设置", "保存", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		hours := cfg.BackupIntervalHours
+		for _, interval := range backupIntervals {
+			if interval.name == intervalSelect.Selected {
+				hours = interval.hours
+			}
+		}
+
+		keep := 0
+		if text := strings.TrimSpace(keepEntry.Text); text != "" {
+			var err error
+			keep, err = strconv.Atoi(text)
+			if err != nil || keep < 0 {
+				dialog.ShowError(errors.New("保留数量必须是非负整数"), s.window)
+				return
+			}
+		}
+
+		dir := strings.TrimSpace(dirEntry.Text)
+		if hours > 0 && dir == "" {
+			dialog.ShowError(errors.New("请选择备份目录"), s.window)
+			return
+		}
+
+		err := UpdateConfig(func(latest *Config) error {
+			latest.BackupDir = dir
+			latest.BackupIntervalHours = hours
+			latest.BackupKeep = keep
+			return nil
+		})
+		if err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+			return
+		}
+
+		onSaved()
+	}, s.window)
+	d.Resize(fyne.NewSize(480, 260))
+	d.Show()
+}
+
+// formatSize 格式化文件大小
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,159 @@
+package settings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"my_portfolio/backup"
+	"my_portfolio/safe_file"
+)
+
+// AppDirName 应用在用户配置/数据目录下使用的子目录名
+const AppDirName = "my_portfolio"
+
+// 存储后端
+const (
+	BackendJSON   = "json"   // 每个工具一个JSON文件（默认）
+	BackendSQLite = "sqlite" // 所有工具共用一个SQLite数据库
+)
+
+// Config 应用配置，保存在用户配置目录中，图形界面和命令行共用
+type Config struct {
+	EncryptedTools  map[string]bool `json:"encrypted_tools,omitempty"`   // 工具ID -> 是否启用加密存储
+	DataDir         string          `json:"data_dir,omitempty"`          // 自定义数据目录，为空时使用默认目录
+	PreviousDataDir string          `json:"previous_data_dir,omitempty"` // 更改数据目录前的旧目录，下次启动时从中迁移数据
+	StorageBackend  string          `json:"storage_backend,omitempty"`   // 存储后端，为空时使用JSON文件
+
+	BackupDir           string    `json:"backup_dir,omitempty"`            // 自动备份目录，为空表示关闭
+	BackupIntervalHours int       `json:"backup_interval_hours,omitempty"` // 自动备份间隔（小时），0表示关闭
+	BackupKeep          int       `json:"backup_keep,omitempty"`           // 保留的自动备份数量，0表示全部保留
+	LastBackup          time.Time `json:"last_backup,omitzero"`            // 上次自动备份的时间
+
+	SyncDir  string    `json:"sync_dir,omitempty"`  // 同步文件夹，为空表示未启用同步
+	DeviceID string    `json:"device_id,omitempty"` // 本机在同步文件夹中的标识
+	LastSync time.Time `json:"last_sync,omitzero"`  // 上次同步的时间
+
+	APIEnabled bool   `json:"api_enabled,omitempty"` // 是否启动本地 HTTP API
+	APIPort    int    `json:"api_port,omitempty"`    // 本地 API 端口，0 表示默认端口
+	APIToken   string `json:"api_token,omitempty"`   // 本地 API 的访问令牌
+}
+
+// ConfigPath 返回配置文件路径
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, AppDirName, "config.json"), nil
+}
+
+// LoadConfig 加载应用配置，文件不存在时返回默认配置
+func LoadConfig() (*Config, error) {
+	cfg := &Config{}
+
+	path, err := ConfigPath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if len(data) == 0 {
+		return cfg, nil
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return &Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// configMu 串行化配置文件的写入；自动备份在后台记录备份时间，可能与界面同时修改配置
+var configMu sync.Mutex
+
+// SaveConfig 保存应用配置
+func SaveConfig(cfg *Config) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+	return saveConfig(cfg)
+}
+
+// UpdateConfig 读取最新配置，交给 update 修改后保存；整个过程持有写锁，
+// 避免并发的读取-修改-保存互相覆盖。update 返回错误时不保存
+func UpdateConfig(update func(cfg *Config) error) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := update(cfg); err != nil {
+		return err
+	}
+	return saveConfig(cfg)
+}
+
+// saveConfig 写入配置文件，调用方需持有 configMu
+func saveConfig(cfg *Config) error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return safe_file.WriteFile(path, data, 0600)
+}
+
+// IsEncrypted 判断工具是否启用了加密存储
+func (c *Config) IsEncrypted(toolID string) bool {
+	return c.EncryptedTools[toolID]
+}
+
+// SetEncrypted 设置工具是否启用加密存储
+func (c *Config) SetEncrypted(toolID string, encrypted bool) {
+	if c.EncryptedTools == nil {
+		c.EncryptedTools = make(map[string]bool)
+	}
+	if encrypted {
+		c.EncryptedTools[toolID] = true
+	} else {
+		delete(c.EncryptedTools, toolID)
+	}
+}
+
+// Backend 返回当前使用的存储后端
+func (c *Config) Backend() string {
+	if c.StorageBackend == BackendSQLite {
+		return BackendSQLite
+	}
+	return BackendJSON
+}
+
+// BackupSchedule 返回自动备份设置
+func (c *Config) BackupSchedule() backup.Schedule {
+	return backup.Schedule{
+		Dir:      c.BackupDir,
+		Interval: time.Duration(c.BackupIntervalHours) * time.Hour,
+		Keep:     c.BackupKeep,
+		Last:     c.LastBackup,
+	}
+}
@@ -0,0 +1,279 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/safe_file"
+)
+
+// activeDataDir 本次运行使用的数据目录
+var activeDataDir string
+
+// ActiveDataDir 返回本次运行使用的数据目录
+func ActiveDataDir() string {
+	return activeDataDir
+}
+
+// DefaultDataDir 返回默认的用户数据目录：
+// Linux/BSD 使用 $XDG_DATA_HOME（默认 ~/.local/share），其他平台使用系统的用户配置目录。
+func DefaultDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "android":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, AppDirName), nil
+	}
+
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, AppDirName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", AppDirName), nil
+}
+
+// ResolveDataDir 确定数据目录并确保其存在。
+// 优先使用配置中的目录，其次是默认用户数据目录，都不可用时使用 fallbackRoot（例如 Fyne 应用的存储根目录）。
+func ResolveDataDir(cfg *Config, fallbackRoot string) (string, error) {
+	dir := cfg.DataDir
+	if dir == "" {
+		var err error
+		dir, err = DefaultDataDir()
+		if err != nil {
+			if fallbackRoot == "" {
+				return "", err
+			}
+			dir = fallbackRoot
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// LegacyDir 可能存放旧数据文件的目录
+type LegacyDir struct {
+	Path  string
+	Owned bool // 是否为以前使用的数据目录：迁移后原文件重命名为 *.migrated，其他目录中的文件只复制不改动
+}
+
+// backupSuffix 数据文件的备份：safe_file 的历史版本 .bakN 和结构迁移前的 .vN.bak
+var backupSuffix = regexp.MustCompile(`^\.(bak\d+|v\d+\.bak)$`)
+
+// LegacyDataDirs 返回可能存放旧数据文件的目录：上一次配置的数据目录、当前工作目录和可执行文件所在目录。
+// 旧版本按相对路径保存在当前工作目录中；工作目录和可执行文件所在目录中的文件只复制不改动
+func LegacyDataDirs(cfg *Config) []LegacyDir {
+	var dirs []LegacyDir
+	if cfg.PreviousDataDir != "" {
+		dirs = append(dirs, LegacyDir{Path: cfg.PreviousDataDir, Owned: true})
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, LegacyDir{Path: wd})
+	}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, LegacyDir{Path: filepath.Dir(exe)})
+	}
+	return dirs
+}
+
+// MigrateDataFiles 将旧位置的数据文件及其备份迁移到数据目录。
+// 只迁移数据目录中尚不存在的文件；以前的数据目录中的原文件迁移后重命名为 *.migrated 保留。返回已迁移文件的原路径。
+func MigrateDataFiles(dataDir string, legacyDirs []LegacyDir, names []string) ([]string, error) {
+	var migrated []string
+	var errs []error
+
+	absDataDir, _ := filepath.Abs(dataDir)
+	for _, name := range names {
+		dst := filepath.Join(dataDir, name)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+
+		for _, dir := range legacyDirs {
+			if absDir, _ := filepath.Abs(dir.Path); absDir == absDataDir {
+				continue
+			}
+
+			src := filepath.Join(dir.Path, name)
+			info, err := os.Stat(src)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			if err := migrateFile(src, dst, info.Mode().Perm(), dir.Owned); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			migrated = append(migrated, src)
+
+			backups, err := migrateBackups(dir, name, dataDir)
+			migrated = append(migrated, backups...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			break
+		}
+	}
+
+	return migrated, errors.Join(errs...)
+}
+
+// migrateBackups 迁移数据文件在旧目录中的备份，数据目录中已有的备份不覆盖
+func migrateBackups(dir LegacyDir, name, dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(dir.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrated []string
+	var errs []error
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), name)
+		if !ok || entry.IsDir() || !backupSuffix.MatchString(suffix) {
+			continue
+		}
+
+		dst := filepath.Join(dataDir, entry.Name())
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		src := filepath.Join(dir.Path, entry.Name())
+		if err := migrateFile(src, dst, info.Mode().Perm(), dir.Owned); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		migrated = append(migrated, src)
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// migrateFile 复制文件到新位置；rename 为 true 时成功后将原文件重命名为 *.migrated
+func migrateFile(src, dst string, perm os.FileMode, rename bool) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := safe_file.WriteFileWithBackups(dst, data, perm, 0); err != nil {
+		return err
+	}
+	if !rename {
+		return nil
+	}
+	return os.Rename(src, src+".migrated")
+}
+
+// PrepareDataDir 确定数据目录并迁移旧位置的数据文件，返回数据目录和已迁移的文件
+func PrepareDataDir(cfg *Config, fallbackRoot string, names []string) (string, []string, error) {
+	dir, err := ResolveDataDir(cfg, fallbackRoot)
+	if err != nil {
+		return "", nil, err
+	}
+
+	activeDataDir = dir
+	migrated, err := MigrateDataFiles(dir, LegacyDataDirs(cfg), names)
+
+	// 目录变更后的迁移已完成，清除记录
+	if cfg.PreviousDataDir != "" && err == nil {
+		cfg.PreviousDataDir = ""
+		if saveErr := SaveConfig(cfg); saveErr != nil {
+			err = saveErr
+		}
+	}
+
+	return dir, migrated, err
+}
+
+// createDataDirCard 创建数据目录设置卡片
+func (s *SettingsUI) createDataDirCard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("📁 数据目录", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("工具数据保存的位置，更改后重启应用生效")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+
+	dirLabel := widget.NewLabel(activeDataDir)
+	dirLabel.Wrapping = fyne.TextWrapBreak
+
+	changeButton := widget.NewButton("更改目录", func() {
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			if uri == nil {
+				return
+			}
+			s.confirmDataDirChange(uri.Path())
+		}, s.window)
+		folderDialog.Show()
+	})
+
+	resetButton := widget.NewButton("恢复默认", func() {
+		s.confirmDataDirChange("")
+	})
+
+	return container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+		dirLabel,
+		container.NewHBox(changeButton, resetButton),
+	)
+}
+
+// confirmDataDirChange 确认并保存新的数据目录，dir 为空表示使用默认目录
+func (s *SettingsUI) confirmDataDirChange(dir string) {
+	display := dir
+	if display == "" {
+		defaultDir, err := DefaultDataDir()
+		if err != nil {
+			dialog.ShowError(errors.New("无法确定默认数据目录: "+err.Error()), s.window)
+			return
+		}
+		display = defaultDir
+	}
+
+	if display == activeDataDir {
+		return
+	}
+
+	message := "数据目录将更改为：\n" + display + "\n\n重启应用后生效，现有数据会在重启时自动迁移到新目录。"
+	dialog.ShowConfirm("更改数据目录", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		err := UpdateConfig(func(cfg *Config) error {
+			cfg.DataDir = dir
+			cfg.PreviousDataDir = activeDataDir
+			return nil
+		})
+		if err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+			return
+		}
+
+		dialog.ShowInformation("✅ 设置已保存", "请重启应用以使用新的数据目录", s.window)
+	}, s.window)
+}
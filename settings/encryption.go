@@ -0,0 +1,225 @@
+package settings
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Encryptable 支持加密存储的工具
+type Encryptable interface {
+	// EncryptionEnabled 当前是否使用加密存储
+	EncryptionEnabled() bool
+
+	// EnableEncryption 将现有数据转换为加密格式并切换到加密存储
+	EnableEncryption(passphrase string) error
+
+	// DisableEncryption 将加密数据转换回明文并切换到普通存储
+	DisableEncryption(passphrase string) error
+}
+
+// encryptableTool 已注册的可加密工具
+type encryptableTool struct {
+	id     string
+	title  string
+	target Encryptable
+}
+
+var encryptableTools []encryptableTool
+
+// RegisterEncryptable 注册可加密的工具，注册后会出现在设置界面的加密选项中
+func RegisterEncryptable(id, title string, target Encryptable) {
+	encryptableTools = append(encryptableTools, encryptableTool{
+		id:     id,
+		title:  title,
+		target: target,
+	})
+}
+
+// createEncryptionCard 创建数据加密设置卡片
+func (s *SettingsUI) createEncryptionCard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("🔒 数据加密", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("使用密码加密保存工具数据，启动时需要输入密码解锁")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+
+	card := container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+	)
+
+	if len(encryptableTools) == 0 {
+		card.Add(widget.NewLabel("没有支持加密的工具"))
+		return card
+	}
+
+	for _, tool := range encryptableTools {
+		card.Add(s.createEncryptionRow(tool))
+	}
+
+	return card
+}
+
+// createEncryptionRow 创建单个工具的加密开关行
+func (s *SettingsUI) createEncryptionRow(tool encryptableTool) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	var toggleButton *widget.Button
+
+	update := func() {
+		if tool.target.EncryptionEnabled() {
+			statusLabel.SetText("已加密")
+			toggleButton.SetText("关闭加密")
+		} else {
+			statusLabel.SetText("未加密")
+			toggleButton.SetText("启用加密")
+		}
+	}
+
+	toggleButton = widget.NewButton("", func() {
+		if tool.target.EncryptionEnabled() {
+			s.showDisableEncryptionDialog(tool, update)
+		} else {
+			s.showEnableEncryptionDialog(tool, update)
+		}
+	})
+	update()
+
+	return container.NewHBox(
+		widget.NewLabelWithStyle(tool.title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		statusLabel,
+		toggleButton,
+	)
+}
+
+// showEnableEncryptionDialog 显示启用加密对话框
+func (s *SettingsUI) showEnableEncryptionDialog(tool encryptableTool, onDone func()) {
+	passEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
+
+	items := []*widget.FormItem{
+		{Text: "密码", Widget: passEntry, HintText: "至少8个字符，遗失后数据无法恢复"},
+		{Text: "确认密码", Widget: confirmEntry},
+	}
+
+	dialog.ShowForm("启用加密 - "+tool.title, "加密", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		if len(passEntry.Text) < 8 {
+			dialog.ShowError(errors.New("密码至少需要8个字符"), s.window)
+			return
+		}
+		if passEntry.Text != confirmEntry.Text {
+			dialog.ShowError(errors.New("两次输入的密码不一致"), s.window)
+			return
+		}
+
+		if err := tool.target.EnableEncryption(passEntry.Text); err != nil {
+			dialog.ShowError(errors.New("启用加密失败: "+err.Error()), s.window)
+			return
+		}
+
+		if err := s.saveEncryptionSetting(tool.id, true); err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+		}
+
+		onDone()
+		dialog.ShowInformation("✅ 加密已启用", tool.title+" 的数据已转换为加密格式", s.window)
+	}, s.window)
+}
+
+// showDisableEncryptionDialog 显示关闭加密对话框
+func (s *SettingsUI) showDisableEncryptionDialog(tool encryptableTool, onDone func()) {
+	passEntry := widget.NewPasswordEntry()
+
+	items := []*widget.FormItem{
+		{Text: "当前密码", Widget: passEntry, HintText: "数据将以明文形式保存"},
+	}
+
+	dialog.ShowForm("关闭加密 - "+tool.title, "解密", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		if err := tool.target.DisableEncryption(passEntry.Text); err != nil {
+			dialog.ShowError(errors.New("关闭加密失败: "+err.Error()), s.window)
+			return
+		}
+
+		if err := s.saveEncryptionSetting(tool.id, false); err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+		}
+
+		onDone()
+		dialog.ShowInformation("✅ 加密已关闭", tool.title+" 的数据已转换为明文格式", s.window)
+	}, s.window)
+}
+
+// saveEncryptionSetting 保存工具的加密设置
+func (s *SettingsUI) saveEncryptionSetting(toolID string, encrypted bool) error {
+	return UpdateConfig(func(cfg *Config) error {
+		cfg.SetEncrypted(toolID, encrypted)
+		return nil
+	})
+}
+
+// ShowUnlockDialog 显示解锁加密数据的密码对话框，unlock 返回错误时会重新提示
+func ShowUnlockDialog(title string, window fyne.Window, unlock func(passphrase string) error) {
+	passEntry := widget.NewPasswordEntry()
+	items := []*widget.FormItem{
+		{Text: "密码", Widget: passEntry},
+	}
+
+	d := dialog.NewForm("🔒 解锁 - "+title, "解锁", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		if err := unlock(passEntry.Text); err != nil {
+			dialog.ShowError(err, window)
+			ShowUnlockDialog(title, window, unlock)
+		}
+	}, window)
+	d.Resize(fyne.NewSize(360, 160))
+	d.Show()
+}
+
+// UnlockBar 加密数据尚未解锁时显示在工具页面顶部的提示条，
+// 关闭解锁对话框后可以点击其中的按钮重新解锁，解锁成功后自动隐藏
+type UnlockBar struct {
+	*fyne.Container
+
+	title  string
+	window fyne.Window
+	unlock func(passphrase string) error
+}
+
+// NewUnlockBar 创建解锁提示条
+func NewUnlockBar(title string, window fyne.Window, unlock func(passphrase string) error) *UnlockBar {
+	bar := &UnlockBar{
+		title:  title,
+		window: window,
+		unlock: unlock,
+	}
+
+	label := widget.NewLabel("🔒 " + title + "数据已加密，解锁后才能查看和修改")
+	button := widget.NewButton("解锁", bar.ShowDialog)
+	button.Importance = widget.HighImportance
+	bar.Container = container.NewBorder(nil, nil, nil, button, label)
+	return bar
+}
+
+// ShowDialog 显示解锁对话框，解锁成功后隐藏提示条
+func (b *UnlockBar) ShowDialog() {
+	ShowUnlockDialog(b.title, b.window, func(passphrase string) error {
+		if err := b.unlock(passphrase); err != nil {
+			return err
+		}
+		b.Hide()
+		return nil
+	})
+}
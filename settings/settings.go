@@ -37,6 +37,24 @@ func (s *SettingsUI) MakeUI() fyne.CanvasObject {
 	// 主题设置
 	themeCard := s.createThemeCard()
 
+	// 数据目录
+	dataDirCard := s.createDataDirCard()
+
+	// 存储方式
+	storageBackendCard := s.createStorageBackendCard()
+
+	// 数据加密
+	encryptionCard := s.createEncryptionCard()
+
+	// 备份与恢复
+	backupCard := s.createBackupCard()
+
+	// 文件夹同步
+	syncCard := s.createSyncCard()
+
+	// 本地 API
+	apiCard := s.createAPICard()
+
 	// 关于信息
 	aboutCard := s.createAboutCard()
 
@@ -47,6 +65,18 @@ func (s *SettingsUI) MakeUI() fyne.CanvasObject {
 		widget.NewSeparator(),
 		themeCard,
 		widget.NewSeparator(),
+		dataDirCard,
+		widget.NewSeparator(),
+		storageBackendCard,
+		widget.NewSeparator(),
+		encryptionCard,
+		widget.NewSeparator(),
+		backupCard,
+		widget.NewSeparator(),
+		syncCard,
+		widget.NewSeparator(),
+		apiCard,
+		widget.NewSeparator(),
 		aboutCard,
 		layout.NewSpacer(),
 	)
@@ -0,0 +1,64 @@
+package settings
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// backendNames 存储后端的显示名称
+var backendNames = map[string]string{
+	BackendJSON:   "JSON 文件",
+	BackendSQLite: "SQLite 数据库",
+}
+
+// createStorageBackendCard 创建存储后端设置卡片
+func (s *SettingsUI) createStorageBackendCard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("🗄️ 存储方式", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("切换到 SQLite 后首次启动会自动导入现有 JSON 数据（JSON 文件保留不变）；切换回 JSON 文件后首次启动会把数据库中的数据导出到 JSON 文件。已加密的工具仍使用加密文件")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+	cardDesc.Wrapping = fyne.TextWrapWord
+
+	cfg, _ := LoadConfig()
+	current := cfg.Backend()
+
+	var radio *widget.RadioGroup
+	radio = widget.NewRadioGroup([]string{backendNames[BackendJSON], backendNames[BackendSQLite]}, func(selected string) {
+		backend := BackendJSON
+		if selected == backendNames[BackendSQLite] {
+			backend = BackendSQLite
+		}
+		if backend == current {
+			return
+		}
+
+		if err := s.saveStorageBackend(backend); err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+			radio.SetSelected(backendNames[current])
+			return
+		}
+
+		current = backend
+		dialog.ShowInformation("✅ 设置已保存", "存储方式已切换为"+backendNames[backend]+"，重启应用后生效", s.window)
+	})
+	radio.Horizontal = true
+	radio.SetSelected(backendNames[current])
+
+	return container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+		radio,
+	)
+}
+
+// saveStorageBackend 保存存储后端设置
+func (s *SettingsUI) saveStorageBackend(backend string) error {
+	return UpdateConfig(func(cfg *Config) error {
+		cfg.StorageBackend = backend
+		return nil
+	})
+}
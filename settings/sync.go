@@ -0,0 +1,302 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
+
+	"my_portfolio/folder_sync"
+)
+
+// SyncStateFile 数据目录中保存本机同步状态的文件名
+const SyncStateFile = "sync_state.json"
+
+// Syncable 支持文件夹同步的工具
+type Syncable interface {
+	// SyncRecords 返回当前的全部记录（记录键 -> 内容）
+	SyncRecords() (map[string]json.RawMessage, error)
+
+	// ApplySyncRecords 用合并后的记录替换本地数据并保存
+	ApplySyncRecords(records map[string]json.RawMessage) error
+
+	// DescribeSyncRecord 返回记录的简短描述，data 为 nil 表示记录已删除
+	DescribeSyncRecord(key string, data json.RawMessage) string
+}
+
+// SyncProtector 可选接口：有不能被其他设备修改的记录（例如已锁定期间的收益记录）的工具实现
+type SyncProtector interface {
+	// ProtectedSyncRecords 返回合并结果中不能采用的记录键，这些记录保留本机版本
+	ProtectedSyncRecords(local, merged map[string]json.RawMessage) []string
+}
+
+// syncableTool 已注册的可同步工具
+type syncableTool struct {
+	id     string
+	title  string
+	target Syncable
+}
+
+var syncableTools []syncableTool
+
+// RegisterSyncable 注册可同步的工具，注册后会参与文件夹同步
+func RegisterSyncable(id, title string, target Syncable) {
+	syncableTools = append(syncableTools, syncableTool{
+		id:     id,
+		title:  title,
+		target: target,
+	})
+}
+
+// createSyncCard 创建文件夹同步设置卡片
+func (s *SettingsUI) createSyncCard() fyne.CanvasObject {
+	cardTitle := widget.NewLabelWithStyle("🔄 文件夹同步", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	cardDesc := widget.NewLabel("通过网盘等工具同步的共享文件夹，在多台电脑之间合并数据")
+	cardDesc.TextStyle = fyne.TextStyle{Italic: true}
+	cardDesc.Wrapping = fyne.TextWrapWord
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapBreak
+	updateStatus := func() {
+		cfg, _ := LoadConfig()
+		statusLabel.SetText(syncStatusText(cfg))
+	}
+	updateStatus()
+
+	chooseButton := widget.NewButton("选择同步文件夹", func() {
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			if uri == nil {
+				return
+			}
+
+			err = UpdateConfig(func(cfg *Config) error {
+				cfg.SyncDir = uri.Path()
+				return nil
+			})
+			if err != nil {
+				dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+				return
+			}
+			updateStatus()
+		}, s.window)
+		folderDialog.Show()
+	})
+
+	syncButton := widget.NewButton("立即同步", func() {
+		s.syncNow(updateStatus)
+	})
+
+	return container.NewVBox(
+		cardTitle,
+		cardDesc,
+		widget.NewSeparator(),
+		statusLabel,
+		container.NewHBox(chooseButton, syncButton),
+	)
+}
+
+// syncStatusText 返回同步状态说明
+func syncStatusText(cfg *Config) string {
+	if cfg.SyncDir == "" {
+		return "尚未设置同步文件夹"
+	}
+
+	text := "同步文件夹：" + cfg.SyncDir
+	if !cfg.LastSync.IsZero() {
+		text += "\n上次同步：" + cfg.LastSync.Format("2006-01-02 15:04")
+	}
+	return text
+}
+
+// syncNow 依次同步所有已注册的工具，有冲突时逐个工具弹出冲突对话框
+func (s *SettingsUI) syncNow(onDone func()) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		dialog.ShowError(errors.New("读取设置失败: "+err.Error()), s.window)
+		return
+	}
+	if cfg.SyncDir == "" {
+		dialog.ShowError(errors.New("请先选择同步文件夹"), s.window)
+		return
+	}
+
+	if cfg.DeviceID == "" {
+		err := UpdateConfig(func(latest *Config) error {
+			if latest.DeviceID == "" {
+				latest.DeviceID = uuid.New().String()
+			}
+			cfg.DeviceID = latest.DeviceID
+			return nil
+		})
+		if err != nil {
+			dialog.ShowError(errors.New("保存设置失败: "+err.Error()), s.window)
+			return
+		}
+	}
+
+	deviceName, _ := os.Hostname()
+	folder := folder_sync.NewFolder(cfg.SyncDir, filepath.Join(activeDataDir, SyncStateFile), cfg.DeviceID, deviceName)
+
+	var results []string
+	var next func(i int)
+	next = func(i int) {
+		if i >= len(syncableTools) {
+			s.finishSync(results, onDone)
+			return
+		}
+
+		tool := syncableTools[i]
+		s.syncTool(folder, tool, func(result string) {
+			results = append(results, tool.title+"："+result)
+			next(i + 1)
+		})
+	}
+	next(0)
+}
+
+// syncTool 同步单个工具，完成后通过 done 返回结果说明
+func (s *SettingsUI) syncTool(folder *folder_sync.Folder, tool syncableTool, done func(result string)) {
+	local, err := tool.target.SyncRecords()
+	if err != nil {
+		done("已跳过（" + err.Error() + "）")
+		return
+	}
+
+	plan, err := folder.Prepare(tool.id, local)
+	if err != nil {
+		done("失败（" + err.Error() + "）")
+		return
+	}
+
+	commit := func() {
+		// 受保护的记录保留本机版本，本机快照中也是本机版本，其他设备下次同步时会看到
+		var protected []string
+		if protector, ok := tool.target.(SyncProtector); ok {
+			for _, key := range protector.ProtectedSyncRecords(local, plan.Records) {
+				if data, ok := local[key]; ok {
+					plan.Records[key] = data
+				} else {
+					delete(plan.Records, key)
+				}
+				protected = append(protected, tool.target.DescribeSyncRecord(key, local[key]))
+			}
+		}
+
+		if plan.Changed() {
+			if err := tool.target.ApplySyncRecords(plan.Records); err != nil {
+				done("失败（" + err.Error() + "）")
+				return
+			}
+		}
+		if err := folder.Commit(plan); err != nil {
+			done("失败（" + err.Error() + "）")
+			return
+		}
+
+		result := fmt.Sprintf("已同步 %d 条记录", len(plan.Records))
+		if len(plan.Conflicts) > 0 {
+			result += fmt.Sprintf("，解决 %d 个冲突", len(plan.Conflicts))
+		}
+		if len(protected) > 0 {
+			result += fmt.Sprintf("\n  %d 个冲突涉及已锁定的记录，保留本机版本：\n  - %s", len(protected), strings.Join(protected, "\n  - "))
+		}
+		done(result)
+	}
+
+	if len(plan.Conflicts) == 0 {
+		commit()
+		return
+	}
+
+	s.showConflictDialog(tool, plan, commit, func() {
+		done("已取消（存在未解决的冲突）")
+	})
+}
+
+// showConflictDialog 显示同步冲突，逐条选择保留本机版本或使用对方版本
+func (s *SettingsUI) showConflictDialog(tool syncableTool, plan *folder_sync.Plan, onResolved, onCancel func()) {
+	const keepLocal = "保留本机"
+
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("%s 有 %d 条记录在本机和其他设备上都被修改：", tool.title, len(plan.Conflicts))),
+		widget.NewSeparator(),
+	)
+
+	choices := make([]*widget.RadioGroup, len(plan.Conflicts))
+	for i, conflict := range plan.Conflicts {
+		useRemote := "使用 " + deviceLabel(conflict)
+		choice := widget.NewRadioGroup([]string{keepLocal, useRemote}, nil)
+		choice.Horizontal = true
+		choice.SetSelected(keepLocal)
+		choices[i] = choice
+
+		content.Add(widget.NewLabel("本机：" + tool.target.DescribeSyncRecord(conflict.Key, conflict.Local)))
+		content.Add(widget.NewLabel(deviceLabel(conflict) + "：" + tool.target.DescribeSyncRecord(conflict.Key, conflict.Remote)))
+		content.Add(choice)
+		content.Add(widget.NewSeparator())
+	}
+
+	selectAll := func(option int) {
+		for _, choice := range choices {
+			choice.SetSelected(choice.Options[option])
+		}
+	}
+	content.Add(container.NewHBox(
+		widget.NewButton("全部保留本机", func() { selectAll(0) }),
+		widget.NewButton("全部使用对方", func() { selectAll(1) }),
+	))
+
+	d := dialog.NewCustomConfirm("同步冲突 - "+tool.title, "应用", "取消同步", container.NewVScroll(content), func(confirmed bool) {
+		if !confirmed {
+			onCancel()
+			return
+		}
+
+		for i, conflict := range plan.Conflicts {
+			plan.Resolve(conflict, choices[i].Selected != keepLocal)
+		}
+		onResolved()
+	}, s.window)
+	d.Resize(fyne.NewSize(560, 460))
+	d.Show()
+}
+
+// deviceLabel 返回冲突对方设备的显示名称
+func deviceLabel(conflict folder_sync.Conflict) string {
+	if conflict.DeviceName != "" {
+		return conflict.DeviceName
+	}
+	return "设备 " + conflict.Device[:min(8, len(conflict.Device))]
+}
+
+// finishSync 记录同步时间并显示结果
+func (s *SettingsUI) finishSync(results []string, onDone func()) {
+	err := UpdateConfig(func(cfg *Config) error {
+		cfg.LastSync = time.Now()
+		return nil
+	})
+	if err != nil {
+		dialog.ShowError(errors.New("保存同步时间失败: "+err.Error()), s.window)
+	}
+
+	onDone()
+
+	if len(results) == 0 {
+		dialog.ShowInformation("🔄 同步完成", "没有支持同步的工具", s.window)
+		return
+	}
+	dialog.ShowInformation("🔄 同步完成", strings.Join(results, "\n"), s.window)
+}
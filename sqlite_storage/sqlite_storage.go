@@ -0,0 +1,191 @@
+package sqlite_storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultDBFile 默认数据库文件名，所有工具共用一个数据库
+const DefaultDBFile = "my_portfolio.db"
+
+// TimeFormat 数据库中保存时间使用的格式
+const TimeFormat = time.RFC3339Nano
+
+// Migration 数据库结构迁移
+type Migration struct {
+	Version     int    // 版本号，从1开始递增
+	Description string // 迁移说明
+	SQL         string // 要执行的语句（可包含多条）
+
+	// Migrate 需要在 Go 中转换数据时使用，在 SQL 之后、同一个事务中执行
+	Migrate func(tx *sql.Tx) error
+}
+
+// Open 打开（或创建）SQLite 数据库，启用 WAL 日志和外键约束
+func Open(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_foreign_keys", "on")
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite 同一时间只允许一个写入者，使用单连接避免锁冲突
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		component   TEXT    NOT NULL,
+		version     INTEGER NOT NULL,
+		description TEXT    NOT NULL,
+		applied_at  TEXT    NOT NULL,
+		PRIMARY KEY (component, version)
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate 按版本顺序执行组件尚未应用的迁移，每个迁移在单独的事务中执行
+func Migrate(db *sql.DB, component string, migrations []Migration) error {
+	current, err := SchemaVersion(db, component)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("%s 的迁移版本号不连续: 第%d个迁移的版本为%d", component, i+1, m.Version)
+		}
+		if m.Version <= current {
+			continue
+		}
+
+		if err := applyMigration(db, component, m); err != nil {
+			return fmt.Errorf("%s 迁移到版本%d失败: %w", component, m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion 返回组件当前的数据库结构版本，未初始化时为0
+func SchemaVersion(db *sql.DB, component string) (int, error) {
+	var version int
+	err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations WHERE component = ?`, component).Scan(&version)
+	return version, err
+}
+
+// applyMigration 在事务中执行单个迁移并记录版本
+func applyMigration(db *sql.DB, component string, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.SQL != "" {
+		if _, err := tx.Exec(m.SQL); err != nil {
+			return err
+		}
+	}
+	if m.Migrate != nil {
+		if err := m.Migrate(tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (component, version, description, applied_at) VALUES (?, ?, ?, ?)`,
+		component, m.Version, m.Description, time.Now().Format(TimeFormat)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FormatTime 将时间格式化为数据库存储格式，零值保存为空字符串
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(TimeFormat)
+}
+
+// ParseTime 解析数据库中保存的时间，空字符串返回零值
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(TimeFormat, s)
+}
+
+// SyncRows 使表中的数据与 rows 一致：只写入新增或内容变化的行，并删除 rows 中不存在的行。
+// columns 的第一列必须是表的主键。
+func SyncRows(tx *sql.Tx, table string, columns []string, rows [][]any) error {
+	key := columns[0]
+
+	if _, err := tx.Exec(`CREATE TEMP TABLE IF NOT EXISTS sync_keep (key TEXT PRIMARY KEY)`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM sync_keep`); err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	var updates, changed []string
+	for _, column := range columns[1:] {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		changed = append(changed, fmt.Sprintf("%s IS NOT excluded.%s", column, column))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING",
+		table, strings.Join(columns, ", "), placeholders, key)
+	if len(updates) > 0 {
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s WHERE %s",
+			table, strings.Join(columns, ", "), placeholders, key, strings.Join(updates, ", "), strings.Join(changed, " OR "))
+	}
+
+	upsert, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	keep, err := tx.Prepare(`INSERT OR IGNORE INTO sync_keep (key) VALUES (?)`)
+	if err != nil {
+		return err
+	}
+	defer keep.Close()
+
+	for _, row := range rows {
+		if _, err := upsert.Exec(row...); err != nil {
+			return err
+		}
+		if _, err := keep.Exec(row[0]); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s NOT IN (SELECT key FROM sync_keep)", table, key))
+	return err
+}
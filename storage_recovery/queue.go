@@ -0,0 +1,132 @@
+package storage_recovery
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/schema"
+	"my_portfolio/secure_storage"
+)
+
+// 保存失败后的重试间隔：从 minRetryDelay 开始每次加倍，最长 maxRetryDelay
+const (
+	minRetryDelay = 2 * time.Second
+	maxRetryDelay = time.Minute
+)
+
+// Retryable 判断保存错误是否值得稍后重试。
+// 文件被其他程序修改、由更新版本写入或尚未解锁时重试也不会成功，需要用户处理。
+func Retryable(err error) bool {
+	switch {
+	case errors.Is(err, file_watch.ErrChanged),
+		errors.Is(err, schema.ErrUnsupportedVersion),
+		errors.Is(err, secure_storage.ErrLocked):
+		return false
+	}
+	return true
+}
+
+// SaveQueue 保存失败后在后台重试，避免数据只留在内存中而丢失
+//
+// coalesce 为 true 时每次保存都写入全部数据，队列只保留最新的一次；
+// 否则（例如追加历史记录）按顺序保留每一次未完成的保存。
+type SaveQueue struct {
+	coalesce bool
+	onChange func(pending int, err error)
+
+	mu      sync.Mutex
+	pending []func() error
+	lastErr error
+	delay   time.Duration
+	timer   *time.Timer
+}
+
+// NewSaveQueue 创建保存队列。onChange 在保存失败和失败后队列清空时调用（后台重试时在后台 goroutine 中）：
+// pending 大于 0 时 err 为最近一次失败的原因；pending 为 0 时 err 非空表示重试中的保存因无法重试的错误被放弃。
+func NewSaveQueue(coalesce bool, onChange func(pending int, err error)) *SaveQueue {
+	return &SaveQueue{
+		coalesce: coalesce,
+		onChange: onChange,
+	}
+}
+
+// Save 执行保存。前面还有未完成的保存时先按顺序完成它们；
+// 失败且可以重试时保留在队列中并在后台重试。返回本次执行遇到的错误。
+func (q *SaveQueue) Save(op func() error) error {
+	q.mu.Lock()
+	if q.coalesce {
+		q.pending = q.pending[:0]
+	}
+	q.pending = append(q.pending, op)
+	q.mu.Unlock()
+
+	return q.Flush()
+}
+
+// Flush 立即执行队列中所有未完成的保存
+func (q *SaveQueue) Flush() error {
+	q.mu.Lock()
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+
+	hadFailed := q.lastErr != nil
+	var dropped error
+	for len(q.pending) > 0 {
+		err := q.pending[0]()
+		if err != nil && Retryable(err) {
+			q.lastErr = err
+			q.scheduleLocked()
+			pending := len(q.pending)
+			q.mu.Unlock()
+
+			q.notify(pending, err)
+			return err
+		}
+
+		// 成功，或重试也无法成功的错误：移出队列，错误交给调用方处理
+		q.pending = q.pending[1:]
+		if err != nil && dropped == nil {
+			dropped = err
+		}
+	}
+	q.lastErr = nil
+	q.delay = 0
+	q.mu.Unlock()
+
+	// 之前失败的保存已经完成或被放弃
+	if hadFailed {
+		q.notify(0, dropped)
+	}
+	return dropped
+}
+
+// Pending 返回尚未完成的保存数量和最近一次失败的原因
+func (q *SaveQueue) Pending() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), q.lastErr
+}
+
+// scheduleLocked 安排下一次重试
+func (q *SaveQueue) scheduleLocked() {
+	if q.delay == 0 {
+		q.delay = minRetryDelay
+	} else {
+		q.delay = min(q.delay*2, maxRetryDelay)
+	}
+
+	q.timer = time.AfterFunc(q.delay, func() {
+		q.Flush()
+	})
+}
+
+// notify 通知待保存状态的变化
+func (q *SaveQueue) notify(pending int, err error) {
+	if q.onChange != nil {
+		q.onChange(pending, err)
+	}
+}
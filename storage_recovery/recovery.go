@@ -0,0 +1,267 @@
+package storage_recovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/safe_file"
+)
+
+// 查看原始文件时最多显示的字节数
+const maxRawSize = 256 << 10
+
+// LoadFailure 数据加载失败的信息和可用的恢复操作
+type LoadFailure struct {
+	Title string       // 数据名称，例如"体重记录"
+	Path  string       // 数据文件路径；为空时（例如数据库存储）只能重试
+	Err   error        // 加载失败的原因
+	Retry func() error // 重新加载数据，成功时返回 nil
+}
+
+// Backup 可以用来恢复的备份文件
+type Backup struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// Backups 返回数据文件的所有备份（历史版本、升级前备份和损坏文件），最新的在前
+func Backups(path string) []Backup {
+	var candidates []string
+	for _, pattern := range []string{".bak*", ".v*.bak", ".corrupt-*"} {
+		matches, _ := filepath.Glob(path + pattern)
+		candidates = append(candidates, matches...)
+	}
+
+	seen := make(map[string]bool)
+	var backups []Backup
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		backups = append(backups, Backup{
+			Path:    candidate,
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime.After(backups[j].ModTime)
+	})
+	return backups
+}
+
+// SetAside 将无法读取的数据文件重命名为 path.corrupt-<时间>，返回新的路径；文件不存在时返回空字符串
+func SetAside(path string) (string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	corruptPath := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, corruptPath); err != nil {
+		return "", err
+	}
+	return corruptPath, nil
+}
+
+// RestoreBackup 用备份替换数据文件，原文件先重命名保留
+func RestoreBackup(path, backupPath string) error {
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if _, err := SetAside(path); err != nil {
+		return err
+	}
+	return safe_file.WriteFileWithBackups(path, data, perm, 0)
+}
+
+// ShowLoadError 显示加载失败对话框，可以重试、从备份恢复、查看原始文件，或保留损坏的文件后从空数据开始
+func ShowLoadError(window fyne.Window, failure LoadFailure) {
+	message := widget.NewLabel(fmt.Sprintf("%s加载失败：%v\n\n为避免覆盖原有数据，修复之前不会保存任何修改。", failure.Title, failure.Err))
+	message.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	// run 关闭对话框后执行恢复操作，失败时重新显示对话框
+	run := func(action func() error) func() {
+		return func() {
+			d.Hide()
+			if err := action(); err != nil {
+				failure.Err = err
+				ShowLoadError(window, failure)
+			}
+		}
+	}
+
+	buttons := container.NewHBox(widget.NewButton("重试", run(failure.Retry)))
+
+	if failure.Path != "" {
+		buttons.Add(widget.NewButton("从备份恢复", func() {
+			d.Hide()
+			showBackupPicker(window, failure)
+		}))
+		buttons.Add(widget.NewButton("查看原始文件", func() {
+			showRawFile(window, failure.Path)
+		}))
+		buttons.Add(widget.NewButton("从空数据开始", func() {
+			d.Hide()
+			confirmStartFresh(window, failure)
+		}))
+	}
+
+	buttons.Add(widget.NewButton("稍后处理", func() {
+		d.Hide()
+	}))
+
+	d = dialog.NewCustomWithoutButtons("⚠️ "+failure.Title+"加载失败", container.NewVBox(message, buttons), window)
+	d.Resize(fyne.NewSize(520, 220))
+	d.Show()
+}
+
+// showBackupPicker 选择用于恢复的备份
+func showBackupPicker(window fyne.Window, failure LoadFailure) {
+	backups := Backups(failure.Path)
+	if len(backups) == 0 {
+		info := dialog.NewInformation("没有可用的备份", "没有找到 "+filepath.Base(failure.Path)+" 的备份文件", window)
+		info.SetOnClosed(func() {
+			ShowLoadError(window, failure)
+		})
+		info.Show()
+		return
+	}
+
+	options := make([]string, len(backups))
+	for i, backup := range backups {
+		options[i] = fmt.Sprintf("%s（%s，%d 字节）",
+			filepath.Base(backup.Path), backup.ModTime.Format("2006-01-02 15:04:05"), backup.Size)
+	}
+	choice := widget.NewRadioGroup(options, nil)
+	choice.SetSelected(options[0])
+
+	content := container.NewVBox(
+		widget.NewLabel("选择要恢复的备份，当前文件会重命名保留："),
+		choice,
+	)
+
+	d := dialog.NewCustomConfirm("从备份恢复 - "+failure.Title, "恢复", "返回", container.NewVScroll(content), func(confirmed bool) {
+		if !confirmed {
+			ShowLoadError(window, failure)
+			return
+		}
+
+		backup := backups[0]
+		for i, option := range options {
+			if option == choice.Selected {
+				backup = backups[i]
+			}
+		}
+
+		err := RestoreBackup(failure.Path, backup.Path)
+		if err == nil {
+			err = failure.Retry()
+		}
+		if err != nil {
+			failure.Err = err
+			ShowLoadError(window, failure)
+		}
+	}, window)
+	d.Resize(fyne.NewSize(520, 320))
+	d.Show()
+}
+
+// confirmStartFresh 确认后将损坏的文件重命名保留，从空数据开始
+func confirmStartFresh(window fyne.Window, failure LoadFailure) {
+	message := fmt.Sprintf("%s 将被重命名为 %s.corrupt-<时间> 保留，然后从空数据开始。\n确定继续吗？",
+		filepath.Base(failure.Path), filepath.Base(failure.Path))
+
+	dialog.ShowConfirm("从空数据开始", message, func(confirmed bool) {
+		if !confirmed {
+			ShowLoadError(window, failure)
+			return
+		}
+
+		_, err := SetAside(failure.Path)
+		if err == nil {
+			err = failure.Retry()
+		}
+		if err != nil {
+			failure.Err = err
+			ShowLoadError(window, failure)
+		}
+	}, window)
+}
+
+// showRawFile 显示数据文件的原始内容
+func showRawFile(window fyne.Window, path string) {
+	text, err := readRaw(path)
+	if err != nil {
+		dialog.ShowError(errors.New("读取文件失败: "+err.Error()), window)
+		return
+	}
+
+	content := widget.NewLabel(text)
+	content.TextStyle = fyne.TextStyle{Monospace: true}
+	content.Wrapping = fyne.TextWrapBreak
+
+	copyButton := widget.NewButton("复制内容", func() {
+		window.Clipboard().SetContent(text)
+	})
+
+	d := dialog.NewCustom(filepath.Base(path), "关闭",
+		container.NewBorder(nil, copyButton, nil, nil, container.NewScroll(content)), window)
+	d.Resize(fyne.NewSize(640, 480))
+	d.Show()
+}
+
+// readRaw 读取文件内容用于显示，过长时截断，无法按文本显示的字节会被替换
+func readRaw(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxRawSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	truncated := len(data) > maxRawSize
+	if truncated {
+		data = data[:maxRawSize]
+	}
+
+	text := string(data)
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "�")
+	}
+	if truncated {
+		text += fmt.Sprintf("\n\n……（仅显示前 %d KB）", maxRawSize>>10)
+	}
+	return text, nil
+}
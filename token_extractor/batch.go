@@ -0,0 +1,290 @@
+package token_extractor
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency 批量提取默认同时运行的账号数，每个账号使用一个独立的浏览器
+const DefaultBatchConcurrency = 3
+
+// MaxBatchConcurrency 批量提取最多同时运行的账号数
+const MaxBatchConcurrency = 8
+
+// 批量提取中单个账号的状态
+const (
+	BatchPending = "pending" // 等待中
+	BatchRunning = "running" // 提取中
+	BatchSuccess = "success" // 成功
+	BatchFailed  = "failed"  // 失败或已取消
+)
+
+// BatchAccount 批量提取的账号
+type BatchAccount struct {
+	Username string
+	Password string
+}
+
+// BatchItem 批量提取中单个账号的进度和结果
+type BatchItem struct {
+	Username string
+	Status   string
+	Step     string // 提取中时为最近的进度
+	Result   *ExtractResult
+	Error    string
+	Duration time.Duration
+}
+
+// KeyHeaders 返回成功提取到的关键Token
+func (item BatchItem) KeyHeaders() []HeaderInfo {
+	if item.Result == nil || !item.Result.Success {
+		return nil
+	}
+	var headers []HeaderInfo
+	for _, header := range item.Result.Headers {
+		if header.IsKey {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+// ParseAccountsCSV 读取账号列表：每行为“账号,密码”，第一行是表头（账号/username）时跳过，
+// 空行和 # 开头的行忽略
+func ParseAccountsCSV(r io.Reader) ([]BatchAccount, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var accounts []BatchAccount
+	seen := make(map[string]bool)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		username := strings.TrimSpace(record[0])
+		if first && (username == "账号" || strings.EqualFold(username, "username")) {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("第 %d 行: 需要账号和密码两列", line)
+		}
+		if username == "" || record[1] == "" {
+			return nil, fmt.Errorf("第 %d 行: 账号和密码不能为空", line)
+		}
+		if seen[username] {
+			return nil, fmt.Errorf("第 %d 行: 账号 %s 重复", line, username)
+		}
+		seen[username] = true
+
+		accounts = append(accounts, BatchAccount{Username: username, Password: record[1]})
+	}
+
+	if len(accounts) == 0 {
+		return nil, errors.New("没有找到账号")
+	}
+	return accounts, nil
+}
+
+// RunBatch 以 base 中的地址和站点配置为每个账号执行提取，最多同时运行 concurrency 个。
+// 每个账号的状态变化时调用 onUpdate（在工作goroutine中，可能同时调用），
+// ctx 取消后未开始的账号标记为失败。返回所有账号的最终结果，顺序与 accounts 相同。
+func RunBatch(ctx context.Context, extractor Extractor, base LoginRequest, accounts []BatchAccount, concurrency int, onUpdate func(index int, item BatchItem)) []BatchItem {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if onUpdate == nil {
+		onUpdate = func(int, BatchItem) {}
+	}
+
+	items := make([]BatchItem, len(accounts))
+	var mu sync.Mutex
+	update := func(index int, change func(item *BatchItem)) {
+		mu.Lock()
+		change(&items[index])
+		item := items[index]
+		mu.Unlock()
+		onUpdate(index, item)
+	}
+
+	for i, account := range accounts {
+		items[i] = BatchItem{Username: account.Username, Status: BatchPending}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(accounts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				runBatchItem(ctx, extractor, base, accounts[index], func(change func(item *BatchItem)) {
+					update(index, change)
+				})
+			}
+		}()
+	}
+
+	for i := range accounts {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		// 已取消：剩余账号不再提取
+		for j := i; j < len(accounts); j++ {
+			update(j, func(item *BatchItem) {
+				item.Status = BatchFailed
+				item.Error = "已取消"
+			})
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	return items
+}
+
+// runBatchItem 提取单个账号并通过 update 报告进度
+func runBatchItem(ctx context.Context, extractor Extractor, base LoginRequest, account BatchAccount, update func(change func(item *BatchItem))) {
+	start := time.Now()
+	update(func(item *BatchItem) {
+		item.Status = BatchRunning
+	})
+
+	req := base
+	req.Username = account.Username
+	req.Password = account.Password
+	req.OnProgress = func(event ProgressEvent) {
+		update(func(item *BatchItem) {
+			item.Step = event.String()
+		})
+	}
+
+	result, err := extractor.Extract(ctx, req)
+	update(func(item *BatchItem) {
+		item.Duration = time.Since(start)
+		item.Result = result
+		switch {
+		case err != nil:
+			item.Status = BatchFailed
+			item.Error = err.Error()
+		case !result.Success:
+			item.Status = BatchFailed
+			item.Error = result.Error
+		default:
+			item.Status = BatchSuccess
+		}
+	})
+}
+
+// batchExport 导出文件中单个账号的记录
+type batchExport struct {
+	Username  string       `json:"username"`
+	Success   bool         `json:"success"`
+	Error     string       `json:"error,omitempty"`
+	Timestamp *time.Time   `json:"timestamp,omitempty"`
+	Tokens    []batchToken `json:"tokens"`
+}
+
+// batchToken 导出的关键Token
+type batchToken struct {
+	Name      string     `json:"name"`
+	Value     string     `json:"value"`
+	Source    string     `json:"source"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExportBatchJSON 将所有账号提取到的关键Token导出为 JSON
+func ExportBatchJSON(w io.Writer, items []BatchItem) error {
+	records := make([]batchExport, 0, len(items))
+	for _, item := range items {
+		record := batchExport{
+			Username: item.Username,
+			Success:  item.Status == BatchSuccess,
+			Error:    item.Error,
+			Tokens:   []batchToken{},
+		}
+		if item.Result != nil && !item.Result.Timestamp.IsZero() {
+			timestamp := item.Result.Timestamp
+			record.Timestamp = &timestamp
+		}
+		for _, header := range item.KeyHeaders() {
+			token := batchToken{
+				Name:   header.Name,
+				Value:  header.Value,
+				Source: sourceName(header.Source),
+			}
+			if !header.ExpiresAt.IsZero() {
+				expiresAt := header.ExpiresAt
+				token.ExpiresAt = &expiresAt
+			}
+			record.Tokens = append(record.Tokens, token)
+		}
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// ExportBatchCSV 将所有账号提取到的关键Token导出为 CSV，每个Token一行，失败的账号单独一行
+func ExportBatchCSV(w io.Writer, items []BatchItem) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"账号", "状态", "名称", "来源", "值", "过期时间", "错误"}); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		headers := item.KeyHeaders()
+		if len(headers) == 0 {
+			status := "失败"
+			if item.Status == BatchSuccess {
+				status = "成功"
+			}
+			if err := writer.Write([]string{item.Username, status, "", "", "", "", item.Error}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, header := range headers {
+			expiresAt := ""
+			if !header.ExpiresAt.IsZero() {
+				expiresAt = header.ExpiresAt.Format(time.RFC3339)
+			}
+			row := []string{item.Username, "成功", header.Name, sourceName(header.Source), header.Value, expiresAt, ""}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// sourceName 返回导出使用的来源名称，空来源为请求头
+func sourceName(source string) string {
+	if source == "" {
+		return SourceHeader
+	}
+	return source
+}
@@ -0,0 +1,352 @@
+package token_extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// batchColumns 批量提取结果表格的列
+var batchColumns = []string{"账号", "状态", "进度", "关键Token", "耗时"}
+
+// showBatchDialog 显示批量提取对话框：载入账号列表，按当前站点配置和URL逐个提取
+func (ui *TokenExtractorUI) showBatchDialog() {
+	var (
+		accounts []BatchAccount
+		items    []BatchItem
+		cancel   context.CancelFunc // 运行中时非空
+	)
+
+	sourceLabel := widget.NewLabel("尚未载入账号")
+	summaryLabel := widget.NewLabel("")
+	progress := widget.NewProgressBar()
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(items), len(batchColumns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			if id.Row >= len(items) {
+				return
+			}
+			obj.(*widget.Label).SetText(batchCellText(items[id.Row], id.Col))
+		},
+	)
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		label := obj.(*widget.Label)
+		if id.Row < 0 {
+			label.SetText(batchColumns[id.Col])
+		} else {
+			label.SetText(strconv.Itoa(id.Row + 1))
+		}
+	}
+	for col, width := range []float32{150, 90, 260, 220, 70} {
+		table.SetColumnWidth(col, width)
+	}
+
+	// refresh 刷新表格、进度条和统计
+	refresh := func() {
+		var done, succeeded int
+		for _, item := range items {
+			switch item.Status {
+			case BatchSuccess:
+				done++
+				succeeded++
+			case BatchFailed:
+				done++
+			}
+		}
+		if len(items) > 0 {
+			progress.SetValue(float64(done) / float64(len(items)))
+		} else {
+			progress.SetValue(0)
+		}
+		summaryLabel.SetText(fmt.Sprintf("完成 %d / %d，成功 %d，失败 %d", done, len(items), succeeded, done-succeeded))
+		table.Refresh()
+	}
+
+	// resetItems 清空上次的结果，所有账号回到等待状态
+	resetItems := func() {
+		items = make([]BatchItem, len(accounts))
+		for i, account := range accounts {
+			items[i] = BatchItem{Username: account.Username, Status: BatchPending}
+		}
+		refresh()
+	}
+
+	// setAccounts 载入新的账号列表
+	setAccounts := func(loaded []BatchAccount, source string) {
+		accounts = loaded
+		resetItems()
+		sourceLabel.SetText(fmt.Sprintf("已从%s载入 %d 个账号", source, len(accounts)))
+	}
+
+	importButton := widget.NewButton("从CSV导入", func() {
+		ui.importBatchAccounts(func(loaded []BatchAccount) {
+			setAccounts(loaded, "CSV")
+		})
+	})
+	savedButton := widget.NewButton("载入保存的账号", func() {
+		ui.loadSavedBatchAccounts(func(loaded []BatchAccount) {
+			setAccounts(loaded, "保存的账号")
+		})
+	})
+
+	concurrencyOptions := make([]string, MaxBatchConcurrency)
+	for i := range concurrencyOptions {
+		concurrencyOptions[i] = strconv.Itoa(i + 1)
+	}
+	concurrencySelect := widget.NewSelect(concurrencyOptions, nil)
+	concurrencySelect.SetSelected(strconv.Itoa(DefaultBatchConcurrency))
+
+	var startButton, stopButton *widget.Button
+	setRunning := func(running bool) {
+		if running {
+			startButton.Disable()
+			importButton.Disable()
+			savedButton.Disable()
+			concurrencySelect.Disable()
+			stopButton.Enable()
+		} else {
+			startButton.Enable()
+			importButton.Enable()
+			savedButton.Enable()
+			concurrencySelect.Enable()
+			stopButton.Disable()
+		}
+	}
+
+	startButton = widget.NewButton("开始提取", func() {
+		if len(accounts) == 0 {
+			dialog.ShowError(errors.New("请先导入或载入账号"), ui.window)
+			return
+		}
+		base, err := ui.batchRequest()
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		concurrency, _ := strconv.Atoi(concurrencySelect.Selected)
+
+		resetItems()
+		sourceLabel.SetText(fmt.Sprintf("正在提取 %d 个账号，同时运行 %d 个", len(accounts), concurrency))
+		setRunning(true)
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		run := accounts
+		go func() {
+			results := RunBatch(ctx, ui.extractor, base, run, concurrency, func(index int, item BatchItem) {
+				if item.Status == BatchSuccess {
+					ui.saveHistory(item.Username, item.Result)
+				}
+				fyne.Do(func() {
+					items[index] = item
+					refresh()
+				})
+			})
+
+			fyne.Do(func() {
+				items = results
+				cancel = nil
+				setRunning(false)
+				sourceLabel.SetText(fmt.Sprintf("已完成 %d 个账号的提取", len(results)))
+				refresh()
+			})
+		}()
+	})
+
+	stopButton = widget.NewButton("停止", func() {
+		if cancel != nil {
+			cancel()
+			sourceLabel.SetText("正在停止，等待进行中的账号结束...")
+		}
+	})
+	stopButton.Disable()
+
+	exportJSONButton := widget.NewButton("导出JSON", func() {
+		ui.saveBatchExport(items, "tokens_batch.json", ExportBatchJSON)
+	})
+	exportCSVButton := widget.NewButton("导出CSV", func() {
+		ui.saveBatchExport(items, "tokens_batch.csv", ExportBatchCSV)
+	})
+
+	hint := widget.NewLabel(fmt.Sprintf("使用站点配置「%s」和当前URL登录。CSV每行为“账号,密码”，第一行可以是表头。", ui.currentProfile().Name))
+	hint.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(
+			hint,
+			container.NewHBox(importButton, savedButton, widget.NewLabel("同时运行:"), concurrencySelect),
+			sourceLabel,
+			progress,
+			summaryLabel,
+		),
+		container.NewHBox(startButton, stopButton, exportJSONButton, exportCSVButton),
+		nil, nil,
+		table,
+	)
+
+	d := dialog.NewCustom("批量提取", "关闭", content, ui.window)
+	d.SetOnClosed(func() {
+		// 关闭后不再继续提取，进行中的结果仍会保存到历史
+		if cancel != nil {
+			cancel()
+		}
+	})
+	d.Resize(fyne.NewSize(880, 600))
+	d.Show()
+}
+
+// batchCellText 返回批量提取表格单元格的内容
+func batchCellText(item BatchItem, col int) string {
+	switch col {
+	case 0:
+		return item.Username
+	case 1:
+		switch item.Status {
+		case BatchRunning:
+			return "🔄 提取中"
+		case BatchSuccess:
+			return "✅ 成功"
+		case BatchFailed:
+			return "❌ 失败"
+		}
+		return "⏳ 等待"
+	case 2:
+		if item.Status == BatchFailed {
+			return item.Error
+		}
+		if item.Status == BatchSuccess && item.Result != nil {
+			return fmt.Sprintf("共捕获 %d 项", len(item.Result.Headers))
+		}
+		return item.Step
+	case 3:
+		var names []string
+		for _, header := range item.KeyHeaders() {
+			names = append(names, header.Name)
+		}
+		return strings.Join(names, ", ")
+	case 4:
+		if item.Duration > 0 {
+			return item.Duration.Round(time.Second).String()
+		}
+	}
+	return ""
+}
+
+// batchRequest 按当前站点配置和URL创建批量提取使用的请求
+func (ui *TokenExtractorUI) batchRequest() (LoginRequest, error) {
+	if ui.urlEntry.Text == "" {
+		return LoginRequest{}, errors.New("请输入目标URL")
+	}
+	profile := ui.currentProfile()
+	if err := profile.Validate(); err != nil {
+		return LoginRequest{}, errors.New("站点配置无效: " + err.Error())
+	}
+	return LoginRequest{TargetURL: ui.urlEntry.Text, Profile: profile}, nil
+}
+
+// importBatchAccounts 从CSV文件导入账号列表
+func (ui *TokenExtractorUI) importBatchAccounts(done func([]BatchAccount)) {
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		accounts, err := ParseAccountsCSV(reader)
+		if err != nil {
+			dialog.ShowError(errors.New("导入账号失败: "+err.Error()), ui.window)
+			return
+		}
+		done(accounts)
+	}, ui.window)
+	d.Show()
+}
+
+// loadSavedBatchAccounts 读取当前站点配置保存的所有账号和密码，读取失败的账号跳过
+func (ui *TokenExtractorUI) loadSavedBatchAccounts(done func([]BatchAccount)) {
+	saved := ui.profiles.AccountsFor(ui.currentProfile().ID)
+	if len(saved) == 0 {
+		dialog.ShowError(errors.New("当前站点配置没有保存的账号"), ui.window)
+		return
+	}
+
+	// 有保存在加密文件中的账号时先解锁
+	backend := BackendKeyring
+	for _, account := range saved {
+		if ui.vault.NeedsUnlock(account.Backend) {
+			backend = BackendFile
+		}
+	}
+
+	ui.withVault(backend, func() {
+		var accounts []BatchAccount
+		var failed []string
+		for _, account := range saved {
+			password, err := ui.vault.Password(account)
+			if err != nil {
+				failed = append(failed, account.Username+": "+err.Error())
+				continue
+			}
+			accounts = append(accounts, BatchAccount{Username: account.Username, Password: password})
+		}
+
+		if len(failed) > 0 {
+			dialog.ShowError(errors.New("以下账号读取密码失败，已跳过:\n"+strings.Join(failed, "\n")), ui.window)
+		}
+		if len(accounts) > 0 {
+			done(accounts)
+		}
+	})
+}
+
+// saveBatchExport 弹出保存文件对话框，导出批量提取的关键Token
+func (ui *TokenExtractorUI) saveBatchExport(items []BatchItem, fileName string, export func(w io.Writer, items []BatchItem) error) {
+	if len(items) == 0 {
+		dialog.ShowError(errors.New("没有可导出的结果"), ui.window)
+		return
+	}
+
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := export(writer, items); err != nil {
+			dialog.ShowError(errors.New("导出失败: "+err.Error()), ui.window)
+			return
+		}
+
+		dialog.ShowInformation("成功", "已导出到 "+writer.URI().Path(), ui.window)
+	}, ui.window)
+	d.SetFileName(fileName)
+	d.Show()
+}
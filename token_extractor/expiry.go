@@ -0,0 +1,151 @@
+package token_extractor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 自动刷新的时间安排
+const (
+	refreshLead       = 5 * time.Minute  // 在过期前多久重新提取
+	minRefreshDelay   = 30 * time.Second // 两次自动提取之间的最短间隔
+	refreshRetryDelay = 2 * time.Minute  // 自动提取失败后重试的间隔
+)
+
+// DecodeJWTExpiry 解析JWT（可以带 Bearer 前缀）载荷中的 exp，不是JWT或没有 exp 时返回 false
+func DecodeJWTExpiry(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+		value = strings.TrimSpace(value[7:])
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == "" {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
+// ParseTimestamp 解析Unix时间戳（秒或毫秒），不在 2000-2100 年之间时返回 false
+func ParseTimestamp(value string) (time.Time, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+
+	t := time.Unix(n, 0)
+	if n > 1e12 {
+		t = time.UnixMilli(n)
+	}
+	if t.Year() < 2000 || t.Year() > 2100 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// annotateExpiry 按站点配置计算每个Token的过期时间：
+// JWT 使用载荷中的 exp；时间戳头部的值在将来时就是过期时间，在过去时视为签发时间再加上有效期；
+// 其他关键Token在配置了有效期时按提取时间计算。
+func (p *SiteProfile) annotateExpiry(headers []HeaderInfo, capturedAt time.Time) {
+	lifetime := p.lifetime()
+
+	for i := range headers {
+		header := &headers[i]
+		if exp, ok := DecodeJWTExpiry(header.Value); ok {
+			header.ExpiresAt = exp
+			continue
+		}
+
+		if header.Source == SourceHeader && p.IsTimestampHeader(header.Name) {
+			if ts, ok := ParseTimestamp(header.Value); ok {
+				switch {
+				case ts.After(capturedAt):
+					header.ExpiresAt = ts
+				case lifetime > 0:
+					header.ExpiresAt = ts.Add(lifetime)
+				}
+				continue
+			}
+		}
+
+		if header.IsKey && lifetime > 0 {
+			header.ExpiresAt = capturedAt.Add(lifetime)
+		}
+	}
+}
+
+// IsTimestampHeader 判断头部的值是否为时间戳（不区分大小写）
+func (p *SiteProfile) IsTimestampHeader(name string) bool {
+	return wantsName(p.TimestampHeaders, name, true)
+}
+
+// lifetime 返回配置的Token有效期，未配置或无效时为 0
+func (p *SiteProfile) lifetime() time.Duration {
+	d, _ := time.ParseDuration(p.TokenLifetime)
+	return d
+}
+
+// ExpiresAt 返回关键Token中最早的过期时间，都不知道过期时间时返回 false
+func (r *ExtractResult) ExpiresAt() (time.Time, bool) {
+	var earliest time.Time
+	for _, header := range r.Headers {
+		if !header.IsKey || header.ExpiresAt.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || header.ExpiresAt.Before(earliest) {
+			earliest = header.ExpiresAt
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// RefreshAt 返回自动重新提取的时间：过期前 refreshLead，剩余时间较短时取剩余时间的一半，且不早于 minRefreshDelay 之后
+func RefreshAt(expiresAt, now time.Time) time.Time {
+	remaining := expiresAt.Sub(now)
+	lead := refreshLead
+	if remaining < 2*lead {
+		lead = remaining / 2
+	}
+
+	at := expiresAt.Add(-lead)
+	if earliest := now.Add(minRefreshDelay); at.Before(earliest) {
+		at = earliest
+	}
+	return at
+}
+
+// FormatRemaining 返回剩余有效时间的倒计时文本
+func FormatRemaining(expiresAt, now time.Time) string {
+	remaining := expiresAt.Sub(now)
+	if remaining <= 0 {
+		return "已过期"
+	}
+
+	remaining = remaining.Truncate(time.Second)
+	hours := int(remaining.Hours())
+	minutes := int(remaining.Minutes()) % 60
+	seconds := int(remaining.Seconds()) % 60
+	if hours >= 24 {
+		return fmt.Sprintf("%d天%d小时", hours/24, hours%24)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
@@ -0,0 +1,257 @@
+package token_extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 导出格式
+const (
+	ExportCurl     = "curl"     // 选中请求的 curl 命令
+	ExportHTTPie   = "httpie"   // 选中请求的 HTTPie 命令
+	ExportPostman  = "postman"  // Postman 环境，关键Token作为变量
+	ExportDotEnv   = "dotenv"   // .env 文件，关键Token作为环境变量
+	ExportTemplate = "template" // 自定义的 Go 模板
+)
+
+// ExportFormat 导出格式的说明
+type ExportFormat struct {
+	ID           string
+	Name         string // 显示名称
+	FileName     string // 保存时的默认文件名
+	NeedsRequest bool   // 是否需要选择一个捕获到的请求
+}
+
+// ExportFormats 支持的导出格式
+var ExportFormats = []ExportFormat{
+	{ID: ExportCurl, Name: "curl 命令", FileName: "request.sh", NeedsRequest: true},
+	{ID: ExportHTTPie, Name: "HTTPie 命令", FileName: "request_httpie.sh", NeedsRequest: true},
+	{ID: ExportPostman, Name: "Postman 环境", FileName: "postman_environment.json"},
+	{ID: ExportDotEnv, Name: ".env 文件", FileName: "tokens.env"},
+	{ID: ExportTemplate, Name: "自定义模板", FileName: "tokens.txt"},
+}
+
+// DefaultExportTemplate 自定义模板的初始内容
+const DefaultExportTemplate = `# 提取时间 {{.Timestamp.Format "2006-01-02 15:04:05"}}
+{{range .KeyHeaders}}{{.Name}}: {{.Value}}
+{{end}}`
+
+// ExportData 自定义模板可以使用的数据
+type ExportData struct {
+	Timestamp  time.Time
+	Headers    []HeaderInfo      // 所有捕获到的Token
+	KeyHeaders []HeaderInfo      // 关键Token
+	Key        map[string]string // 关键Token，名称 -> 值
+	Requests   []CapturedRequest // 所有匹配的请求
+	Request    *CapturedRequest  // 选中的请求，没有时为空
+}
+
+// exportFuncs 自定义模板中可以使用的函数
+var exportFuncs = template.FuncMap{
+	"shellquote": shellQuote,
+	"envname":    envName,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// RenderTemplate 使用自定义的 Go 模板导出提取结果，request 为选中的请求，可以为空
+func RenderTemplate(text string, result *ExtractResult, request *CapturedRequest) (string, error) {
+	tmpl, err := template.New("export").Funcs(exportFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("模板格式错误: %w", err)
+	}
+
+	data := ExportData{
+		Timestamp:  result.Timestamp,
+		Headers:    result.Headers,
+		KeyHeaders: keyHeaders(result),
+		Key:        make(map[string]string),
+		Requests:   result.Requests,
+		Request:    request,
+	}
+	for _, header := range data.KeyHeaders {
+		data.Key[header.Name] = header.Value
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("模板执行失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// CurlCommand 返回重新发送请求的 curl 命令
+func CurlCommand(req CapturedRequest) string {
+	lines := []string{"curl " + shellQuote(req.URL)}
+	if req.Method != "" && req.Method != "GET" {
+		lines[0] = "curl -X " + req.Method + " " + shellQuote(req.URL)
+	}
+
+	compressed := false
+	for _, name := range requestHeaderNames(req) {
+		if strings.EqualFold(name, "Accept-Encoding") {
+			compressed = true
+		}
+		lines = append(lines, "-H "+shellQuote(name+": "+req.Headers[name]))
+	}
+	if req.Body != "" {
+		lines = append(lines, "--data-raw "+shellQuote(req.Body))
+	}
+	// 浏览器接受压缩的响应，curl 需要 --compressed 才会解压
+	if compressed {
+		lines = append(lines, "--compressed")
+	}
+	return strings.Join(lines, " \\\n  ") + "\n"
+}
+
+// HTTPieCommand 返回重新发送请求的 HTTPie 命令
+func HTTPieCommand(req CapturedRequest) string {
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	lines := []string{"http " + method + " " + shellQuote(req.URL)}
+
+	for _, name := range requestHeaderNames(req) {
+		// HTTPie 使用 “名称;” 表示值为空的头部
+		item := name + ":" + req.Headers[name]
+		if req.Headers[name] == "" {
+			item = name + ";"
+		}
+		lines = append(lines, shellQuote(item))
+	}
+	if req.Body != "" {
+		lines = append(lines, "--raw "+shellQuote(req.Body))
+	}
+	return strings.Join(lines, " \\\n  ") + "\n"
+}
+
+// requestHeaderNames 返回导出命令使用的请求头名称（已排序），
+// 跳过 HTTP/2 伪头部和由客户端计算的 Content-Length
+func requestHeaderNames(req CapturedRequest) []string {
+	var names []string
+	for name := range req.Headers {
+		if strings.HasPrefix(name, ":") || strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// postmanEnvironment Postman 环境文件
+type postmanEnvironment struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Values     []postmanVariable `json:"values"`
+	Scope      string            `json:"_postman_variable_scope"`
+	ExportedAt string            `json:"_postman_exported_at"`
+}
+
+// postmanVariable Postman 环境变量
+type postmanVariable struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// PostmanEnvironment 将关键Token导出为 Postman 环境，变量名与Token名称相同，可以在请求中用 {{名称}} 引用
+func PostmanEnvironment(name string, result *ExtractResult) ([]byte, error) {
+	env := postmanEnvironment{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Values:     []postmanVariable{},
+		Scope:      "environment",
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	seen := make(map[string]bool)
+	for _, header := range keyHeaders(result) {
+		if seen[header.Name] {
+			continue
+		}
+		seen[header.Name] = true
+		env.Values = append(env.Values, postmanVariable{
+			Key:     header.Name,
+			Value:   header.Value,
+			Type:    "secret",
+			Enabled: true,
+		})
+	}
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// DotEnv 将关键Token导出为 .env 文件，变量名为Token名称转换成的大写形式（例如 X_AUTH_TOKEN）
+func DotEnv(result *ExtractResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Token提取器导出于 %s\n", result.Timestamp.Format("2006-01-02 15:04:05"))
+
+	seen := make(map[string]int)
+	for _, header := range keyHeaders(result) {
+		name := envName(header.Name)
+		// 不同来源的同名Token加上序号
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", name, dotEnvQuote(header.Value))
+	}
+	return b.String()
+}
+
+// keyHeaders 返回关键Token，按名称排序
+func keyHeaders(result *ExtractResult) []HeaderInfo {
+	var headers []HeaderInfo
+	for _, header := range result.Headers {
+		if header.IsKey {
+			headers = append(headers, header)
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+	return headers
+}
+
+// envName 将Token名称转换为环境变量名：大写，字母和数字以外的字符替换为下划线
+func envName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	result := b.String()
+	if result == "" || result[0] >= '0' && result[0] <= '9' {
+		result = "_" + result
+	}
+	return result
+}
+
+// dotEnvQuote 为 .env 的值加上双引号并转义
+func dotEnvQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "$", `\$`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
+
+// shellQuote 为 shell 参数加上单引号
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,185 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showExportDialog 显示导出对话框：选择格式（和请求）后预览，复制到剪贴板或保存到文件
+func (ui *TokenExtractorUI) showExportDialog() {
+	result := ui.currentResult
+	if result == nil || !result.Success {
+		dialog.ShowError(errors.New("没有可导出的结果"), ui.window)
+		return
+	}
+
+	formatNames := make([]string, len(ExportFormats))
+	for i, format := range ExportFormats {
+		formatNames[i] = format.Name
+	}
+	requestNames := make([]string, len(result.Requests))
+	for i, req := range result.Requests {
+		requestNames[i] = fmt.Sprintf("%d. %s %s", i+1, req.Method, req.URL)
+	}
+
+	format := ExportFormats[0]
+	formatSelect := widget.NewSelect(formatNames, nil)
+	requestSelect := widget.NewSelect(requestNames, nil)
+	requestSelect.PlaceHolder = "没有捕获到匹配的请求"
+	requestRow := container.NewBorder(nil, nil, widget.NewLabel("请求:"), nil, requestSelect)
+
+	templateEntry := widget.NewMultiLineEntry()
+	templateEntry.SetText(ui.profiles.CustomTemplate)
+	if templateEntry.Text == "" {
+		templateEntry.SetText(DefaultExportTemplate)
+	}
+	templateEntry.SetMinRowsVisible(4)
+	templateHint := widget.NewLabel("Go 模板，可用 .Key \"名称\"、.KeyHeaders、.Headers、.Request、.Requests、.Timestamp，函数 shellquote、envname、json")
+	templateHint.Wrapping = fyne.TextWrapWord
+	templateBox := container.NewVBox(templateHint, templateEntry)
+
+	preview := widget.NewMultiLineEntry()
+	preview.Wrapping = fyne.TextWrapBreak
+	errorLabel := widget.NewLabel("")
+	errorLabel.Wrapping = fyne.TextWrapWord
+	errorLabel.Hide()
+
+	// render 按当前选择生成导出内容
+	render := func() (string, error) {
+		var request *CapturedRequest
+		if i := requestSelect.SelectedIndex(); i >= 0 {
+			request = &result.Requests[i]
+		}
+
+		switch format.ID {
+		case ExportCurl, ExportHTTPie:
+			if request == nil {
+				return "", errors.New("没有捕获到与站点配置匹配的请求")
+			}
+			if format.ID == ExportCurl {
+				return CurlCommand(*request), nil
+			}
+			return HTTPieCommand(*request), nil
+		case ExportPostman:
+			if len(keyHeaders(result)) == 0 {
+				return "", errors.New("未找到关键Token")
+			}
+			data, err := PostmanEnvironment(ui.currentProfile().Name+" Token", result)
+			return string(data) + "\n", err
+		case ExportDotEnv:
+			if len(keyHeaders(result)) == 0 {
+				return "", errors.New("未找到关键Token")
+			}
+			return DotEnv(result), nil
+		}
+		return RenderTemplate(templateEntry.Text, result, request)
+	}
+
+	var copyButton, saveButton *widget.Button
+	updatePreview := func() {
+		if format.NeedsRequest {
+			requestRow.Show()
+		} else {
+			requestRow.Hide()
+		}
+		if format.ID == ExportTemplate {
+			templateBox.Show()
+		} else {
+			templateBox.Hide()
+		}
+
+		text, err := render()
+		if err != nil {
+			errorLabel.SetText("❌ " + err.Error())
+			errorLabel.Show()
+			preview.SetText("")
+			copyButton.Disable()
+			saveButton.Disable()
+			return
+		}
+		errorLabel.Hide()
+		preview.SetText(text)
+		copyButton.Enable()
+		saveButton.Enable()
+	}
+
+	// exported 复制或保存后记住修改过的自定义模板
+	exported := func() {
+		if format.ID != ExportTemplate || templateEntry.Text == ui.profiles.CustomTemplate {
+			return
+		}
+		ui.profiles.CustomTemplate = templateEntry.Text
+		if err := ui.saveProfiles(); err != nil {
+			ui.statusLabel.SetText("⚠️ 保存自定义模板失败: " + err.Error())
+		}
+	}
+
+	copyButton = widget.NewButton("复制到剪贴板", func() {
+		ui.window.Clipboard().SetContent(preview.Text)
+		exported()
+		dialog.ShowInformation("成功", "已复制到剪贴板", ui.window)
+	})
+	saveButton = widget.NewButton("保存到文件", func() {
+		d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if _, err := writer.Write([]byte(preview.Text)); err != nil {
+				dialog.ShowError(errors.New("导出失败: "+err.Error()), ui.window)
+				return
+			}
+			exported()
+			dialog.ShowInformation("成功", "已导出到 "+writer.URI().Path(), ui.window)
+		}, ui.window)
+		d.SetFileName(format.FileName)
+		d.Show()
+	})
+
+	formatSelect.OnChanged = func(string) {
+		format = ExportFormats[formatSelect.SelectedIndex()]
+		updatePreview()
+	}
+	requestSelect.OnChanged = func(string) {
+		updatePreview()
+	}
+	templateEntry.OnChanged = func(string) {
+		updatePreview()
+	}
+
+	// 默认选中最后一个请求，其中的Token最新
+	if len(requestNames) > 0 {
+		requestSelect.SetSelectedIndex(len(requestNames) - 1)
+	}
+	formatSelect.SetSelectedIndex(0)
+
+	hint := widget.NewLabel("导出内容包含完整的Token，请妥善保管。预览中的内容可以修改后再复制或保存。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(
+			hint,
+			container.NewBorder(nil, nil, widget.NewLabel("格式:"), nil, formatSelect),
+			requestRow,
+			templateBox,
+			errorLabel,
+		),
+		container.NewHBox(copyButton, saveButton),
+		nil, nil,
+		preview,
+	)
+
+	d := dialog.NewCustom("导出Token", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(760, 600))
+	d.Show()
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
 	"github.com/chromedp/chromedp"
 )
 
@@ -18,6 +18,7 @@ var (
 	ErrBrowserError       = errors.New("浏览器初始化失败")
 	ErrTimeout            = errors.New("操作超时")
 	ErrNoHeaders          = errors.New("未能捕获到请求头")
+	ErrCanceled           = errors.New("提取已取消")
 )
 
 // Extractor token提取器接口
@@ -29,6 +30,34 @@ type Extractor interface {
 	Close() error
 }
 
+// profileExtractor 按站点配置的提取方式选择浏览器或HTTP实现
+type profileExtractor struct {
+	browser Extractor
+	http    Extractor
+}
+
+// NewExtractor 创建提取器：站点配置使用HTTP方式时直接发送请求，否则使用Chrome
+func NewExtractor() (Extractor, error) {
+	browser, err := NewChromeExtractor()
+	if err != nil {
+		return nil, err
+	}
+	return &profileExtractor{browser: browser, http: NewHTTPExtractor()}, nil
+}
+
+// Extract 按请求中的站点配置选择实现
+func (e *profileExtractor) Extract(ctx context.Context, req LoginRequest) (*ExtractResult, error) {
+	if req.Profile != nil && req.Profile.UsesHTTP() {
+		return e.http.Extract(ctx, req)
+	}
+	return e.browser.Extract(ctx, req)
+}
+
+// Close 关闭两种实现的资源
+func (e *profileExtractor) Close() error {
+	return errors.Join(e.browser.Close(), e.http.Close())
+}
+
 // ChromeExtractor 基于Chrome的实现
 type ChromeExtractor struct {
 	allocCtx   context.Context
@@ -66,12 +95,33 @@ func (e *ChromeExtractor) Extract(ctx context.Context, req LoginRequest) (*Extra
 		}, err
 	}
 
+	// 站点配置：使用副本并预先编译规则，监听回调中只读取已编译的规则
+	profile := DefaultProfile()
+	if req.Profile != nil {
+		profile = req.Profile
+	}
+	site := *profile
+	if err := site.Validate(); err != nil {
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		}, err
+	}
+
+	// 调用方取消或到达调用方的截止时间时关闭浏览器。
+	// chromedp 的取消函数会等待浏览器退出，只能调用一次，所以这里取消的是它的父上下文
+	parentCtx, parentCancel := context.WithCancel(e.allocCtx)
+	defer parentCancel()
+	stop := context.AfterFunc(ctx, parentCancel)
+	defer stop()
+
 	// 创建浏览器上下文
-	browserCtx, cancel := chromedp.NewContext(e.allocCtx)
+	browserCtx, cancel := chromedp.NewContext(parentCtx)
 	defer cancel()
 
 	// 设置超时
-	timeoutCtx, timeoutCancel := context.WithTimeout(browserCtx, 90*time.Second)
+	timeoutCtx, timeoutCancel := context.WithTimeout(browserCtx, site.timeout())
 	defer timeoutCancel()
 
 	// 存储捕获的请求头
@@ -79,73 +129,157 @@ func (e *ChromeExtractor) Extract(ctx context.Context, req LoginRequest) (*Extra
 	var headersMutex = make(chan struct{}, 1)
 	headersMutex <- struct{}{}
 
+	// 需要读取响应的请求（配置了响应头或响应体Token规则时）
+	matchedRequests := make(map[network.RequestID]bool)
+	var finishedRequests []network.RequestID
+	capturedResponseHeaders := make(map[string]string)
+
+	// 已发出的请求地址，供登录脚本等待特定请求
+	var requestURLs []string
+
+	// 逐个记录匹配的请求和响应
+	recorder := newRequestRecorder(req.Password)
+
 	// 监听网络请求
 	chromedp.ListenTarget(timeoutCtx, func(ev interface{}) {
-		if ev, ok := ev.(*network.EventRequestWillBeSent); ok {
-			// 只捕获目标域名的请求
-			if strings.Contains(ev.Request.URL, "ankersolix-professional-ci.anker.com") {
-				<-headersMutex
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			<-headersMutex
+			requestURLs = append(requestURLs, ev.Request.URL)
+
+			// 只捕获站点配置匹配的请求
+			matched := site.MatchURL(ev.Request.URL)
+			captured := recorder.onRequest(ev, matched)
+			if matched {
 				for name, value := range ev.Request.Headers {
 					if strValue, ok := value.(string); ok {
 						capturedHeaders[name] = strValue
 					}
 				}
-				headersMutex <- struct{}{}
+				if site.NeedsResponses() {
+					matchedRequests[ev.RequestID] = true
+				}
+			}
+			headersMutex <- struct{}{}
+
+			if captured != nil {
+				req.emit(ProgressEvent{
+					Kind:    ProgressRequestCaptured,
+					Message: "捕获到请求 " + captured.Method + " " + captured.URL,
+					Request: captured,
+				})
+			}
+		case *network.EventResponseReceived:
+			<-headersMutex
+			recorder.onResponse(ev)
+			if matchedRequests[ev.RequestID] {
+				for name, value := range ev.Response.Headers {
+					if strValue, ok := value.(string); ok && site.WantsResponseHeader(name) {
+						capturedResponseHeaders[name] = strValue
+					}
+				}
 			}
+			headersMutex <- struct{}{}
+		case *network.EventLoadingFinished:
+			<-headersMutex
+			recorder.onFinished(ev)
+			if matchedRequests[ev.RequestID] {
+				finishedRequests = append(finishedRequests, ev.RequestID)
+			}
+			headersMutex <- struct{}{}
+		case *network.EventLoadingFailed:
+			<-headersMutex
+			recorder.onFailed(ev)
+			headersMutex <- struct{}{}
 		}
 	})
 
-	// 执行登录流程
-	err := chromedp.Run(timeoutCtx,
-		network.Enable(),
-		chromedp.Navigate(req.TargetURL),
-		chromedp.Sleep(2*time.Second),
-
-		// 等待登录表单加载
-		chromedp.WaitVisible(`input[type="text"], input[type="email"], input[name="username"], input[placeholder*="账号"], input[placeholder*="用户名"], input[placeholder*="邮箱"]`, chromedp.ByQuery),
-
-		// 填写用户名
-		chromedp.SendKeys(`input[type="text"], input[type="email"], input[name="username"], input[placeholder*="账号"], input[placeholder*="用户名"], input[placeholder*="邮箱"]`, req.Username, chromedp.ByQuery),
-
-		// 填写密码
-		chromedp.SendKeys(`input[type="password"]`, req.Password, chromedp.ByQuery),
-
-		chromedp.Sleep(1*time.Second),
+	// 按站点配置的登录脚本执行登录流程
+	runner := &scriptRunner{
+		req: req,
+		sawRequest: func(match func(url string) bool) bool {
+			<-headersMutex
+			defer func() { headersMutex <- struct{}{} }()
+			for _, u := range requestURLs {
+				if match(u) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	// snapshotRequests 返回目前记录的请求，失败时也包含在结果中，便于排查
+	snapshotRequests := func() []CapturedRequest {
+		<-headersMutex
+		defer func() { headersMutex <- struct{}{} }()
+		return recorder.snapshot()
+	}
 
-		// 点击登录按钮
-		chromedp.Click(`button[type="submit"], button:contains("登录"), button:contains("Login"), .login-button`, chromedp.ByQuery),
+	// stopped 调用方取消或超时后的结果
+	stopped := func(steps []StepLog) (*ExtractResult, error) {
+		err := stopError(ctx)
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+			Steps:     steps,
+			Requests:  snapshotRequests(),
+		}, err
+	}
 
-		// 等待登录完成（等待页面跳转或特定元素出现）
-		chromedp.Sleep(5*time.Second),
-	)
+	var steps []StepLog
+	err := chromedp.Run(timeoutCtx, network.Enable())
+	if err == nil {
+		req.progress(ProgressBrowserStarted, "浏览器已启动")
+		steps, err = runner.run(timeoutCtx, site.Script())
+	}
 
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return &ExtractResult{
-				Success:   false,
-				Timestamp: time.Now(),
-				Error:     ErrTimeout.Error(),
-			}, ErrTimeout
+		if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+			return stopped(steps)
 		}
 
 		return &ExtractResult{
 			Success:   false,
 			Timestamp: time.Now(),
 			Error:     fmt.Sprintf("登录失败: %v", err),
-		}, ErrLoginFailed
+			Steps:     steps,
+			Requests:  snapshotRequests(),
+		}, fmt.Errorf("%w: %v", ErrLoginFailed, err)
 	}
 
 	// 等待一下确保请求被捕获
-	time.Sleep(2 * time.Second)
+	select {
+	case <-time.After(2 * time.Second):
+	case <-timeoutCtx.Done():
+		return stopped(steps)
+	}
+	req.progress(ProgressCollecting, "登录完成，正在读取Token")
+
+	// 提取Cookie、页面存储和响应体中的Token
+	<-headersMutex
+	requestIDs := append([]network.RequestID(nil), finishedRequests...)
+	headersMutex <- struct{}{}
+	tokens := collectProfileTokens(timeoutCtx, &site, requestIDs)
 
 	// 检查是否捕获到头部
 	<-headersMutex
-	if len(capturedHeaders) == 0 {
+	for name, value := range capturedResponseHeaders {
+		tokens = append(tokens, HeaderInfo{
+			Name:   name,
+			Value:  value,
+			IsKey:  true,
+			Source: SourceResponseHeader,
+		})
+	}
+	if len(capturedHeaders) == 0 && len(tokens) == 0 {
 		headersMutex <- struct{}{}
 		return &ExtractResult{
 			Success:   false,
 			Timestamp: time.Now(),
 			Error:     ErrNoHeaders.Error(),
+			Steps:     steps,
+			Requests:  snapshotRequests(),
 		}, ErrNoHeaders
 	}
 	headersMutex <- struct{}{}
@@ -154,19 +288,121 @@ func (e *ChromeExtractor) Extract(ctx context.Context, req LoginRequest) (*Extra
 	var headers []HeaderInfo
 	for name, value := range capturedHeaders {
 		headers = append(headers, HeaderInfo{
-			Name:  name,
-			Value: value,
-			IsKey: IsKeyHeader(name),
+			Name:   name,
+			Value:  value,
+			IsKey:  site.IsKeyHeader(name),
+			Source: SourceHeader,
 		})
 	}
+	headers = append(headers, tokens...)
+	site.annotateExpiry(headers, time.Now())
 
 	return &ExtractResult{
 		Success:   true,
 		Timestamp: time.Now(),
 		Headers:   headers,
+		Steps:     steps,
+		Requests:  snapshotRequests(),
 	}, nil
 }
 
+// collectProfileTokens 按站点配置提取登录后的Cookie、localStorage/sessionStorage和匹配请求响应体中的Token
+func collectProfileTokens(ctx context.Context, site *SiteProfile, requestIDs []network.RequestID) []HeaderInfo {
+	var tokens []HeaderInfo
+	if len(site.BodyTokens) == 0 {
+		requestIDs = nil
+	}
+	if len(site.Cookies) == 0 && len(site.LocalStorage) == 0 && len(site.SessionStorage) == 0 && len(requestIDs) == 0 {
+		return tokens
+	}
+
+	chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if len(site.Cookies) > 0 {
+			cookies, err := storage.GetCookies().Do(ctx)
+			if err == nil {
+				for _, cookie := range cookies {
+					if site.WantsCookie(cookie.Name) {
+						tokens = append(tokens, HeaderInfo{
+							Name:   cookie.Name,
+							Value:  cookie.Value,
+							IsKey:  true,
+							Source: SourceCookie,
+						})
+					}
+				}
+			}
+		}
+
+		// 页面存储读取失败（例如页面禁止访问存储）时跳过
+		for _, area := range []struct {
+			session bool
+			keys    []string
+			source  string
+		}{
+			{false, site.LocalStorage, SourceLocalStorage},
+			{true, site.SessionStorage, SourceSessionStorage},
+		} {
+			if len(area.keys) == 0 {
+				continue
+			}
+			var items map[string]string
+			if err := chromedp.Evaluate(storageScript(area.session), &items).Do(ctx); err != nil {
+				continue
+			}
+			for key, value := range items {
+				if site.WantsStorageKey(area.session, key) {
+					tokens = append(tokens, HeaderInfo{
+						Name:   key,
+						Value:  value,
+						IsKey:  true,
+						Source: area.source,
+					})
+				}
+			}
+		}
+
+		found := make(map[string]bool)
+		for _, id := range requestIDs {
+			// 响应体可能已被浏览器释放，读取失败时跳过
+			body, err := network.GetResponseBody(id).Do(ctx)
+			if err != nil {
+				continue
+			}
+			for name, value := range site.ExtractBodyTokens(string(body)) {
+				if found[name] {
+					continue
+				}
+				found[name] = true
+				tokens = append(tokens, HeaderInfo{
+					Name:   name,
+					Value:  value,
+					IsKey:  true,
+					Source: SourceBody,
+				})
+			}
+		}
+		return nil
+	}))
+
+	return tokens
+}
+
+// storageScript 返回读取页面 localStorage 或 sessionStorage 所有键值的脚本
+func storageScript(session bool) string {
+	name := "localStorage"
+	if session {
+		name = "sessionStorage"
+	}
+	return `(() => {
+	const s = window.` + name + `, items = {};
+	for (let i = 0; i < s.length; i++) {
+		const key = s.key(i);
+		items[key] = s.getItem(key);
+	}
+	return items;
+})()`
+}
+
 // Close 清理资源
 func (e *ChromeExtractor) Close() error {
 	if e.allocCancel != nil {
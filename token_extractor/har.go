@@ -0,0 +1,219 @@
+package token_extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR 1.2 格式（http://www.softwareishard.com/blog/har-12-spec/），只包含导出需要的字段
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // 毫秒
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WriteHAR 将捕获的请求导出为 HAR 文件，可以导入浏览器开发者工具等查看；不包含响应体
+func WriteHAR(w io.Writer, requests []CapturedRequest) error {
+	file := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "Token提取器", Version: "1.0"},
+		Entries: make([]harEntry, 0, len(requests)),
+	}}
+
+	for _, req := range requests {
+		file.Log.Entries = append(file.Log.Entries, harEntryFor(req))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// harEntryFor 将单个请求转换为 HAR 条目
+func harEntryFor(req CapturedRequest) harEntry {
+	version := harHTTPVersion(req.Protocol)
+
+	request := harRequest{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: version,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Headers),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(req.Body),
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		query := u.Query()
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range query[name] {
+				request.QueryString = append(request.QueryString, harNameValue{Name: name, Value: value})
+			}
+		}
+	}
+	if req.Body != "" {
+		request.PostData = &harPostData{
+			MimeType: headerValue(req.Headers, "Content-Type"),
+			Text:     req.Body,
+		}
+	}
+
+	response := harResponse{
+		Status:      req.Status,
+		StatusText:  req.StatusText,
+		HTTPVersion: version,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.ResponseHeaders),
+		Content:     harContent{Size: req.Size, MimeType: req.MimeType},
+		RedirectURL: headerValue(req.ResponseHeaders, "Location"),
+		HeadersSize: -1,
+		BodySize:    req.Size,
+	}
+	if req.Status == 0 {
+		response.BodySize = -1
+	}
+
+	// 浏览器只提供等待响应头的时间，其余时间都算作接收
+	wait := milliseconds(req.Wait)
+	receive := milliseconds(req.Duration) - wait
+	if receive < 0 {
+		receive = 0
+	}
+
+	return harEntry{
+		StartedDateTime: req.StartedAt.Format(time.RFC3339Nano),
+		Time:            milliseconds(req.Duration),
+		Request:         request,
+		Response:        response,
+		Timings:         harTimings{Send: 0, Wait: wait, Receive: receive},
+		Comment:         req.Error,
+	}
+}
+
+// harHeaders 将头部转换为按名称排序的 HAR 列表
+func harHeaders(headers map[string]string) []harNameValue {
+	list := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		list = append(list, harNameValue{Name: name, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// harHTTPVersion 将浏览器的协议名称转换为 HAR 的 HTTP 版本
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2.0"
+	case "h3":
+		return "HTTP/3.0"
+	case "":
+		return "HTTP/1.1"
+	}
+	return strings.ToUpper(protocol)
+}
+
+// headerValue 不区分大小写地查找头部的值
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// milliseconds 将时长转换为毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Matches 判断请求是否符合筛选条件：方法、地址、状态码或资源类型中包含筛选文本（不区分大小写），
+// 多个条件用空格分隔，需要全部符合
+func (r CapturedRequest) Matches(filter string) bool {
+	text := strings.ToLower(fmt.Sprintf("%s %s %d %s %s", r.Method, r.URL, r.Status, r.Type, r.Error))
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showRequestsDialog 显示上次提取记录的请求：左侧为可筛选的列表，右侧为选中请求的详情，可以导出为 HAR
+func (ui *TokenExtractorUI) showRequestsDialog() {
+	requests := ui.requests
+	if len(requests) == 0 {
+		dialog.ShowError(errors.New("没有记录的请求，请先提取一次；只记录与站点配置匹配的请求"), ui.window)
+		return
+	}
+
+	// visible 符合筛选条件的请求下标
+	visible := make([]int, len(requests))
+	for i := range visible {
+		visible[i] = i
+	}
+
+	countLabel := widget.NewLabel("")
+	detail := widget.NewMultiLineEntry()
+	detail.Wrapping = fyne.TextWrapBreak
+	detail.SetPlaceHolder("选择一个请求查看详情")
+
+	list := widget.NewList(
+		func() int {
+			return len(visible)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("200 GET https://example.com")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(visible) {
+				return
+			}
+			obj.(*widget.Label).SetText(requestSummary(requests[visible[id]]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(visible) {
+			detail.SetText(requestDetail(requests[visible[id]]))
+		}
+	}
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("筛选：方法、地址、状态码或类型，多个条件用空格分隔，例如 POST 401")
+	filterEntry.OnChanged = func(filter string) {
+		visible = visible[:0]
+		for i, req := range requests {
+			if req.Matches(filter) {
+				visible = append(visible, i)
+			}
+		}
+		countLabel.SetText(fmt.Sprintf("共 %d 个请求，显示 %d 个", len(requests), len(visible)))
+		list.UnselectAll()
+		list.Refresh()
+		detail.SetText("")
+	}
+	filterEntry.OnChanged("")
+
+	exportButton := widget.NewButton("导出HAR", func() {
+		d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if err := WriteHAR(writer, requests); err != nil {
+				dialog.ShowError(errors.New("导出失败: "+err.Error()), ui.window)
+				return
+			}
+			dialog.ShowInformation("成功", "已导出到 "+writer.URI().Path(), ui.window)
+		}, ui.window)
+		d.SetFileName(fmt.Sprintf("tokens_%s.har", time.Now().Format("20060102_150405")))
+		d.Show()
+	})
+
+	split := container.NewHSplit(list, detail)
+	split.Offset = 0.45
+
+	content := container.NewBorder(
+		container.NewVBox(filterEntry, countLabel),
+		container.NewHBox(exportButton, widget.NewLabel("HAR 文件包含完整的请求头（含Token），请妥善保管")),
+		nil, nil,
+		split,
+	)
+
+	d := dialog.NewCustom("请求记录", "关闭", content, ui.window)
+	d.Resize(fyne.NewSize(960, 620))
+	d.Show()
+}
+
+// requestSummary 返回请求列表中的一行
+func requestSummary(req CapturedRequest) string {
+	status := "⏳"
+	switch {
+	case req.Error != "":
+		status = "❌"
+	case req.Status >= 400:
+		status = fmt.Sprintf("⚠️ %d", req.Status)
+	case req.Status > 0:
+		status = fmt.Sprintf("✅ %d", req.Status)
+	}
+
+	summary := fmt.Sprintf("%s %s %s", status, req.Method, req.URL)
+	if req.Duration > 0 {
+		summary += fmt.Sprintf(" (%s)", formatRequestDuration(req.Duration))
+	}
+	return summary
+}
+
+// requestDetail 返回请求详情：概要、请求头、请求体和响应头
+func requestDetail(req CapturedRequest) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n\n", req.Method, req.URL)
+	if req.Status > 0 {
+		fmt.Fprintf(&b, "状态: %d %s\n", req.Status, req.StatusText)
+	}
+	if req.Error != "" {
+		fmt.Fprintf(&b, "错误: %s\n", req.Error)
+	}
+	if req.Type != "" {
+		fmt.Fprintf(&b, "类型: %s\n", req.Type)
+	}
+	if req.Protocol != "" {
+		fmt.Fprintf(&b, "协议: %s\n", req.Protocol)
+	}
+	if !req.StartedAt.IsZero() {
+		fmt.Fprintf(&b, "开始时间: %s\n", req.StartedAt.Format("2006-01-02 15:04:05.000"))
+	}
+	if req.Wait > 0 {
+		fmt.Fprintf(&b, "等待响应: %s\n", formatRequestDuration(req.Wait))
+	}
+	if req.Duration > 0 {
+		fmt.Fprintf(&b, "总耗时: %s\n", formatRequestDuration(req.Duration))
+	}
+	if req.Size > 0 {
+		fmt.Fprintf(&b, "大小: %d 字节\n", req.Size)
+	}
+
+	writeHeaders(&b, "请求头", req.Headers)
+	if req.Body != "" {
+		fmt.Fprintf(&b, "\n== 请求体 ==\n%s\n", req.Body)
+	}
+	if req.Status > 0 {
+		writeHeaders(&b, "响应头", req.ResponseHeaders)
+	}
+	return b.String()
+}
+
+// writeHeaders 按名称顺序写入头部
+func writeHeaders(b *strings.Builder, title string, headers map[string]string) {
+	fmt.Fprintf(b, "\n== %s ==\n", title)
+	for _, header := range harHeaders(headers) {
+		fmt.Fprintf(b, "%s: %s\n", header.Name, header.Value)
+	}
+}
+
+// formatRequestDuration 返回请求耗时的显示文本
+func formatRequestDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%d ms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2f s", d.Seconds())
+}
@@ -0,0 +1,367 @@
+package token_extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxHTTPBodySize 读取响应体的上限，超过的部分不用于提取Token
+const maxHTTPBodySize = 10 << 20
+
+// HTTPExtractor 不启动浏览器，直接按站点配置的 HTTPLogin 依次发送请求登录，
+// 每次提取使用独立的Cookie，自动跟随重定向。只支持请求头、响应头、Cookie和响应体中的Token
+type HTTPExtractor struct {
+	// Transport 发送请求使用的传输层，为空时为 http.DefaultTransport；
+	// 可以设置为 httptest 服务器的 Client().Transport 在本地测试
+	Transport http.RoundTripper
+}
+
+// NewHTTPExtractor 创建HTTP提取器
+func NewHTTPExtractor() *HTTPExtractor {
+	return &HTTPExtractor{}
+}
+
+// Extract 实现提取逻辑
+func (e *HTTPExtractor) Extract(ctx context.Context, req LoginRequest) (*ExtractResult, error) {
+	// 验证请求
+	if err := req.Validate(); err != nil {
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		}, err
+	}
+
+	profile := DefaultProfile()
+	if req.Profile != nil {
+		profile = req.Profile
+	}
+	site := *profile
+	err := site.Validate()
+	if err == nil && len(site.HTTPLogin) == 0 {
+		err = fmt.Errorf("站点配置 %s 没有设置HTTP登录请求", site.Name)
+	}
+	if err != nil {
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		}, err
+	}
+	base, _ := url.Parse(req.TargetURL)
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, site.timeout())
+	defer timeoutCancel()
+
+	transport := e.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	jar, _ := cookiejar.New(nil)
+	login := &httpLogin{
+		req:       req,
+		site:      &site,
+		transport: transport,
+		headers:   make(map[string]string),
+		responses: make(map[string]string),
+		found:     make(map[string]bool),
+		vars: map[string]string{
+			"username": req.Username,
+			"password": req.Password,
+			"url":      req.TargetURL,
+		},
+	}
+	client := &http.Client{Transport: login, Jar: jar}
+
+	steps, err := login.run(timeoutCtx, client, base)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+			err := stopError(ctx)
+			return &ExtractResult{
+				Success:   false,
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+				Steps:     steps,
+				Requests:  login.snapshot(),
+			}, err
+		}
+
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     fmt.Sprintf("登录失败: %v", err),
+			Steps:     steps,
+			Requests:  login.snapshot(),
+		}, fmt.Errorf("%w: %v", ErrLoginFailed, err)
+	}
+	req.progress(ProgressCollecting, "登录完成，正在读取Token")
+
+	// 响应头、Cookie和响应体中的Token
+	var tokens []HeaderInfo
+	for name, value := range login.responses {
+		tokens = append(tokens, HeaderInfo{
+			Name:   name,
+			Value:  value,
+			IsKey:  true,
+			Source: SourceResponseHeader,
+		})
+	}
+	tokens = append(tokens, login.cookies(jar, base)...)
+	tokens = append(tokens, login.bodyTokens...)
+
+	if len(login.headers) == 0 && len(tokens) == 0 {
+		return &ExtractResult{
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     ErrNoHeaders.Error(),
+			Steps:     steps,
+			Requests:  login.snapshot(),
+		}, ErrNoHeaders
+	}
+
+	// 构建结果
+	var headers []HeaderInfo
+	for name, value := range login.headers {
+		headers = append(headers, HeaderInfo{
+			Name:   name,
+			Value:  value,
+			IsKey:  site.IsKeyHeader(name),
+			Source: SourceHeader,
+		})
+	}
+	headers = append(headers, tokens...)
+	site.annotateExpiry(headers, time.Now())
+
+	return &ExtractResult{
+		Success:   true,
+		Timestamp: time.Now(),
+		Headers:   headers,
+		Steps:     steps,
+		Requests:  login.snapshot(),
+	}, nil
+}
+
+// Close 清理资源
+func (e *HTTPExtractor) Close() error {
+	return nil
+}
+
+// httpLogin 一次HTTP登录的状态；同时作为客户端的传输层，记录包括重定向在内的每个请求。
+// 请求由同一个goroutine依次发送，不需要加锁
+type httpLogin struct {
+	req       LoginRequest
+	site      *SiteProfile
+	transport http.RoundTripper
+
+	requests []*CapturedRequest // 匹配的请求，按发出顺序
+	last     *CapturedRequest   // 最近一次发出的匹配请求，不匹配时为空
+	visited  []*url.URL         // 所有请求的地址，用于读取Cookie
+
+	headers    map[string]string // 匹配请求的请求头
+	responses  map[string]string // 匹配请求中需要提取的响应头
+	bodyTokens []HeaderInfo      // 响应体中的Token，每条规则只取第一个
+	found      map[string]bool
+	vars       map[string]string // 请求中可以使用的变量，包括之前提取到的Token
+}
+
+// run 依次发送登录请求，每完成一个发送进度事件；某个请求失败时停止并返回已执行的步骤
+func (l *httpLogin) run(ctx context.Context, client *http.Client, base *url.URL) ([]StepLog, error) {
+	var logs []StepLog
+	for i, step := range l.site.HTTPLogin {
+		start := time.Now()
+		err := l.runStep(ctx, client, base, step)
+
+		// 日志中只显示配置的地址，不显示请求体，避免泄露密码
+		target := step.URL
+		if target == "" {
+			target = l.req.TargetURL
+		}
+		log := StepLog{
+			Index:    i + 1,
+			Step:     LoginStep{Action: step.Method, Target: target},
+			Success:  err == nil,
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			log.Error = err.Error()
+		}
+		logs = append(logs, log)
+		l.req.emit(stepProgress(log))
+
+		if err != nil {
+			return logs, fmt.Errorf("第 %d 个请求（%s）失败: %w", log.Index, log.Step.Describe(), err)
+		}
+	}
+	return logs, nil
+}
+
+// runStep 发送一个登录请求并读取响应，状态码为 4xx 或 5xx 时失败
+func (l *httpLogin) runStep(ctx context.Context, client *http.Client, base *url.URL, step HTTPStep) error {
+	request, err := step.newHTTPRequest(base, l.vars)
+	if err != nil {
+		return err
+	}
+
+	l.last = nil
+	resp, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodySize))
+	if captured := l.last; captured != nil {
+		captured.Size = int64(len(body))
+		captured.Duration = time.Since(captured.StartedAt)
+		if err != nil {
+			captured.Error = err.Error()
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrNetworkError, err)
+	}
+
+	if l.last != nil {
+		for name, value := range l.site.ExtractBodyTokens(string(body)) {
+			if l.found[name] {
+				continue
+			}
+			l.found[name] = true
+			l.vars[name] = value
+			l.bodyTokens = append(l.bodyTokens, HeaderInfo{
+				Name:   name,
+				Value:  value,
+				IsKey:  true,
+				Source: SourceBody,
+			})
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		// 有的服务会在错误信息中带上提交的内容
+		return errHTTPStatus(resp, []byte(redactPassword(string(body), l.req.Password)))
+	}
+	return nil
+}
+
+// RoundTrip 发送请求并记录与站点配置匹配的请求和响应，重定向的每一跳都会经过这里
+func (l *httpLogin) RoundTrip(request *http.Request) (*http.Response, error) {
+	l.visited = append(l.visited, request.URL)
+	if !l.site.MatchURL(request.URL.String()) {
+		l.last = nil
+		return l.transport.RoundTrip(request)
+	}
+
+	captured := &CapturedRequest{
+		Method:    request.Method,
+		URL:       request.URL.String(),
+		Headers:   flattenHeaders(request.Header),
+		Type:      "HTTP",
+		StartedAt: time.Now(),
+	}
+	if request.GetBody != nil {
+		if body, err := request.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			captured.Body = redactPassword(string(data), l.req.Password)
+		}
+	}
+	for name, value := range captured.Headers {
+		l.headers[name] = value
+	}
+
+	if len(l.requests) == maxCapturedRequests {
+		l.requests = l.requests[1:]
+	}
+	l.requests = append(l.requests, captured)
+	l.last = captured
+
+	event := *captured
+	l.req.emit(ProgressEvent{
+		Kind:    ProgressRequestCaptured,
+		Message: "捕获到请求 " + captured.Method + " " + captured.URL,
+		Request: &event,
+	})
+
+	resp, err := l.transport.RoundTrip(request)
+	captured.Wait = time.Since(captured.StartedAt)
+	captured.Duration = captured.Wait
+	if err != nil {
+		captured.Error = err.Error()
+		return nil, err
+	}
+
+	captured.Status = resp.StatusCode
+	captured.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode)))
+	captured.Protocol = strings.ToLower(resp.Proto)
+	if resp.ProtoMajor == 2 {
+		captured.Protocol = "h2"
+	}
+	captured.MimeType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	captured.ResponseHeaders = flattenHeaders(resp.Header)
+
+	for name, value := range captured.ResponseHeaders {
+		if l.site.WantsResponseHeader(name) {
+			l.responses[name] = value
+			l.vars[name] = value
+		}
+	}
+	return resp, nil
+}
+
+// cookies 返回登录过程中访问过的地址的Cookie中需要提取的部分，同名的只取一个
+func (l *httpLogin) cookies(jar http.CookieJar, base *url.URL) []HeaderInfo {
+	var tokens []HeaderInfo
+	if len(l.site.Cookies) == 0 {
+		return tokens
+	}
+
+	seen := make(map[string]bool)
+	for _, u := range append([]*url.URL{base}, l.visited...) {
+		for _, cookie := range jar.Cookies(u) {
+			if seen[cookie.Name] || !l.site.WantsCookie(cookie.Name) {
+				continue
+			}
+			seen[cookie.Name] = true
+			tokens = append(tokens, HeaderInfo{
+				Name:   cookie.Name,
+				Value:  cookie.Value,
+				IsKey:  true,
+				Source: SourceCookie,
+			})
+		}
+	}
+	return tokens
+}
+
+// snapshot 返回记录的请求副本，按发出顺序
+func (l *httpLogin) snapshot() []CapturedRequest {
+	requests := make([]CapturedRequest, len(l.requests))
+	for i, captured := range l.requests {
+		requests[i] = *captured
+	}
+	return requests
+}
+
+// flattenHeaders 将头部转换为字符串，同名的多个值用逗号连接
+func flattenHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		result[name] = strings.Join(values, ", ")
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package token_extractor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPassword = `p&ss="w rd"`
+
+// newTestLoginServer 本地登录服务：表单登录后重定向到会话接口，会话接口设置Cookie并在JSON中返回Token，
+// 用户接口要求 Authorization 中带上这个Token。登录请求只支持HTTPS，所以使用 TLS 服务器
+func newTestLoginServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.PostFormValue("username") != "alice" || r.PostFormValue("password") != testPassword {
+			http.Error(w, `{"msg":"账号或密码错误"}`, http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, "/session", http.StatusFound)
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "cookie-1", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Refresh-Token", "refresh-1")
+		fmt.Fprint(w, `{"data":{"token":"token-1"}}`)
+	})
+	mux.HandleFunc("/api/me", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("sid")
+		if r.Header.Get("Authorization") != "Bearer token-1" || err != nil || cookie.Value != "cookie-1" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"name":"alice"}`)
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testHTTPProfile(t *testing.T, targetURL, script string) *SiteProfile {
+	steps, err := ParseHTTPSteps(script)
+	if err != nil {
+		t.Fatalf("ParseHTTPSteps: %v", err)
+	}
+	profile := NewSiteProfile("本地测试", targetURL)
+	profile.Backend = BackendHTTP
+	profile.HTTPLogin = steps
+	profile.BodyTokens = []BodyTokenRule{{Name: "token", JSONPath: "$.data.token"}}
+	profile.ResponseHeaders = []string{"X-Refresh-Token"}
+	profile.Cookies = []string{"sid"}
+	return profile
+}
+
+func TestHTTPExtractorExtract(t *testing.T) {
+	server := newTestLoginServer(t)
+	profile := testHTTPProfile(t, server.URL+"/", strings.Join([]string{
+		"POST | /login | username={{username}}&password={{password}}",
+		"GET | /api/me",
+		"header | Authorization | Bearer {{token}}",
+	}, "\n"))
+
+	extractor := &HTTPExtractor{Transport: server.Client().Transport}
+	result, err := extractor.Extract(context.Background(), LoginRequest{
+		Username:  "alice",
+		Password:  testPassword,
+		TargetURL: server.URL + "/",
+		Profile:   profile,
+	})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	want := map[string]HeaderInfo{
+		"X-Refresh-Token": {Value: "refresh-1", Source: SourceResponseHeader},
+		"sid":             {Value: "cookie-1", Source: SourceCookie},
+		"token":           {Value: "token-1", Source: SourceBody},
+		"Authorization":   {Value: "Bearer token-1", Source: SourceHeader},
+	}
+	for _, header := range result.Headers {
+		expected, ok := want[header.Name]
+		if !ok {
+			continue
+		}
+		if header.Value != expected.Value || header.Source != expected.Source || !header.IsKey {
+			t.Errorf("%s = %+v, want value %q source %q as key", header.Name, header, expected.Value, expected.Source)
+		}
+		delete(want, header.Name)
+	}
+	for name := range want {
+		t.Errorf("missing token %s", name)
+	}
+
+	// 登录、重定向后的会话接口和用户接口
+	if len(result.Requests) != 3 {
+		t.Fatalf("captured %d requests, want 3", len(result.Requests))
+	}
+	if status := result.Requests[0].Status; status != http.StatusFound {
+		t.Errorf("login status = %d, want 302", status)
+	}
+	login := result.Requests[0].Body
+	if strings.Contains(login, url.QueryEscape(testPassword)) || !strings.Contains(login, "password="+passwordMask) {
+		t.Errorf("captured login body not redacted: %q", login)
+	}
+	if len(result.Steps) != 2 || !result.Steps[1].Success {
+		t.Errorf("steps = %+v", result.Steps)
+	}
+}
+
+func TestHTTPExtractorLoginFailed(t *testing.T) {
+	server := newTestLoginServer(t)
+	profile := testHTTPProfile(t, server.URL+"/", "POST | /login | username={{username}}&password={{password}}")
+
+	extractor := &HTTPExtractor{Transport: server.Client().Transport}
+	result, err := extractor.Extract(context.Background(), LoginRequest{
+		Username:  "alice",
+		Password:  "wrong",
+		TargetURL: server.URL + "/",
+		Profile:   profile,
+	})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want 401 login failure", err)
+	}
+	if result.Success || len(result.Steps) != 1 || result.Steps[0].Success {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestHTTPStepsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []HTTPStep
+	}{
+		{
+			name: "JSON登录",
+			text: `POST | /api/login | {"username":"{{username}}","password":"{{password}}"}`,
+			want: []HTTPStep{{Method: "POST", URL: "/api/login", Body: `{"username":"{{username}}","password":"{{password}}"}`}},
+		},
+		{
+			name: "请求头",
+			text: "GET | /api/me\nheader | Authorization | Bearer {{token}}\nheader | X-Client | web",
+			want: []HTTPStep{{Method: "GET", URL: "/api/me", Headers: map[string]string{"Authorization": "Bearer {{token}}", "X-Client": "web"}}},
+		},
+		{
+			name: "登录地址和多个请求",
+			text: "GET |\nPOST | /login | a=1|2",
+			want: []HTTPStep{{Method: "GET"}, {Method: "POST", URL: "/login", Body: "a=1|2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := ParseHTTPSteps(tt.text)
+			if err != nil {
+				t.Fatalf("ParseHTTPSteps: %v", err)
+			}
+			if !reflect.DeepEqual(steps, tt.want) {
+				t.Fatalf("ParseHTTPSteps = %+v, want %+v", steps, tt.want)
+			}
+			again, err := ParseHTTPSteps(FormatHTTPSteps(steps))
+			if err != nil || !reflect.DeepEqual(again, steps) {
+				t.Errorf("round trip = %+v (%v), want %+v", again, err, steps)
+			}
+		})
+	}
+}
+
+func TestParseHTTPStepsErrors(t *testing.T) {
+	for _, text := range []string{
+		"header | Authorization | x",
+		"FETCH | /login",
+		"GET | /\nheader | Bad Name | x",
+	} {
+		if _, err := ParseHTTPSteps(text); err == nil {
+			t.Errorf("ParseHTTPSteps(%q) succeeded, want error", text)
+		}
+	}
+}
+
+func TestExpandHTTPVariables(t *testing.T) {
+	vars := map[string]string{"username": "a@b.com", "password": `p&"w d`}
+	tests := []struct {
+		name   string
+		text   string
+		escape func(string) string
+		want   string
+	}{
+		{"表单", "u={{username}}&p={{password}}", url.QueryEscape, `u=a%40b.com&p=p%26%22w+d`},
+		{"JSON", `{"u":"{{username}}","p":"{{ password }}"}`, jsonEscape, `{"u":"a@b.com","p":"p&\"w d"}`},
+		{"请求头", "{{password}}", noEscape, `p&"w d`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandHTTPVariables(tt.text, vars, tt.escape)
+			if err != nil || got != tt.want {
+				t.Errorf("expandHTTPVariables = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := expandHTTPVariables("Bearer {{token}}", vars, noEscape); err == nil {
+		t.Error("missing variable succeeded, want error")
+	}
+}
+
+func TestRedactPassword(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"username=a&password=" + url.QueryEscape(testPassword), "username=a&password=" + passwordMask},
+		{`{"password":"` + jsonEscape(testPassword) + `"}`, `{"password":"` + passwordMask + `"}`},
+		{"no secret here", "no secret here"},
+	}
+	for _, tt := range tests {
+		if got := redactPassword(tt.body, testPassword); got != tt.want {
+			t.Errorf("redactPassword(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package token_extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 提取方式
+const (
+	BackendBrowser = "browser" // 在无头 Chrome 中执行登录脚本（默认）
+	BackendHTTP    = "http"    // 直接发送配置的HTTP登录请求，不需要浏览器
+)
+
+// httpHeaderAction HTTP登录请求文本中为上一个请求添加请求头的行
+const httpHeaderAction = "header"
+
+// httpMethods HTTP登录请求支持的方法
+var httpMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// httpVariable 请求中的变量，例如 {{username}}
+var httpVariable = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// HTTPStep HTTP登录中依次发送的一个请求
+type HTTPStep struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url,omitempty"`     // 请求地址，可以是相对登录地址的路径，为空时为登录地址
+	Body    string            `json:"body,omitempty"`    // 请求体，以 { 或 [ 开头时按JSON发送，否则按表单发送
+	Headers map[string]string `json:"headers,omitempty"` // 额外的请求头
+}
+
+// UsesHTTP 是否使用HTTP请求而不是浏览器登录
+func (p *SiteProfile) UsesHTTP() bool {
+	return p.Backend == BackendHTTP
+}
+
+// ValidateHTTPSteps 检查HTTP登录请求
+func ValidateHTTPSteps(steps []HTTPStep) error {
+	for i, step := range steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("HTTP登录第 %d 个请求无效: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s HTTPStep) validate() error {
+	valid := false
+	for _, method := range httpMethods {
+		if s.Method == method {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("不支持的请求方法 %q，可以使用 %s", s.Method, strings.Join(httpMethods, "、"))
+	}
+	if _, err := url.Parse(s.URL); err != nil {
+		return fmt.Errorf("无效的请求地址 %q", s.URL)
+	}
+	for name := range s.Headers {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " :") {
+			return fmt.Errorf("无效的请求头名称 %q", name)
+		}
+	}
+	return nil
+}
+
+// isJSON 请求体是否按JSON发送：设置了 Content-Type 时按其判断，否则看请求体是否以 { 或 [ 开头
+func (s HTTPStep) isJSON() bool {
+	for name, value := range s.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			return strings.Contains(strings.ToLower(value), "json")
+		}
+	}
+	body := strings.TrimSpace(s.Body)
+	return strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[")
+}
+
+// FormatHTTPSteps 把HTTP登录请求格式化为文本：每个请求一行 方法 | 地址 | 请求体，之后是它的请求头 header | 名称 | 值
+func FormatHTTPSteps(steps []HTTPStep) string {
+	var lines []string
+	for _, step := range steps {
+		line := step.Method + " " + scriptSeparator + " " + step.URL
+		if step.Body != "" {
+			line += " " + scriptSeparator + " " + step.Body
+		}
+		lines = append(lines, strings.TrimSpace(line))
+
+		names := make([]string, 0, len(step.Headers))
+		for name := range step.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("%s %s %s %s %s", httpHeaderAction, scriptSeparator, name, scriptSeparator, step.Headers[name]))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseHTTPSteps 解析HTTP登录请求文本，格式见 FormatHTTPSteps；方法不区分大小写，空行和 # 开头的行被忽略
+func ParseHTTPSteps(text string) ([]HTTPStep, error) {
+	var steps []HTTPStep
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, scriptSeparator, 3)
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		action := strings.TrimSpace(fields[0])
+		target := strings.TrimSpace(fields[1])
+		value := strings.TrimSpace(fields[2])
+
+		if strings.EqualFold(action, httpHeaderAction) {
+			if len(steps) == 0 {
+				return nil, fmt.Errorf("HTTP登录第 %d 行无效: 请求头需要写在请求的下一行", number+1)
+			}
+			step := &steps[len(steps)-1]
+			if step.Headers == nil {
+				step.Headers = make(map[string]string)
+			}
+			step.Headers[target] = value
+		} else {
+			steps = append(steps, HTTPStep{Method: strings.ToUpper(action), URL: target, Body: value})
+		}
+
+		if err := steps[len(steps)-1].validate(); err != nil {
+			return nil, fmt.Errorf("HTTP登录第 %d 行无效: %w", number+1, err)
+		}
+	}
+	return steps, nil
+}
+
+// expandHTTPVariables 替换文本中的 {{名称}}，值按 escape 转义；使用了不存在的变量时返回错误
+func expandHTTPVariables(text string, vars map[string]string, escape func(string) string) (string, error) {
+	var missing []string
+	expanded := httpVariable.ReplaceAllStringFunc(text, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-2])
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, match)
+			return match
+		}
+		return escape(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("变量 %s 没有值，只能使用 {{username}}、{{password}}、{{url}} 和之前的请求中提取到的Token", strings.Join(missing, "、"))
+	}
+	return expanded, nil
+}
+
+// jsonEscape 转义JSON字符串中的内容（不含两边的引号），不转义 HTML 字符，与请求体中的原文一致
+func jsonEscape(value string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	data := bytes.TrimSpace(buf.Bytes())
+	return string(data[1 : len(data)-1])
+}
+
+// noEscape 不转义，用于请求头
+func noEscape(value string) string {
+	return value
+}
+
+// newHTTPRequest 按登录请求的配置和变量创建请求：地址中的变量按查询参数转义，
+// 请求体中的变量按JSON或表单转义
+func (s HTTPStep) newHTTPRequest(base *url.URL, vars map[string]string) (*http.Request, error) {
+	rawURL, err := expandHTTPVariables(s.URL, vars, url.QueryEscape)
+	if err != nil {
+		return nil, err
+	}
+	target, err := base.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的请求地址 %q", rawURL)
+	}
+
+	escape := url.QueryEscape
+	if s.isJSON() {
+		escape = jsonEscape
+	}
+	body, err := expandHTTPVariables(s.Body, vars, escape)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(s.Method, target.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == "" {
+		request.Body, request.GetBody, request.ContentLength = http.NoBody, nil, 0
+	} else if s.isJSON() {
+		request.Header.Set("Content-Type", "application/json")
+	} else {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	for name, value := range s.Headers {
+		if value, err = expandHTTPVariables(value, vars, noEscape); err != nil {
+			return nil, err
+		}
+		request.Header.Set(name, value)
+	}
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", "application/json, text/plain, */*")
+	}
+	return request, nil
+}
+
+// errHTTPStatus 返回请求失败的状态码和响应体开头，响应体中通常有失败原因
+func errHTTPStatus(resp *http.Response, body []byte) error {
+	text := strings.TrimSpace(string(body))
+	if runes := []rune(text); len(runes) > 200 {
+		text = string(runes[:200]) + "..."
+	}
+	if text == "" {
+		return errors.New("HTTP " + resp.Status)
+	}
+	return errors.New("HTTP " + resp.Status + ": " + text)
+}
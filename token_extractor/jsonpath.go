@@ -0,0 +1,118 @@
+package token_extractor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep JSON路径中的一级：对象字段或数组下标
+type jsonPathStep struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// parseJSONPath 解析简单的JSON路径，例如 $.data.token、$.items[0].value、$["access-token"]
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, errors.New("JSON路径必须以 $ 开头")
+	}
+
+	var steps []jsonPathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSON路径 %q 中缺少字段名", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[:end], isKey: true})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSON路径 %q 中缺少 ]", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1], isKey: true})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("JSON路径 %q 中的下标 %q 无效", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: index})
+
+		default:
+			return nil, fmt.Errorf("JSON路径 %q 格式无效", path)
+		}
+	}
+	return steps, nil
+}
+
+// decodeJSONDocument 解析响应体JSON，无效时返回 nil。
+// 数字保留为 json.Number，避免较长的数字ID在转换为 float64 时丢失精度
+func decodeJSONDocument(body string) any {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil
+	}
+	return doc
+}
+
+// lookupJSONPath 按路径取出JSON中的值，字符串原样返回，数字按原文返回，其他类型返回JSON文本
+func lookupJSONPath(doc any, steps []jsonPathStep) (string, bool) {
+	value := doc
+	for _, step := range steps {
+		if step.isKey {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return "", false
+			}
+			if value, ok = object[step.key]; !ok {
+				return "", false
+			}
+			continue
+		}
+
+		array, ok := value.([]any)
+		if !ok || step.index >= len(array) {
+			return "", false
+		}
+		value = array[step.index]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
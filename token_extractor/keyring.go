@@ -0,0 +1,186 @@
+package token_extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	ErrSecretNotFound     = errors.New("未找到保存的密码")
+	ErrKeyringUnavailable = errors.New("系统钥匙串不可用")
+)
+
+// SecretStore 保存账号密码的后端（系统钥匙串或加密文件）
+type SecretStore interface {
+	// Name 后端名称，用于界面显示
+	Name() string
+
+	// Get 读取密码，不存在时返回 ErrSecretNotFound
+	Get(key string) (string, error)
+
+	// Set 保存或覆盖密码
+	Set(key, secret string) error
+
+	// Delete 删除密码，不存在时不返回错误
+	Delete(key string) error
+}
+
+// keyringService 保存在系统钥匙串中的服务名称
+const keyringService = "my_portfolio.token_extractor"
+
+// keyringProbeKey 检查钥匙串是否可用时查询的条目，不会被保存
+const keyringProbeKey = "my_portfolio.probe"
+
+// 钥匙串命令在条目不存在时的退出状态
+const (
+	securityNotFound   = 44 // security: errSecItemNotFound
+	secretToolNotFound = 1  // secret-tool lookup: 没有匹配的条目（不输出错误信息）
+)
+
+// NewSystemKeyring 返回当前系统的钥匙串，没有可用的钥匙串时返回 ErrKeyringUnavailable。
+// macOS 使用 security 命令（登录钥匙串），Linux 等系统使用 libsecret 的 secret-tool 命令。
+// 命令存在但钥匙串服务不可用（例如没有运行 Secret Service）时同样视为不可用。
+var NewSystemKeyring = func() (SecretStore, error) {
+	tool := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		tool = "security"
+	}
+	if runtime.GOOS == "windows" {
+		return nil, ErrKeyringUnavailable
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, ErrKeyringUnavailable
+	}
+
+	keyring := &commandKeyring{tool: tool, service: keyringService}
+	if _, err := keyring.Get(keyringProbeKey); err != nil && err != ErrSecretNotFound {
+		return nil, fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+	return keyring, nil
+}
+
+// commandKeyring 通过系统命令读写钥匙串，密码通过标准输入传递，不出现在命令行参数中
+type commandKeyring struct {
+	tool    string
+	service string
+}
+
+// Name 后端名称
+func (k *commandKeyring) Name() string {
+	if k.tool == "security" {
+		return "macOS 钥匙串"
+	}
+	return "系统钥匙串"
+}
+
+// Get 读取密码
+func (k *commandKeyring) Get(key string) (string, error) {
+	var out []byte
+	var err error
+	if k.tool == "security" {
+		out, err = k.run(nil, "find-generic-password", "-s", k.service, "-a", key, "-w")
+	} else {
+		out, err = k.run(nil, "lookup", "service", k.service, "account", key)
+	}
+
+	if k.notFound(err) || err == nil && len(out) == 0 {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Set 保存或覆盖密码
+func (k *commandKeyring) Set(key, secret string) error {
+	if k.tool == "security" {
+		// 交互模式从标准输入读取命令，避免密码出现在进程列表中
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(k.service), securityQuote(key), securityQuote(secret))
+		var stderr bytes.Buffer
+		cmd := exec.Command(k.tool, "-i")
+		cmd.Stdin = strings.NewReader(command)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		// 交互模式下命令失败时退出状态仍可能为 0，只能从错误输出判断
+		if stderr.Len() > 0 {
+			return errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+
+	_, err := k.run([]byte(secret), "store", "--label=Token提取器: "+key, "service", k.service, "account", key)
+	return err
+}
+
+// Delete 删除密码
+func (k *commandKeyring) Delete(key string) error {
+	var err error
+	if k.tool == "security" {
+		_, err = k.run(nil, "delete-generic-password", "-s", k.service, "-a", key)
+	} else {
+		_, err = k.run(nil, "clear", "service", k.service, "account", key)
+	}
+
+	if k.notFound(err) {
+		return nil
+	}
+	return err
+}
+
+// notFound 命令是否因为条目不存在而失败；其他非零状态（例如钥匙串被锁定或服务不可用）不算
+func (k *commandKeyring) notFound(err error) bool {
+	var cmdErr *commandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	if k.tool == "security" {
+		return cmdErr.code == securityNotFound
+	}
+	// secret-tool 在服务不可用时也以 1 退出，但会输出原因
+	return cmdErr.code == secretToolNotFound && cmdErr.stderr == ""
+}
+
+// commandError 钥匙串命令以非零状态退出
+type commandError struct {
+	code   int
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return fmt.Sprintf("exit status %d: %s", e.code, e.stderr)
+}
+
+// run 执行钥匙串命令，以非零状态退出时返回 *commandError，其中包含命令的错误输出
+func (k *commandKeyring) run(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command(k.tool, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out, &commandError{code: exitErr.ExitCode(), stderr: strings.TrimSpace(stderr.String())}
+	}
+	return out, err
+}
+
+// securityQuote 为 security 交互模式的参数加上双引号
+func securityQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
@@ -3,7 +3,11 @@ package token_extractor
 import (
 	"errors"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // LoginRequest 登录请求
@@ -11,6 +15,11 @@ type LoginRequest struct {
 	Username  string
 	Password  string
 	TargetURL string
+	Profile   *SiteProfile // 站点配置，为空时使用内置配置
+
+	// OnProgress 提取过程中的进度事件（启动浏览器、每个登录步骤、捕获到请求等），可以为空。
+	// 可能在浏览器事件的goroutine中调用，不能阻塞
+	OnProgress func(ProgressEvent)
 }
 
 // Validate 验证登录请求
@@ -46,11 +55,84 @@ func (r *LoginRequest) Validate() error {
 	return nil
 }
 
-// HeaderInfo HTTP头部信息
+// Token的来源
+const (
+	SourceHeader         = "header"          // 请求头
+	SourceResponseHeader = "response_header" // 响应头
+	SourceCookie         = "cookie"          // 登录后的Cookie
+	SourceBody           = "body"            // 响应体
+	SourceLocalStorage   = "local_storage"   // 登录后页面的 localStorage
+	SourceSessionStorage = "session_storage" // 登录后页面的 sessionStorage
+)
+
+// SourceLabel 返回来源的显示名称
+func SourceLabel(source string) string {
+	switch source {
+	case SourceResponseHeader:
+		return "响应头"
+	case SourceCookie:
+		return "Cookie"
+	case SourceBody:
+		return "响应体"
+	case SourceLocalStorage:
+		return "localStorage"
+	case SourceSessionStorage:
+		return "sessionStorage"
+	}
+	return "请求头"
+}
+
+// HeaderInfo 提取到的一项Token：请求头，或响应头、Cookie、响应体和页面存储中的值，以 Source 区分
 type HeaderInfo struct {
-	Name  string
-	Value string
-	IsKey bool // 是否为关键头部（如token）
+	Name   string
+	Value  string
+	IsKey  bool   // 是否为关键头部（如token）
+	Source string // 来源，为空时为请求头
+
+	ExpiresAt time.Time // 过期时间，零值表示未知
+}
+
+// CapturedRequest 捕获到的与站点配置匹配的请求和响应，可以导出为 curl 等命令重新发送或导出为 HAR
+type CapturedRequest struct {
+	Method    string
+	URL       string
+	Headers   map[string]string
+	Body      string    // 请求体（POST 等），其中的密码替换为 passwordMask；过长时浏览器不提供
+	Type      string    // 资源类型，例如 Document、XHR、Fetch
+	StartedAt time.Time // 发出请求的时间
+
+	// 响应，没有收到响应时为零值
+	Status          int
+	StatusText      string
+	Protocol        string // 例如 h2、http/1.1
+	MimeType        string
+	ResponseHeaders map[string]string
+	Size            int64 // 接收的字节数（压缩后）
+
+	Wait     time.Duration // 从发送完请求到收到响应头
+	Duration time.Duration // 从发出请求到加载完成（或失败），未完成时为 0
+	Error    string        // 加载失败的原因
+}
+
+// passwordMask 记录的请求体中代替密码的文本
+const passwordMask = "******"
+
+// redactPassword 把请求体中的密码（原文以及URL编码、JSON转义后的形式）替换为 passwordMask。
+// 记录的请求会显示在请求记录中，并导出为 HAR、curl 等
+func redactPassword(body, password string) string {
+	if password == "" || body == "" {
+		return body
+	}
+
+	forms := []string{password, url.QueryEscape(password), url.PathEscape(password), jsonEscape(password)}
+	sort.Slice(forms, func(i, j int) bool {
+		return len(forms[i]) > len(forms[j])
+	})
+	var pairs []string
+	for _, form := range forms {
+		pairs = append(pairs, form, passwordMask)
+	}
+	return strings.NewReplacer(pairs...).Replace(body)
 }
 
 // ExtractResult 提取结果
@@ -59,6 +141,8 @@ type ExtractResult struct {
 	Timestamp time.Time
 	Headers   []HeaderInfo
 	Error     string
+	Steps     []StepLog         // 登录脚本的执行情况
+	Requests  []CapturedRequest // 匹配的请求，按发出顺序，最多保留最近的 maxCapturedRequests 个；失败时也会记录
 }
 
 // HistoryRecord 历史记录
@@ -70,21 +154,34 @@ type HistoryRecord struct {
 	KeyHeaders map[string]string // 仅保存关键头部
 }
 
-// IsKeyHeader 判断是否为关键头部
-func IsKeyHeader(name string) bool {
-	keyHeaders := []string{
-		"X-Auth-Token",
-		"X-Auth-Ts",
-		"Gtoken",
-		"Authorization",
-		"x-auth-token",
-		"x-auth-ts",
-		"gtoken",
-		"authorization",
+// NewHistoryRecord 根据提取结果创建历史记录，关键头部只保存脱敏后的值
+func NewHistoryRecord(username string, result *ExtractResult) HistoryRecord {
+	// 提取关键头部
+	keyHeaders := make(map[string]string)
+	for _, header := range result.Headers {
+		if header.IsKey {
+			// 脱敏处理：只保存前后几位
+			value := header.Value
+			if len(value) > 20 {
+				value = value[:8] + "..." + value[len(value)-8:]
+			}
+			keyHeaders[header.Name] = value
+		}
+	}
+
+	return HistoryRecord{
+		ID:         uuid.New().String(), // 批量提取时多个账号可能在同一秒内完成
+		Timestamp:  result.Timestamp,
+		Username:   username,
+		Success:    result.Success,
+		KeyHeaders: keyHeaders,
 	}
+}
 
-	for _, key := range keyHeaders {
-		if name == key {
+// IsKeyHeader 判断是否为默认的关键头部（不区分大小写），站点配置中的关键头部见 SiteProfile.IsKeyHeader
+func IsKeyHeader(name string) bool {
+	for _, key := range DefaultKeyHeaders {
+		if strings.EqualFold(name, key) {
 			return true
 		}
 	}
@@ -0,0 +1,373 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 正则表达式形式的URL匹配规则前缀，其他规则按通配符（* 和 ?）匹配整个URL
+const regexPrefix = "re:"
+
+// DefaultKeyHeaders 默认视为关键头部的名称
+var DefaultKeyHeaders = []string{
+	"X-Auth-Token",
+	"X-Auth-Ts",
+	"Gtoken",
+	"Authorization",
+}
+
+// BodyTokenRule 从响应体中提取Token的规则，正则表达式和JSON路径二选一
+type BodyTokenRule struct {
+	Name     string `json:"name"`                // 结果中显示的名称
+	Pattern  string `json:"pattern,omitempty"`   // 正则表达式，第一个捕获组（没有捕获组时为整个匹配）作为Token的值
+	JSONPath string `json:"json_path,omitempty"` // JSON路径，例如 $.data.token
+}
+
+// SiteProfile 站点配置：登录地址、要捕获的请求以及哪些头部、Cookie和响应内容算作关键Token
+type SiteProfile struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	TargetURL   string          `json:"target_url"`
+	URLPatterns []string        `json:"url_patterns"`           // 捕获哪些请求，为空时捕获与登录地址同一主机的请求
+	KeyHeaders  []string        `json:"key_headers"`            // 关键头部名称（不区分大小写）
+	Cookies     []string        `json:"cookies,omitempty"`      // 登录后要提取的Cookie名称，"*" 表示全部
+	BodyTokens  []BodyTokenRule `json:"body_tokens,omitempty"`  // 从匹配请求的响应体中提取Token的规则
+	LoginScript []LoginStep     `json:"login_script,omitempty"` // 登录脚本，为空时使用内置脚本
+
+	ResponseHeaders []string `json:"response_headers,omitempty"` // 要提取的匹配请求的响应头（不区分大小写），"*" 表示全部
+	LocalStorage    []string `json:"local_storage,omitempty"`    // 登录后要提取的 localStorage 键，"*" 表示全部
+	SessionStorage  []string `json:"session_storage,omitempty"`  // 登录后要提取的 sessionStorage 键，"*" 表示全部
+
+	TimestampHeaders []string `json:"timestamp_headers,omitempty"` // 值为Unix时间戳的头部，用于计算过期时间
+	TokenLifetime    string   `json:"token_lifetime,omitempty"`    // Token有效期（如 24h），无法从Token本身得知过期时间时使用
+
+	Timeout string `json:"timeout,omitempty"` // 整个提取的超时时间（如 2m），为空时为 DefaultExtractTimeout
+
+	Backend   string     `json:"backend,omitempty"`    // 提取方式：BackendBrowser（默认）或 BackendHTTP
+	HTTPLogin []HTTPStep `json:"http_login,omitempty"` // HTTP方式依次发送的登录请求
+
+	patterns  []*regexp.Regexp
+	bodyRes   []*regexp.Regexp // 与 BodyTokens 一一对应，JSON路径规则为 nil
+	bodyPaths [][]jsonPathStep // 与 BodyTokens 一一对应，正则表达式规则为 nil
+}
+
+// ProfileSet 所有站点配置和当前选中的配置
+type ProfileSet struct {
+	Profiles []SiteProfile `json:"profiles"`
+	Selected string        `json:"selected,omitempty"` // 选中的配置ID
+
+	AutoRefresh    bool   `json:"auto_refresh,omitempty"`    // 是否在Token过期前自动重新提取
+	CustomTemplate string `json:"custom_template,omitempty"` // 导出使用的自定义模板，为空时使用 DefaultExportTemplate
+
+	Accounts []SavedAccount `json:"accounts,omitempty"` // 保存的账号（不含密码）
+}
+
+// NewSiteProfile 创建新的站点配置，使用默认的关键头部
+func NewSiteProfile(name, targetURL string) *SiteProfile {
+	return &SiteProfile{
+		ID:         uuid.New().String(),
+		Name:       name,
+		TargetURL:  targetURL,
+		KeyHeaders: append([]string(nil), DefaultKeyHeaders...),
+	}
+}
+
+// DefaultProfile 返回内置的 Anker Solix 专业版站点配置
+func DefaultProfile() *SiteProfile {
+	profile := NewSiteProfile("Anker Solix 专业版", "https://ankersolix-professional-ci.anker.com/home/systemlist")
+	profile.ID = "anker-solix-professional"
+	profile.URLPatterns = []string{"*ankersolix-professional-ci.anker.com*"}
+	profile.TimestampHeaders = []string{"X-Auth-Ts"}
+	return profile
+}
+
+// DefaultProfileSet 返回只包含内置配置的配置集合
+func DefaultProfileSet() *ProfileSet {
+	profile := DefaultProfile()
+	return &ProfileSet{
+		Profiles: []SiteProfile{*profile},
+		Selected: profile.ID,
+	}
+}
+
+// Validate 校验配置并编译匹配规则
+func (p *SiteProfile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("配置名称不能为空")
+	}
+	if p.TargetURL != "" {
+		parsed, err := url.Parse(p.TargetURL)
+		if err != nil || parsed.Host == "" {
+			return errors.New("无效的登录地址")
+		}
+	}
+
+	if p.TokenLifetime != "" {
+		if d, err := time.ParseDuration(p.TokenLifetime); err != nil || d <= 0 {
+			return fmt.Errorf("无效的Token有效期 %q，例如 30m 或 24h", p.TokenLifetime)
+		}
+	}
+	if p.Timeout != "" {
+		if d, err := time.ParseDuration(p.Timeout); err != nil || d < 10*time.Second || d > maxExtractTimeout {
+			return fmt.Errorf("无效的超时时间 %q，需要在 10s 到 %s 之间，例如 90s 或 3m", p.Timeout, maxExtractTimeout)
+		}
+	}
+
+	// 重新分配，避免与配置的副本共用底层数组
+	p.patterns = nil
+	for _, pattern := range p.URLPatterns {
+		re, err := compileURLPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("URL匹配规则 %q 无效: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+
+	p.bodyRes, p.bodyPaths = nil, nil
+	for _, rule := range p.BodyTokens {
+		if strings.TrimSpace(rule.Name) == "" {
+			return errors.New("响应体Token规则的名称不能为空")
+		}
+		if (rule.Pattern == "") == (rule.JSONPath == "") {
+			return fmt.Errorf("响应体Token规则 %q 需要指定正则表达式或JSON路径之一", rule.Name)
+		}
+
+		var re *regexp.Regexp
+		var path []jsonPathStep
+		var err error
+		if rule.JSONPath != "" {
+			path, err = parseJSONPath(rule.JSONPath)
+		} else {
+			re, err = regexp.Compile(rule.Pattern)
+		}
+		if err != nil {
+			return fmt.Errorf("响应体Token规则 %q 无效: %w", rule.Name, err)
+		}
+		p.bodyRes = append(p.bodyRes, re)
+		p.bodyPaths = append(p.bodyPaths, path)
+	}
+	switch p.Backend {
+	case "", BackendBrowser:
+	case BackendHTTP:
+		if len(p.HTTPLogin) == 0 {
+			return errors.New("使用HTTP请求登录时需要配置登录请求")
+		}
+	default:
+		return fmt.Errorf("未知的提取方式 %q", p.Backend)
+	}
+	if err := ValidateHTTPSteps(p.HTTPLogin); err != nil {
+		return err
+	}
+	return ValidateScript(p.LoginScript)
+}
+
+// Script 返回要执行的登录脚本，未配置时为内置脚本
+func (p *SiteProfile) Script() []LoginStep {
+	if len(p.LoginScript) == 0 {
+		return DefaultLoginScript()
+	}
+	return p.LoginScript
+}
+
+// compileURLPattern 编译URL匹配规则："re:" 开头为正则表达式（部分匹配），否则为匹配整个URL的通配符
+func compileURLPattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		return regexp.Compile(expr)
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// compiled 确保匹配规则已编译；配置无效时返回错误
+func (p *SiteProfile) compiled() error {
+	if len(p.patterns) == len(p.URLPatterns) && len(p.bodyRes) == len(p.BodyTokens) && len(p.bodyPaths) == len(p.BodyTokens) {
+		return nil
+	}
+	return p.Validate()
+}
+
+// MatchURL 判断请求是否需要捕获
+func (p *SiteProfile) MatchURL(rawURL string) bool {
+	if p.compiled() != nil {
+		return false
+	}
+
+	if len(p.patterns) == 0 {
+		target, err := url.Parse(p.TargetURL)
+		if err != nil || target.Host == "" {
+			return false
+		}
+		request, err := url.Parse(rawURL)
+		return err == nil && strings.EqualFold(request.Host, target.Host)
+	}
+
+	for _, re := range p.patterns {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKeyHeader 判断头部是否为该站点的关键头部（不区分大小写）
+func (p *SiteProfile) IsKeyHeader(name string) bool {
+	for _, key := range p.KeyHeaders {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// WantsCookie 判断是否需要提取该Cookie
+func (p *SiteProfile) WantsCookie(name string) bool {
+	return wantsName(p.Cookies, name, false)
+}
+
+// WantsResponseHeader 判断是否需要提取该响应头（不区分大小写）
+func (p *SiteProfile) WantsResponseHeader(name string) bool {
+	return wantsName(p.ResponseHeaders, name, true)
+}
+
+// WantsStorageKey 判断是否需要提取 localStorage（session 为 false）或 sessionStorage 中的键
+func (p *SiteProfile) WantsStorageKey(session bool, key string) bool {
+	if session {
+		return wantsName(p.SessionStorage, key, false)
+	}
+	return wantsName(p.LocalStorage, key, false)
+}
+
+// wantsName 判断名称是否在列表中，"*" 匹配所有名称
+func wantsName(names []string, name string, fold bool) bool {
+	for _, want := range names {
+		if want == "*" || want == name || fold && strings.EqualFold(want, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsResponses 是否需要记录匹配请求的响应（响应头或响应体）
+func (p *SiteProfile) NeedsResponses() bool {
+	return len(p.BodyTokens) > 0 || len(p.ResponseHeaders) > 0
+}
+
+// ExtractBodyTokens 按规则从响应体中提取Token（规则名称 -> 值），每条规则只取第一个匹配
+func (p *SiteProfile) ExtractBodyTokens(body string) map[string]string {
+	tokens := make(map[string]string)
+	if p.compiled() != nil {
+		return tokens
+	}
+
+	// 响应体只在有JSON路径规则时解析一次，不是JSON时跳过这些规则
+	var doc any
+	parsed := false
+	for i, rule := range p.BodyTokens {
+		if re := p.bodyRes[i]; re != nil {
+			match := re.FindStringSubmatch(body)
+			if match == nil {
+				continue
+			}
+			value := match[0]
+			if len(match) > 1 {
+				value = match[1]
+			}
+			tokens[rule.Name] = value
+			continue
+		}
+
+		if !parsed {
+			parsed = true
+			doc = decodeJSONDocument(body)
+		}
+		if value, ok := lookupJSONPath(doc, p.bodyPaths[i]); ok {
+			tokens[rule.Name] = value
+		}
+	}
+	return tokens
+}
+
+// Find 按ID查找配置，返回副本
+func (s *ProfileSet) Find(id string) *SiteProfile {
+	for _, profile := range s.Profiles {
+		if profile.ID == id {
+			return &profile
+		}
+	}
+	return nil
+}
+
+// FindByName 按名称查找配置（不区分大小写），返回副本
+func (s *ProfileSet) FindByName(name string) *SiteProfile {
+	for _, profile := range s.Profiles {
+		if strings.EqualFold(profile.Name, name) {
+			return &profile
+		}
+	}
+	return nil
+}
+
+// Current 返回选中的配置，没有选中时返回第一个配置，没有任何配置时返回内置配置
+func (s *ProfileSet) Current() *SiteProfile {
+	if profile := s.Find(s.Selected); profile != nil {
+		return profile
+	}
+	if len(s.Profiles) > 0 {
+		profile := s.Profiles[0]
+		return &profile
+	}
+	return DefaultProfile()
+}
+
+// Put 添加或替换配置（按ID）
+func (s *ProfileSet) Put(profile SiteProfile) {
+	for i := range s.Profiles {
+		if s.Profiles[i].ID == profile.ID {
+			s.Profiles[i] = profile
+			return
+		}
+	}
+	s.Profiles = append(s.Profiles, profile)
+}
+
+// Remove 删除配置及其保存的账号记录，删除的是选中的配置时清空选择
+func (s *ProfileSet) Remove(id string) {
+	profiles := s.Profiles[:0]
+	for _, profile := range s.Profiles {
+		if profile.ID != id {
+			profiles = append(profiles, profile)
+		}
+	}
+	s.Profiles = profiles
+
+	accounts := s.Accounts[:0]
+	for _, account := range s.Accounts {
+		if account.ProfileID != id {
+			accounts = append(accounts, account)
+		}
+	}
+	s.Accounts = accounts
+
+	if s.Selected == id {
+		s.Selected = ""
+	}
+}
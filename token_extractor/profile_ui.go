@@ -0,0 +1,323 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+)
+
+// loadProfiles 加载站点配置；失败时使用内置配置，并在修复前不保存，避免覆盖原文件
+func (ui *TokenExtractorUI) loadProfiles() {
+	ui.profiles, ui.profilesErr = ui.profileStorage.Load()
+	if ui.profilesErr != nil {
+		dialog.ShowError(errors.New("加载站点配置失败，本次使用内置配置: "+ui.profilesErr.Error()), ui.window)
+	}
+}
+
+// saveProfiles 保存站点配置；文件在读取后被其他程序修改时询问覆盖还是重新加载
+func (ui *TokenExtractorUI) saveProfiles() error {
+	if ui.profilesErr != nil {
+		return errors.New("站点配置加载失败，修复配置文件之前不会保存: " + ui.profilesErr.Error())
+	}
+
+	err := ui.profileStorage.Save(ui.profiles)
+	if errors.Is(err, file_watch.ErrChanged) {
+		ui.confirmOverwriteProfiles()
+		return nil
+	}
+	return err
+}
+
+// currentProfile 返回当前选中的站点配置
+func (ui *TokenExtractorUI) currentProfile() *SiteProfile {
+	return ui.profiles.Current()
+}
+
+// createProfileBar 创建站点配置选择栏
+func (ui *TokenExtractorUI) createProfileBar() fyne.CanvasObject {
+	ui.profileSelect = widget.NewSelect(nil, func(name string) {
+		profile := ui.profiles.FindByName(name)
+		if profile == nil || profile.ID == ui.profiles.Selected {
+			return
+		}
+		ui.profiles.Selected = profile.ID
+		ui.urlEntry.SetText(profile.TargetURL)
+		ui.refreshAccounts()
+		if err := ui.saveProfiles(); err != nil {
+			ui.statusLabel.SetText("⚠️ " + err.Error())
+		}
+	})
+	ui.refreshProfileSelect()
+
+	addButton := widget.NewButton("新建", func() {
+		ui.showProfileEditor(NewSiteProfile("", ui.urlEntry.Text))
+	})
+	editButton := widget.NewButton("编辑", func() {
+		ui.showProfileEditor(ui.currentProfile())
+	})
+	deleteButton := widget.NewButton("删除", func() {
+		ui.deleteProfile(ui.currentProfile())
+	})
+
+	return container.NewBorder(nil, nil, widget.NewLabel("站点:"),
+		container.NewHBox(addButton, editButton, deleteButton), ui.profileSelect)
+}
+
+// refreshProfileSelect 刷新配置下拉框并选中当前配置
+func (ui *TokenExtractorUI) refreshProfileSelect() {
+	names := make([]string, len(ui.profiles.Profiles))
+	for i, profile := range ui.profiles.Profiles {
+		names[i] = profile.Name
+	}
+	ui.profileSelect.Options = names
+	ui.profileSelect.SetSelected(ui.currentProfile().Name)
+	ui.profileSelect.Refresh()
+}
+
+// showProfileEditor 显示站点配置编辑对话框
+func (ui *TokenExtractorUI) showProfileEditor(profile *SiteProfile) {
+	isNew := ui.profiles.Find(profile.ID) == nil
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(profile.Name)
+	nameEntry.SetPlaceHolder("例如：公司后台")
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(profile.TargetURL)
+	urlEntry.SetPlaceHolder("https://example.com/login")
+
+	patternsEntry := newLinesEntry(profile.URLPatterns, "*example.com/api/*\nre:^https://api\\.example\\.com/")
+	keyHeadersEntry := newLinesEntry(profile.KeyHeaders, "Authorization")
+	cookiesEntry := newLinesEntry(profile.Cookies, "sessionid")
+	responseHeadersEntry := newLinesEntry(profile.ResponseHeaders, "X-Refresh-Token")
+	localStorageEntry := newLinesEntry(profile.LocalStorage, "access_token")
+	sessionStorageEntry := newLinesEntry(profile.SessionStorage, "*")
+
+	bodyRules := make([]string, len(profile.BodyTokens))
+	for i, rule := range profile.BodyTokens {
+		if rule.JSONPath != "" {
+			bodyRules[i] = rule.Name + "=" + rule.JSONPath
+		} else {
+			bodyRules[i] = rule.Name + "=" + rule.Pattern
+		}
+	}
+	bodyEntry := newLinesEntry(bodyRules, "access_token=$.data.access_token\nrefresh=\"refresh\":\"([^\"]+)\"")
+
+	timestampEntry := newLinesEntry(profile.TimestampHeaders, "X-Auth-Ts")
+	lifetimeEntry := widget.NewEntry()
+	lifetimeEntry.SetText(profile.TokenLifetime)
+	lifetimeEntry.SetPlaceHolder("例如 30m 或 24h")
+
+	timeoutEntry := widget.NewEntry()
+	timeoutEntry.SetText(profile.Timeout)
+	timeoutEntry.SetPlaceHolder(fmt.Sprintf("默认 %s", DefaultExtractTimeout))
+
+	scriptEntry := widget.NewMultiLineEntry()
+	scriptEntry.SetPlaceHolder(FormatScript(DefaultLoginScript()))
+	scriptEntry.SetText(FormatScript(profile.LoginScript))
+	scriptEntry.SetMinRowsVisible(6)
+	scriptEntry.TextStyle = fyne.TextStyle{Monospace: true}
+
+	// 提取方式，与 backendNames 一一对应
+	backends := []string{BackendBrowser, BackendHTTP}
+	backendNames := []string{"浏览器（Chrome）", "HTTP请求（不需要浏览器）"}
+	backendSelect := widget.NewSelect(backendNames, nil)
+	backendSelect.SetSelectedIndex(0)
+	if profile.UsesHTTP() {
+		backendSelect.SetSelectedIndex(1)
+	}
+
+	httpEntry := widget.NewMultiLineEntry()
+	httpEntry.SetPlaceHolder("POST | /api/login | {\"username\":\"{{username}}\",\"password\":\"{{password}}\"}\n" +
+		"GET | /api/user/info\nheader | Authorization | Bearer {{access_token}}")
+	httpEntry.SetText(FormatHTTPSteps(profile.HTTPLogin))
+	httpEntry.SetMinRowsVisible(4)
+	httpEntry.TextStyle = fyne.TextStyle{Monospace: true}
+
+	hint := func(text string) *widget.FormItem {
+		label := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		label.Wrapping = fyne.TextWrapWord
+		return &widget.FormItem{Widget: label}
+	}
+
+	items := []*widget.FormItem{
+		{Text: "名称", Widget: nameEntry},
+		{Text: "登录地址", Widget: urlEntry},
+		{Text: "捕获请求", Widget: patternsEntry},
+		hint("每行一条，* 和 ? 为通配符；以 re: 开头为正则表达式；留空时捕获登录地址所在主机的请求"),
+		{Text: "关键头部", Widget: keyHeadersEntry},
+		hint("每行一个头部名称，不区分大小写"),
+		{Text: "响应头", Widget: responseHeadersEntry},
+		hint("从捕获的请求的响应中提取的头部名称，每行一个，* 表示全部"),
+		{Text: "响应体Token", Widget: bodyEntry},
+		hint("每行一条：名称=JSON路径（以 $ 开头，如 $.data.token、$.items[0].id）或 名称=正则表达式（取第一个捕获组）"),
+		{Text: "Cookie", Widget: cookiesEntry},
+		hint("登录后提取的Cookie名称，每行一个，* 表示全部"),
+		{Text: "localStorage", Widget: localStorageEntry},
+		{Text: "sessionStorage", Widget: sessionStorageEntry},
+		hint("登录后从页面存储中提取的键，每行一个，* 表示全部（HTTP请求方式不支持）"),
+		{Text: "时间戳头部", Widget: timestampEntry},
+		hint("值为Unix时间戳的头部：时间在将来时作为过期时间，在过去时作为签发时间加上Token有效期"),
+		{Text: "Token有效期", Widget: lifetimeEntry},
+		hint("JWT 会自动读取过期时间；其他关键Token按提取时间加上有效期计算，留空表示未知"),
+		{Text: "超时时间", Widget: timeoutEntry},
+		hint("整个提取（启动浏览器、登录和读取Token）的最长时间，例如 90s 或 3m"),
+		{Text: "提取方式", Widget: backendSelect},
+		{Text: "登录脚本", Widget: scriptEntry},
+		hint("浏览器方式使用。每行一步：动作 | 目标 | 值。动作有 navigate、wait_for、fill、click、select_frame、wait_for_request、sleep；" +
+			"填写时可以使用 {{username}} 和 {{password}}；留空时使用内置脚本"),
+		{Text: "HTTP登录请求", Widget: httpEntry},
+		hint("HTTP请求方式使用，依次发送并自动跟随重定向、保存Cookie。每个请求一行：方法 | 地址 | 请求体，地址可以是相对登录地址的路径；" +
+			"之后的 header | 名称 | 值 行为它添加请求头。请求体以 { 开头时按JSON发送，否则按表单发送；" +
+			"可以使用 {{username}}、{{password}} 和之前的请求中提取到的响应头或响应体Token，例如 {{access_token}}"),
+	}
+
+	title := "编辑站点配置"
+	if isNew {
+		title = "新建站点配置"
+	}
+
+	// 表单较长，放在可滚动的区域中
+	form := container.NewVScroll(widget.NewForm(items...))
+	d := dialog.NewCustomConfirm(title, "保存", "取消", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		edited := *profile
+		edited.Name = strings.TrimSpace(nameEntry.Text)
+		edited.TargetURL = strings.TrimSpace(urlEntry.Text)
+		edited.URLPatterns = splitLines(patternsEntry.Text)
+		edited.KeyHeaders = splitLines(keyHeadersEntry.Text)
+		edited.Cookies = splitLines(cookiesEntry.Text)
+		edited.ResponseHeaders = splitLines(responseHeadersEntry.Text)
+		edited.LocalStorage = splitLines(localStorageEntry.Text)
+		edited.SessionStorage = splitLines(sessionStorageEntry.Text)
+		edited.TimestampHeaders = splitLines(timestampEntry.Text)
+		edited.TokenLifetime = strings.TrimSpace(lifetimeEntry.Text)
+		edited.Timeout = strings.TrimSpace(timeoutEntry.Text)
+		edited.Backend = backends[backendSelect.SelectedIndex()]
+		if edited.Backend == BackendBrowser {
+			edited.Backend = ""
+		}
+
+		rules, err := parseBodyRules(splitLines(bodyEntry.Text))
+		if err == nil {
+			edited.BodyTokens = rules
+			edited.LoginScript, err = ParseScript(scriptEntry.Text)
+		}
+		if err == nil {
+			edited.HTTPLogin, err = ParseHTTPSteps(httpEntry.Text)
+		}
+		if err == nil {
+			err = edited.Validate()
+		}
+		if err == nil {
+			if other := ui.profiles.FindByName(edited.Name); other != nil && other.ID != edited.ID {
+				err = fmt.Errorf("已存在名为 %s 的配置", edited.Name)
+			}
+		}
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+
+		ui.profiles.Put(edited)
+		ui.profiles.Selected = edited.ID
+		if err := ui.saveProfiles(); err != nil {
+			dialog.ShowError(errors.New("保存站点配置失败: "+err.Error()), ui.window)
+		}
+		ui.urlEntry.SetText(edited.TargetURL)
+		ui.refreshProfileSelect()
+		ui.refreshAccounts()
+	}, ui.window)
+	d.Resize(fyne.NewSize(640, 760))
+	d.Show()
+}
+
+// deleteProfile 确认后删除站点配置，至少保留一个配置
+func (ui *TokenExtractorUI) deleteProfile(profile *SiteProfile) {
+	if len(ui.profiles.Profiles) <= 1 {
+		dialog.ShowError(errors.New("至少需要保留一个站点配置"), ui.window)
+		return
+	}
+
+	dialog.ShowConfirm("确认删除", fmt.Sprintf("确定要删除站点配置 %s 吗？", profile.Name), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		ui.removeProfileAccounts(profile.ID)
+		ui.profiles.Remove(profile.ID)
+		if err := ui.saveProfiles(); err != nil {
+			dialog.ShowError(errors.New("保存站点配置失败: "+err.Error()), ui.window)
+		}
+		ui.urlEntry.SetText(ui.currentProfile().TargetURL)
+		ui.refreshProfileSelect()
+		ui.refreshAccounts()
+	}, ui.window)
+}
+
+// newLinesEntry 创建每行一项的多行输入框
+func newLinesEntry(lines []string, placeholder string) *widget.Entry {
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder(placeholder)
+	entry.SetText(strings.Join(lines, "\n"))
+	entry.SetMinRowsVisible(3)
+	return entry
+}
+
+// splitLines 按行拆分并去掉空行
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseBodyRules 解析 "名称=JSON路径" 或 "名称=正则表达式" 格式的响应体Token规则，以 $ 开头的为JSON路径
+func parseBodyRules(lines []string) ([]BodyTokenRule, error) {
+	var rules []BodyTokenRule
+	for _, line := range lines {
+		name, pattern, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" || pattern == "" {
+			return nil, fmt.Errorf("响应体Token规则 %q 格式无效，请使用 名称=JSON路径 或 名称=正则表达式", line)
+		}
+
+		rule := BodyTokenRule{Name: strings.TrimSpace(name)}
+		if strings.HasPrefix(pattern, "$") {
+			rule.JSONPath = pattern
+		} else {
+			rule.Pattern = pattern
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// sourceIcon 返回结果来源的图标前缀，请求头部不加前缀
+func sourceIcon(source string) string {
+	switch source {
+	case SourceResponseHeader:
+		return "📨 "
+	case SourceCookie:
+		return "🍪 "
+	case SourceBody:
+		return "📄 "
+	case SourceLocalStorage:
+		return "💾 "
+	case SourceSessionStorage:
+		return "🗂 "
+	}
+	return ""
+}
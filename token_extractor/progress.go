@@ -0,0 +1,92 @@
+package token_extractor
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 提取的超时时间
+const (
+	DefaultExtractTimeout = 90 * time.Second // 站点配置没有设置超时时间时使用
+	maxExtractTimeout     = 30 * time.Minute
+)
+
+// 提取进度事件的类型
+const (
+	ProgressBrowserStarted  = "browser_started"  // 浏览器已启动
+	ProgressPageLoaded      = "page_loaded"      // 打开页面的步骤完成
+	ProgressFormFilled      = "form_filled"      // 填写的步骤完成
+	ProgressStep            = "step"             // 其他登录步骤完成或任意步骤失败
+	ProgressRequestCaptured = "request_captured" // 捕获到与站点配置匹配的请求
+	ProgressCollecting      = "collecting"       // 登录完成，正在读取Token
+)
+
+// ProgressEvent 提取过程中的进度事件
+type ProgressEvent struct {
+	Kind    string
+	Time    time.Time
+	Message string // 显示文本
+
+	Step    *StepLog         // 登录步骤的执行情况，只有登录步骤的事件有
+	Request *CapturedRequest // 捕获到的请求（响应尚未收到），只有 ProgressRequestCaptured 有
+}
+
+// String 返回进度事件的显示文本，登录步骤使用步骤日志的格式
+func (e ProgressEvent) String() string {
+	if e.Step != nil {
+		return e.Step.String()
+	}
+	icon := "🔎"
+	switch e.Kind {
+	case ProgressBrowserStarted:
+		icon = "🌐"
+	case ProgressRequestCaptured:
+		icon = "📡"
+	}
+	return icon + " " + e.Message
+}
+
+// progress 发送进度事件，没有设置 OnProgress 时忽略
+func (r *LoginRequest) progress(kind, message string) {
+	r.emit(ProgressEvent{Kind: kind, Message: message})
+}
+
+// emit 补全时间后调用 OnProgress
+func (r *LoginRequest) emit(event ProgressEvent) {
+	if r.OnProgress == nil {
+		return
+	}
+	event.Time = time.Now()
+	r.OnProgress(event)
+}
+
+// stepProgress 返回登录步骤完成后的进度事件，按动作区分页面加载和填写表单
+func stepProgress(log StepLog) ProgressEvent {
+	event := ProgressEvent{Kind: ProgressStep, Message: log.Step.Describe(), Step: &log}
+	if log.Success {
+		switch log.Step.Action {
+		case ActionNavigate:
+			event.Kind = ProgressPageLoaded
+		case ActionFill:
+			event.Kind = ProgressFormFilled
+		}
+	}
+	return event
+}
+
+// timeout 返回配置的提取超时时间，未配置或无效时为 DefaultExtractTimeout
+func (p *SiteProfile) timeout() time.Duration {
+	if d, err := time.ParseDuration(p.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultExtractTimeout
+}
+
+// stopError 返回提取中止时的错误：调用方取消时为 ErrCanceled，否则为 ErrTimeout
+func stopError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ErrCanceled
+	}
+	return ErrTimeout
+}
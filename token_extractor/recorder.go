@@ -0,0 +1,133 @@
+package token_extractor
+
+import (
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// maxCapturedRequests 结果中最多保留的匹配请求数，超过时丢弃最早的请求
+const maxCapturedRequests = 500
+
+// recordedRequest 记录中的请求和计算耗时用的发出时间
+type recordedRequest struct {
+	id     network.RequestID
+	req    *CapturedRequest
+	sentAt *cdp.MonotonicTime
+}
+
+// requestRecorder 按浏览器网络事件逐个记录与站点配置匹配的请求（不合并同名头部），
+// 调用方负责加锁
+type requestRecorder struct {
+	requests []*recordedRequest
+	byID     map[network.RequestID]*recordedRequest
+	password string // 登录密码，记录的地址和请求体中替换为 passwordMask
+}
+
+// newRequestRecorder 创建请求记录
+func newRequestRecorder(password string) *requestRecorder {
+	return &requestRecorder{
+		byID:     make(map[network.RequestID]*recordedRequest),
+		password: password,
+	}
+}
+
+// onRequest 记录发出的请求并返回其副本，不匹配时返回 nil；重定向时同一个请求ID会再次发出，先用重定向响应补全上一跳
+func (r *requestRecorder) onRequest(ev *network.EventRequestWillBeSent, matched bool) *CapturedRequest {
+	if prev, ok := r.byID[ev.RequestID]; ok && ev.RedirectResponse != nil {
+		prev.setResponse(ev.RedirectResponse)
+		prev.finish(ev.Timestamp)
+		delete(r.byID, ev.RequestID)
+	}
+	if !matched {
+		return nil
+	}
+
+	req := &CapturedRequest{
+		Method:  ev.Request.Method,
+		URL:     redactPassword(ev.Request.URL, r.password),
+		Headers: stringHeaders(ev.Request.Headers),
+		Body:    redactPassword(ev.Request.PostData, r.password),
+		Type:    string(ev.Type),
+	}
+	if ev.WallTime != nil {
+		req.StartedAt = ev.WallTime.Time()
+	}
+
+	if len(r.requests) == maxCapturedRequests {
+		delete(r.byID, r.requests[0].id)
+		r.requests = r.requests[1:]
+	}
+	recorded := &recordedRequest{id: ev.RequestID, req: req, sentAt: ev.Timestamp}
+	r.requests = append(r.requests, recorded)
+	r.byID[ev.RequestID] = recorded
+
+	captured := *req
+	return &captured
+}
+
+// onResponse 记录收到的响应
+func (r *requestRecorder) onResponse(ev *network.EventResponseReceived) {
+	if recorded, ok := r.byID[ev.RequestID]; ok {
+		recorded.setResponse(ev.Response)
+	}
+}
+
+// onFinished 记录加载完成的时间和接收的字节数
+func (r *requestRecorder) onFinished(ev *network.EventLoadingFinished) {
+	if recorded, ok := r.byID[ev.RequestID]; ok {
+		recorded.req.Size = int64(ev.EncodedDataLength)
+		recorded.finish(ev.Timestamp)
+	}
+}
+
+// onFailed 记录加载失败的原因
+func (r *requestRecorder) onFailed(ev *network.EventLoadingFailed) {
+	if recorded, ok := r.byID[ev.RequestID]; ok {
+		recorded.req.Error = ev.ErrorText
+		if ev.Canceled {
+			recorded.req.Error = "已取消"
+		}
+		recorded.finish(ev.Timestamp)
+	}
+}
+
+// snapshot 返回记录的请求副本，按发出顺序
+func (r *requestRecorder) snapshot() []CapturedRequest {
+	requests := make([]CapturedRequest, len(r.requests))
+	for i, recorded := range r.requests {
+		requests[i] = *recorded.req
+	}
+	return requests
+}
+
+// setResponse 填入响应的状态、头部和等待时间
+func (r *recordedRequest) setResponse(resp *network.Response) {
+	r.req.Status = int(resp.Status)
+	r.req.StatusText = resp.StatusText
+	r.req.Protocol = resp.Protocol
+	r.req.MimeType = resp.MimeType
+	r.req.ResponseHeaders = stringHeaders(resp.Headers)
+	if timing := resp.Timing; timing != nil && timing.SendEnd >= 0 && timing.ReceiveHeadersEnd >= timing.SendEnd {
+		r.req.Wait = time.Duration((timing.ReceiveHeadersEnd - timing.SendEnd) * float64(time.Millisecond))
+	}
+}
+
+// finish 按发出和结束的时间计算总耗时
+func (r *recordedRequest) finish(at *cdp.MonotonicTime) {
+	if r.sentAt != nil && at != nil {
+		r.req.Duration = at.Time().Sub(r.sentAt.Time())
+	}
+}
+
+// stringHeaders 将浏览器事件中的头部转换为字符串
+func stringHeaders(headers network.Headers) map[string]string {
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if strValue, ok := value.(string); ok {
+			result[name] = strValue
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// expiryText 返回结果列表中单个Token的剩余有效时间，未知时为空
+func expiryText(expiresAt, now time.Time) string {
+	if expiresAt.IsZero() {
+		return ""
+	}
+	if !expiresAt.After(now) {
+		return "⌛ 已过期"
+	}
+	return "⏳ " + FormatRemaining(expiresAt, now)
+}
+
+// runCountdown 每秒刷新结果列表中的倒计时和自动刷新状态
+func (ui *TokenExtractorUI) runCountdown() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		fyne.Do(func() {
+			if ui.currentResult == nil {
+				return
+			}
+			if _, ok := ui.currentResult.ExpiresAt(); ok {
+				ui.resultList.Refresh()
+				ui.updateRefreshLabel()
+			}
+		})
+	}
+}
+
+// updateRefreshLabel 显示最早的过期时间和下次自动提取的时间
+func (ui *TokenExtractorUI) updateRefreshLabel() {
+	if ui.currentResult == nil || !ui.currentResult.Success {
+		ui.refreshLabel.Hide()
+		return
+	}
+
+	now := time.Now()
+	expiresAt, ok := ui.currentResult.ExpiresAt()
+	var text string
+	switch {
+	case !ok:
+		text = "未能从Token中得知过期时间，可以在站点配置中设置Token有效期"
+	case !expiresAt.After(now):
+		text = fmt.Sprintf("⌛ 关键Token已于 %s 过期", expiresAt.Format("01-02 15:04:05"))
+	default:
+		text = fmt.Sprintf("⏱ 关键Token将于 %s 过期（剩余 %s）", expiresAt.Format("01-02 15:04:05"), FormatRemaining(expiresAt, now))
+	}
+	if !ui.refreshAt.IsZero() {
+		text += fmt.Sprintf("，将在 %s 自动重新提取", ui.refreshAt.Format("15:04:05"))
+	}
+
+	ui.refreshLabel.SetText(text)
+	ui.refreshLabel.Show()
+}
+
+// onAutoRefreshChanged 保存自动刷新设置并重新安排下次提取
+func (ui *TokenExtractorUI) onAutoRefreshChanged(checked bool) {
+	if ui.profiles.AutoRefresh != checked {
+		ui.profiles.AutoRefresh = checked
+		if err := ui.saveProfiles(); err != nil {
+			ui.statusLabel.SetText("⚠️ " + err.Error())
+		}
+	}
+	ui.scheduleAutoRefresh()
+}
+
+// scheduleAutoRefresh 启用自动刷新时，按当前结果的过期时间安排下次提取
+func (ui *TokenExtractorUI) scheduleAutoRefresh() {
+	ui.cancelAutoRefresh()
+	defer ui.updateRefreshLabel()
+
+	if !ui.autoRefresh.Checked || ui.lastRequest == nil || ui.currentResult == nil {
+		return
+	}
+	expiresAt, ok := ui.currentResult.ExpiresAt()
+	if !ok {
+		return
+	}
+	ui.scheduleRefreshAt(RefreshAt(expiresAt, time.Now()))
+}
+
+// scheduleRefreshAt 在指定时间使用上次的请求重新提取
+func (ui *TokenExtractorUI) scheduleRefreshAt(at time.Time) {
+	ui.cancelAutoRefresh()
+	req := *ui.lastRequest
+
+	ui.refreshAt = at
+	ui.refreshTimer = time.AfterFunc(time.Until(at), func() {
+		fyne.Do(func() {
+			ui.refreshTimer = nil
+			ui.refreshAt = time.Time{}
+			if !ui.autoRefresh.Checked {
+				return
+			}
+			// 正在手动提取时稍后再试
+			if ui.extractButton.Disabled() {
+				ui.scheduleRefreshAt(time.Now().Add(minRefreshDelay))
+				return
+			}
+			ui.startExtract(req, true)
+		})
+	})
+}
+
+// cancelAutoRefresh 取消计划的自动提取
+func (ui *TokenExtractorUI) cancelAutoRefresh() {
+	if ui.refreshTimer != nil {
+		ui.refreshTimer.Stop()
+		ui.refreshTimer = nil
+	}
+	ui.refreshAt = time.Time{}
+}
+
+// onAutoRefreshFailed 自动提取失败时发送系统通知并提示；Token还没过期时在过期前重试
+func (ui *TokenExtractorUI) onAutoRefreshFailed(req LoginRequest, err error) {
+	message := "自动重新提取Token失败: " + err.Error()
+
+	if ui.currentResult != nil {
+		now := time.Now()
+		if expiresAt, ok := ui.currentResult.ExpiresAt(); ok && now.Add(minRefreshDelay).Before(expiresAt) {
+			retryAt := now.Add(refreshRetryDelay)
+			if at := RefreshAt(expiresAt, now); at.Before(retryAt) {
+				retryAt = at
+			}
+			ui.lastRequest = &req
+			ui.scheduleRefreshAt(retryAt)
+			message += fmt.Sprintf("，将在 %s 重试", ui.refreshAt.Format("15:04:05"))
+		}
+	}
+	ui.updateRefreshLabel()
+
+	if app := fyne.CurrentApp(); app != nil {
+		app.SendNotification(fyne.NewNotification("Token提取器", message))
+	}
+	dialog.ShowError(errors.New(message), ui.window)
+}
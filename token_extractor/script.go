@@ -0,0 +1,307 @@
+package token_extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+)
+
+// 登录脚本支持的动作
+const (
+	ActionNavigate       = "navigate"         // 打开页面，目标为空时打开登录地址
+	ActionWaitFor        = "wait_for"         // 等待元素可见，值为超时时间
+	ActionFill           = "fill"             // 在元素中输入文字，值中可以使用 {{username}} 和 {{password}}
+	ActionClick          = "click"            // 点击元素
+	ActionSelectFrame    = "select_frame"     // 之后的元素操作在该 iframe 中进行，目标为空时回到主页面
+	ActionWaitForRequest = "wait_for_request" // 等待匹配规则的请求发出（规则格式同捕获请求），值为超时时间
+	ActionSleep          = "sleep"            // 固定等待，值为等待时间
+)
+
+// stepActionNames 各动作在步骤日志中显示的名称
+var stepActionNames = map[string]string{
+	ActionNavigate:       "打开页面",
+	ActionWaitFor:        "等待元素",
+	ActionFill:           "填写",
+	ActionClick:          "点击",
+	ActionSelectFrame:    "切换框架",
+	ActionWaitForRequest: "等待请求",
+	ActionSleep:          "等待",
+}
+
+// defaultStepTimeout 等待类步骤未指定超时时间时的默认值
+const defaultStepTimeout = 30 * time.Second
+
+// 脚本文本中每行的字段分隔符：动作 | 目标 | 值
+const scriptSeparator = "|"
+
+// LoginStep 登录脚本中的一步
+type LoginStep struct {
+	Action string `json:"action"`
+	Target string `json:"target,omitempty"` // 选择器、地址或请求匹配规则
+	Value  string `json:"value,omitempty"`  // 输入的文字或时间（如 2s）
+}
+
+// StepLog 登录脚本中一步的执行情况
+type StepLog struct {
+	Index    int // 从 1 开始
+	Step     LoginStep
+	Success  bool
+	Duration time.Duration
+	Error    string
+}
+
+// String 返回步骤日志的单行描述，填写的内容只显示脚本中的原文，不会显示密码
+func (l StepLog) String() string {
+	mark := "✅"
+	if !l.Success {
+		mark = "❌"
+	}
+	text := fmt.Sprintf("%s %d. %s (%s)", mark, l.Index, l.Step.Describe(), l.Duration.Round(10*time.Millisecond))
+	if l.Error != "" {
+		text += ": " + l.Error
+	}
+	return text
+}
+
+// Describe 返回步骤的中文描述，HTTP登录的步骤显示请求方法
+func (s LoginStep) Describe() string {
+	name, ok := stepActionNames[s.Action]
+	if !ok {
+		name = s.Action
+	}
+	parts := []string{name}
+	if s.Target != "" {
+		parts = append(parts, s.Target)
+	}
+	if s.Value != "" {
+		parts = append(parts, s.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// DefaultLoginScript 返回内置登录脚本：填写账号密码后点击提交按钮
+func DefaultLoginScript() []LoginStep {
+	usernameSelector := `input[type="text"], input[type="email"], input[name="username"], input[placeholder*="账号"], input[placeholder*="用户名"], input[placeholder*="邮箱"]`
+	return []LoginStep{
+		{Action: ActionNavigate},
+		{Action: ActionSleep, Value: "2s"},
+		{Action: ActionWaitFor, Target: usernameSelector},
+		{Action: ActionFill, Target: usernameSelector, Value: "{{username}}"},
+		{Action: ActionFill, Target: `input[type="password"]`, Value: "{{password}}"},
+		{Action: ActionSleep, Value: "1s"},
+		{Action: ActionClick, Target: `button[type="submit"], button:contains("登录"), button:contains("Login"), .login-button`},
+		{Action: ActionSleep, Value: "5s"},
+	}
+}
+
+// ValidateScript 检查登录脚本中的动作和参数
+func ValidateScript(steps []LoginStep) error {
+	for i, step := range steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("登录脚本第 %d 步无效: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s LoginStep) validate() error {
+	if _, ok := stepActionNames[s.Action]; !ok {
+		return fmt.Errorf("未知的动作 %q", s.Action)
+	}
+
+	switch s.Action {
+	case ActionWaitFor, ActionFill, ActionClick, ActionWaitForRequest:
+		if s.Target == "" {
+			return errors.New(stepActionNames[s.Action] + "需要指定目标")
+		}
+	case ActionSleep:
+		if s.Value == "" {
+			return errors.New("等待需要指定时间，例如 2s")
+		}
+	}
+
+	if s.Action == ActionWaitForRequest {
+		if _, err := compileURLPattern(s.Target); err != nil {
+			return fmt.Errorf("请求匹配规则无效: %w", err)
+		}
+	}
+	if s.Action == ActionWaitFor || s.Action == ActionWaitForRequest || s.Action == ActionSleep {
+		if _, err := s.duration(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duration 返回等待类步骤的时间，未指定时为默认超时时间
+func (s LoginStep) duration() (time.Duration, error) {
+	if s.Value == "" {
+		return defaultStepTimeout, nil
+	}
+	d, err := time.ParseDuration(s.Value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("无效的时间 %q，例如 2s 或 1m", s.Value)
+	}
+	return d, nil
+}
+
+// FormatScript 把登录脚本格式化为每行一步的文本
+func FormatScript(steps []LoginStep) string {
+	lines := make([]string, len(steps))
+	for i, step := range steps {
+		fields := []string{step.Action, step.Target, step.Value}
+		for len(fields) > 1 && fields[len(fields)-1] == "" {
+			fields = fields[:len(fields)-1]
+		}
+		// 空目标写成 "sleep | | 2s"
+		lines[i] = strings.ReplaceAll(strings.Join(fields, " "+scriptSeparator+" "), "|  |", "| |")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseScript 解析每行一步的登录脚本文本（动作 | 目标 | 值），空行和 # 开头的行被忽略
+func ParseScript(text string) ([]LoginStep, error) {
+	var steps []LoginStep
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, scriptSeparator, 3)
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		step := LoginStep{
+			Action: strings.TrimSpace(fields[0]),
+			Target: strings.TrimSpace(fields[1]),
+			Value:  strings.TrimSpace(fields[2]),
+		}
+		if err := step.validate(); err != nil {
+			return nil, fmt.Errorf("登录脚本第 %d 行无效: %w", number+1, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// scriptRunner 在浏览器中执行登录脚本
+type scriptRunner struct {
+	req        LoginRequest
+	sawRequest func(match func(url string) bool) bool // 是否已发出匹配的请求
+	frame      *cdp.Node                              // 当前选中的 iframe，为空时为主页面
+}
+
+// run 依次执行登录脚本，每完成一步发送进度事件；某一步失败时停止并返回已执行的步骤
+func (r *scriptRunner) run(ctx context.Context, steps []LoginStep) ([]StepLog, error) {
+	var logs []StepLog
+	for i, step := range steps {
+		start := time.Now()
+		err := r.runStep(ctx, step)
+
+		log := StepLog{
+			Index:    i + 1,
+			Step:     step,
+			Success:  err == nil,
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			log.Error = err.Error()
+		}
+		logs = append(logs, log)
+		r.req.emit(stepProgress(log))
+
+		if err != nil {
+			return logs, fmt.Errorf("第 %d 步（%s）失败: %w", log.Index, step.Describe(), err)
+		}
+	}
+	return logs, nil
+}
+
+func (r *scriptRunner) runStep(ctx context.Context, step LoginStep) error {
+	switch step.Action {
+	case ActionNavigate:
+		target := step.Target
+		if target == "" {
+			target = r.req.TargetURL
+		}
+		r.frame = nil
+		return chromedp.Run(ctx, chromedp.Navigate(r.expand(target)))
+
+	case ActionWaitFor:
+		timeout, _ := step.duration()
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err := chromedp.Run(waitCtx, chromedp.WaitVisible(step.Target, r.queryOptions()...))
+		if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+			// 只是这一步超时，整个提取还没有超时
+			return errors.New("等待超时，元素未出现")
+		}
+		return err
+
+	case ActionFill:
+		return chromedp.Run(ctx, chromedp.SendKeys(step.Target, r.expand(step.Value), r.queryOptions()...))
+
+	case ActionClick:
+		return chromedp.Run(ctx, chromedp.Click(step.Target, r.queryOptions()...))
+
+	case ActionSelectFrame:
+		if step.Target == "" {
+			r.frame = nil
+			return nil
+		}
+		var nodes []*cdp.Node
+		if err := chromedp.Run(ctx, chromedp.Nodes(step.Target, &nodes, r.queryOptions()...)); err != nil {
+			return err
+		}
+		r.frame = nodes[0]
+		return nil
+
+	case ActionWaitForRequest:
+		timeout, _ := step.duration()
+		re, err := compileURLPattern(step.Target)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(timeout)
+		for !r.sawRequest(re.MatchString) {
+			if time.Now().After(deadline) {
+				return errors.New("等待超时，未发现匹配的请求")
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		return nil
+
+	case ActionSleep:
+		d, _ := step.duration()
+		return chromedp.Run(ctx, chromedp.Sleep(d))
+	}
+	return fmt.Errorf("未知的动作 %q", step.Action)
+}
+
+// queryOptions 返回元素查询选项，选中 iframe 时在其文档中查找
+func (r *scriptRunner) queryOptions() []chromedp.QueryOption {
+	opts := []chromedp.QueryOption{chromedp.ByQuery}
+	if r.frame != nil {
+		opts = append(opts, chromedp.FromNode(r.frame))
+	}
+	return opts
+}
+
+// expand 替换脚本中的 {{username}}、{{password}} 和 {{url}}
+func (r *scriptRunner) expand(text string) string {
+	return strings.NewReplacer(
+		"{{username}}", r.req.Username,
+		"{{password}}", r.req.Password,
+		"{{url}}", r.req.TargetURL,
+	).Replace(text)
+}
@@ -0,0 +1,241 @@
+package token_extractor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"my_portfolio/safe_file"
+	"my_portfolio/sqlite_storage"
+
+	"github.com/google/uuid"
+)
+
+// maxHistory 最多保存的历史记录数
+const maxHistory = 100
+
+// migrations 历史记录的数据库结构迁移
+var migrations = []sqlite_storage.Migration{
+	{
+		Version:     1,
+		Description: "创建Token提取历史表",
+		SQL: `CREATE TABLE token_history (
+			id          TEXT    PRIMARY KEY,
+			timestamp   TEXT    NOT NULL,
+			username    TEXT    NOT NULL,
+			success     INTEGER NOT NULL,
+			key_headers TEXT    NOT NULL
+		);
+		CREATE INDEX idx_token_history_timestamp ON token_history (timestamp);`,
+	},
+	{
+		Version:     2,
+		Description: "历史记录时间改为Unix纳秒整数，按数值排序不受时区和小数位数影响",
+		SQL: `CREATE TABLE token_history_v2 (
+			id          TEXT    PRIMARY KEY,
+			timestamp   INTEGER NOT NULL,
+			username    TEXT    NOT NULL,
+			success     INTEGER NOT NULL,
+			key_headers TEXT    NOT NULL
+		);`,
+		Migrate: migrateHistoryTimestamps,
+	},
+}
+
+// migrateHistoryTimestamps 将文本格式的时间转换为Unix纳秒，复制到新表后替换旧表
+func migrateHistoryTimestamps(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, timestamp, username, success, key_headers FROM token_history`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type row struct {
+		id, username, headers string
+		timestamp             time.Time
+		success               bool
+	}
+	var history []row
+	for rows.Next() {
+		var r row
+		var timestamp string
+		if err := rows.Scan(&r.id, &timestamp, &r.username, &r.success, &r.headers); err != nil {
+			return err
+		}
+		if r.timestamp, err = sqlite_storage.ParseTime(timestamp); err != nil {
+			return err
+		}
+		history = append(history, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, r := range history {
+		if _, err := tx.Exec(`INSERT INTO token_history_v2 (id, timestamp, username, success, key_headers) VALUES (?, ?, ?, ?, ?)`,
+			r.id, r.timestamp.UnixNano(), r.username, r.success, r.headers); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DROP TABLE token_history;
+		ALTER TABLE token_history_v2 RENAME TO token_history;
+		CREATE INDEX idx_token_history_timestamp ON token_history (timestamp);`)
+	return err
+}
+
+// SQLiteStorage SQLite数据库存储实现，每次保存只插入一条记录
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage 创建SQLite存储并执行数据库结构迁移
+func NewSQLiteStorage(db *sql.DB) (*SQLiteStorage, error) {
+	if err := sqlite_storage.Migrate(db, ToolID, migrations); err != nil {
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// SaveHistory 保存历史记录，并删除超出数量限制的旧记录
+func (s *SQLiteStorage) SaveHistory(record HistoryRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertHistory(tx, record); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM token_history WHERE id NOT IN (
+		SELECT id FROM token_history ORDER BY timestamp DESC LIMIT ?)`, maxHistory); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertHistory 插入一条历史记录
+func insertHistory(tx *sql.Tx, record HistoryRecord) error {
+	headers, err := json.Marshal(record.KeyHeaders)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO token_history (id, timestamp, username, success, key_headers) VALUES (?, ?, ?, ?, ?)`,
+		record.ID, record.Timestamp.UnixNano(), record.Username, record.Success, string(headers))
+	return err
+}
+
+// GetHistory 获取历史记录（按时间从新到旧）
+func (s *SQLiteStorage) GetHistory(limit int) ([]HistoryRecord, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite 中负数表示不限制
+	}
+
+	rows, err := s.db.Query(`SELECT id, timestamp, username, success, key_headers
+		FROM token_history ORDER BY timestamp DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []HistoryRecord{}
+	for rows.Next() {
+		var record HistoryRecord
+		var timestamp int64
+		var headers string
+		if err := rows.Scan(&record.ID, &timestamp, &record.Username, &record.Success, &headers); err != nil {
+			return nil, err
+		}
+		record.Timestamp = time.Unix(0, timestamp)
+		if err := json.Unmarshal([]byte(headers), &record.KeyHeaders); err != nil {
+			return nil, err
+		}
+		history = append(history, record)
+	}
+
+	return history, rows.Err()
+}
+
+// ClearHistory 清空历史
+func (s *SQLiteStorage) ClearHistory() error {
+	_, err := s.db.Exec(`DELETE FROM token_history`)
+	return err
+}
+
+// ExportJSON 将数据库中的历史记录导出到JSON历史文件并清空数据库中的记录，返回导出的记录数。
+// 数据库中没有记录时不修改JSON文件
+func (s *SQLiteStorage) ExportJSON(path string) (int, error) {
+	history, err := s.GetHistory(0)
+	if err != nil {
+		return 0, err
+	}
+	if len(history) == 0 {
+		return 0, nil
+	}
+
+	data, err := dataSchema.Encode(history)
+	if err != nil {
+		return 0, err
+	}
+	if err := safe_file.WriteFile(path, data, 0600); err != nil {
+		return 0, err
+	}
+
+	if err := s.ClearHistory(); err != nil {
+		return 0, err
+	}
+	return len(history), nil
+}
+
+// ImportJSON 将JSON历史文件导入数据库。
+// 只在数据库中还没有历史记录时导入，返回导入的记录数；JSON文件保持不变。
+func (s *SQLiteStorage) ImportJSON(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM token_history`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	history, err := NewJSONStorage(path).GetHistory(0)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// 旧版本按秒生成ID，同一秒内的记录ID相同，重复时重新生成，避免丢失记录
+	seen := make(map[string]bool)
+	for _, record := range history {
+		if record.ID == "" || seen[record.ID] {
+			record.ID = uuid.New().String()
+		}
+		seen[record.ID] = true
+		if err := insertHistory(tx, record); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(history), nil
+}
@@ -2,9 +2,21 @@ package token_extractor
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/safe_file"
+	"my_portfolio/schema"
 )
 
+// CurrentSchemaVersion 历史记录文件的当前结构版本
+const CurrentSchemaVersion = 1
+
+// dataSchema 历史记录文件的迁移注册表，修改 HistoryRecord 的结构时在这里注册新的迁移
+var dataSchema = schema.NewRegistry("Token历史", CurrentSchemaVersion).
+	Register(0, "为旧版历史文件添加版本信息", schema.Keep)
+
 // Storage 存储接口
 type Storage interface {
 	// SaveHistory 保存历史记录
@@ -45,30 +57,46 @@ func (s *JSONStorage) SaveHistory(record HistoryRecord) error {
 		history = history[:100]
 	}
 
-	// 保存到文件
-	data, err := json.MarshalIndent(history, "", "  ")
+	// 保存到文件（带版本信息）
+	data, err := dataSchema.Encode(history)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filePath, data, 0600)
+	// 原子写入文件，并保留历史版本
+	return safe_file.WriteFile(s.filePath, data, 0600)
 }
 
 // GetHistory 获取历史记录
 func (s *JSONStorage) GetHistory(limit int) ([]HistoryRecord, error) {
-	data, err := os.ReadFile(s.filePath)
+	// 读取文件（主文件损坏时自动回退到最近的可用备份）
+	data, err := safe_file.ReadFile(s.filePath, func(data []byte) error {
+		var history []HistoryRecord
+		return dataSchema.Decode(data, &history)
+	})
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []HistoryRecord{}, nil
 		}
-		return nil, err
+		var recovered *safe_file.RecoveredError
+		if !errors.As(err, &recovered) {
+			return nil, err
+		}
 	}
 
-	var history []HistoryRecord
-	if err := json.Unmarshal(data, &history); err != nil {
+	// 旧版本文件先备份再升级到当前版本
+	payload, err := dataSchema.UpgradeFile(s.filePath, data, 0600)
+	if err != nil {
 		return nil, err
 	}
 
+	history := []HistoryRecord{}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &history); err != nil {
+			return nil, err
+		}
+	}
+
 	if limit > 0 && len(history) > limit {
 		return history[:limit], nil
 	}
@@ -80,3 +108,84 @@ func (s *JSONStorage) GetHistory(limit int) ([]HistoryRecord, error) {
 func (s *JSONStorage) ClearHistory() error {
 	return os.Remove(s.filePath)
 }
+
+// CurrentProfileSchemaVersion 站点配置文件的当前结构版本
+const CurrentProfileSchemaVersion = 1
+
+// profileSchema 站点配置文件的迁移注册表
+var profileSchema = schema.NewRegistry("Token站点配置", CurrentProfileSchemaVersion).
+	Register(0, "为站点配置文件添加版本信息", schema.Keep)
+
+// ProfileStorage 站点配置的JSON文件存储（不论数据使用哪种存储后端，站点配置都保存在JSON文件中）
+type ProfileStorage struct {
+	filePath string
+	guard    *file_watch.Guard
+}
+
+// NewProfileStorage 创建站点配置存储
+func NewProfileStorage(filePath string) *ProfileStorage {
+	return &ProfileStorage{
+		filePath: filePath,
+		guard:    file_watch.NewGuard(filePath),
+	}
+}
+
+// Guard 返回站点配置文件的外部修改检查
+func (s *ProfileStorage) Guard() *file_watch.Guard {
+	return s.guard
+}
+
+// Load 加载站点配置，文件不存在时返回内置配置
+func (s *ProfileStorage) Load() (*ProfileSet, error) {
+	data, err := safe_file.ReadFile(s.filePath, func(data []byte) error {
+		var set ProfileSet
+		return profileSchema.Decode(data, &set)
+	})
+	s.guard.Remember()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultProfileSet(), nil
+		}
+		var recovered *safe_file.RecoveredError
+		if !errors.As(err, &recovered) {
+			return DefaultProfileSet(), err
+		}
+	}
+
+	payload, err := profileSchema.UpgradeFile(s.filePath, data, 0600)
+	if err != nil {
+		return DefaultProfileSet(), err
+	}
+
+	set := &ProfileSet{}
+	if err := json.Unmarshal(payload, set); err != nil {
+		return DefaultProfileSet(), err
+	}
+	for i := range set.Profiles {
+		// 规则无效的配置仍然保留，提取时再提示
+		set.Profiles[i].Validate()
+	}
+	return set, nil
+}
+
+// Save 保存站点配置
+func (s *ProfileStorage) Save(set *ProfileSet) error {
+	if err := profileSchema.CheckWritable(s.filePath); err != nil {
+		return err
+	}
+
+	// 不覆盖读取之后被其他程序修改的文件
+	if err := s.guard.Check(); err != nil {
+		return err
+	}
+
+	data, err := profileSchema.Encode(set)
+	if err != nil {
+		return err
+	}
+	if err := safe_file.WriteFile(s.filePath, data, 0600); err != nil {
+		return err
+	}
+	s.guard.Remember()
+	return nil
+}
@@ -2,6 +2,7 @@ package token_extractor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/storage_recovery"
 )
 
 // TokenExtractorUI token提取器UI
@@ -18,28 +21,106 @@ type TokenExtractorUI struct {
 	extractor Extractor
 	storage   Storage
 
+	// 站点配置
+	profileStorage *ProfileStorage
+	profiles       *ProfileSet
+	profilesErr    error // 加载失败时不保存，避免覆盖原文件
+	profileSelect  *widget.Select
+
+	// 保存的账号
+	vault         *Vault
+	accountSelect *widget.Select
+
 	// UI组件
 	urlEntry      *widget.Entry
 	usernameEntry *widget.Entry
 	passwordEntry *widget.Entry
 	extractButton *widget.Button
+	cancelButton  *widget.Button
 	statusLabel   *widget.Label
+	stepLog       *widget.Label // 提取进度和登录脚本的步骤日志
+	refreshLabel  *widget.Label // 过期时间和自动刷新状态
+	autoRefresh   *widget.Check
 	resultList    *widget.List
 	progressBar   *widget.ProgressBarInfinite
 
 	// 数据
 	currentResult *ExtractResult
+	cancelExtract context.CancelFunc // 提取进行中时非空
+	capturedCount int                // 本次提取捕获到的匹配请求数
+	requests      []CapturedRequest // 上次提取记录的请求（提取失败时也保留）
+
+	// 自动刷新：上次成功提取的请求（包含凭证，只保存在内存中）和计划的下次提取
+	lastRequest  *LoginRequest
+	refreshTimer *time.Timer
+	refreshAt    time.Time
+
+	// 历史记录保存失败后在后台重试
+	saveQueue *storage_recovery.SaveQueue
+
+	// 停止监视站点配置和历史记录文件
+	stopWatches []func()
+}
+
+const (
+	// ToolID 工具标识，用于配置文件和数据库
+	ToolID = "token_extractor"
+
+	// DefaultHistoryFile 默认历史记录文件名
+	DefaultHistoryFile = "token_history.json"
+
+	// DefaultProfilesFile 默认站点配置文件名
+	DefaultProfilesFile = "token_profiles.json"
+
+	// DefaultVaultFile 系统钥匙串不可用时加密保存账号密码的文件名
+	DefaultVaultFile = "token_vault.json"
+)
+
+// Options Token提取器UI的创建选项
+type Options struct {
+	HistoryPath string  // 历史记录文件路径，为空时使用默认文件名
+	Storage     Storage // 自定义存储（例如SQLite），非空时忽略 HistoryPath
+
+	ProfilesPath string // 站点配置文件路径，为空时使用默认文件名
+	VaultPath    string // 加密保存账号密码的文件路径，为空时使用默认文件名
 }
 
 // NewTokenExtractorUI 创建UI实例
 func NewTokenExtractorUI(window fyne.Window) *TokenExtractorUI {
-	extractor, _ := NewChromeExtractor()
+	return NewTokenExtractorUIWithOptions(window, Options{})
+}
+
+// NewTokenExtractorUIWithOptions 按选项创建UI实例
+func NewTokenExtractorUIWithOptions(window fyne.Window, opts Options) *TokenExtractorUI {
+	if opts.HistoryPath == "" {
+		opts.HistoryPath = DefaultHistoryFile
+	}
+	if opts.ProfilesPath == "" {
+		opts.ProfilesPath = DefaultProfilesFile
+	}
+	if opts.VaultPath == "" {
+		opts.VaultPath = DefaultVaultFile
+	}
 
-	return &TokenExtractorUI{
-		window:    window,
-		extractor: extractor,
-		storage:   NewJSONStorage("token_history.json"),
+	extractor, _ := NewExtractor()
+
+	storage := opts.Storage
+	if storage == nil {
+		storage = NewJSONStorage(opts.HistoryPath)
+	}
+
+	ui := &TokenExtractorUI{
+		window:         window,
+		extractor:      extractor,
+		storage:        storage,
+		profileStorage: NewProfileStorage(opts.ProfilesPath),
+		vault:          NewVault(opts.VaultPath),
 	}
+	ui.loadProfiles()
+	// 历史记录逐条追加，失败的每一条都需要按顺序重试
+	ui.saveQueue = storage_recovery.NewSaveQueue(false, ui.onSaveQueueChanged)
+
+	return ui
 }
 
 // MakeUI 构建UI界面
@@ -49,8 +130,8 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 
 	// URL输入框
 	ui.urlEntry = widget.NewEntry()
-	ui.urlEntry.SetPlaceHolder("https://ankersolix-professional-ci.anker.com/home/systemlist")
-	ui.urlEntry.SetText("https://ankersolix-professional-ci.anker.com/home/systemlist")
+	ui.urlEntry.SetPlaceHolder("https://example.com/login")
+	ui.urlEntry.SetText(ui.currentProfile().TargetURL)
 
 	// 输入表单 - 使用紧凑的水平布局
 	ui.usernameEntry = widget.NewEntry()
@@ -63,6 +144,8 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 	ui.extractButton = widget.NewButton("开始提取", func() {
 		ui.handleExtract()
 	})
+	ui.cancelButton = widget.NewButton("取消", ui.handleCancel)
+	ui.cancelButton.Hide()
 
 	// 进度条
 	ui.progressBar = widget.NewProgressBarInfinite()
@@ -72,6 +155,18 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 	ui.statusLabel = widget.NewLabel("等待操作...")
 	ui.statusLabel.Alignment = fyne.TextAlignCenter
 
+	// 登录步骤日志
+	ui.stepLog = widget.NewLabel("")
+	ui.stepLog.Wrapping = fyne.TextWrapBreak
+	ui.stepLog.Hide()
+
+	// 过期时间和自动刷新
+	ui.refreshLabel = widget.NewLabel("")
+	ui.refreshLabel.Alignment = fyne.TextAlignCenter
+	ui.refreshLabel.Hide()
+	ui.autoRefresh = widget.NewCheck("过期前自动重新提取", ui.onAutoRefreshChanged)
+	ui.autoRefresh.SetChecked(ui.profiles.AutoRefresh)
+
 	// 输入区域 - 紧凑的网格布局
 	urlLabel := widget.NewLabel("URL:")
 	usernameLabel := widget.NewLabel("账号:")
@@ -79,11 +174,15 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 
 	// 使用表格式布局，更紧凑
 	inputGrid := container.NewVBox(
+		ui.createProfileBar(),
 		container.NewBorder(nil, nil, urlLabel, nil, ui.urlEntry),
+		ui.createAccountBar(),
 		container.NewHBox(
 			container.NewBorder(nil, nil, usernameLabel, nil, ui.usernameEntry),
 			container.NewBorder(nil, nil, passwordLabel, nil, ui.passwordEntry),
 			ui.extractButton,
+			ui.cancelButton,
+			ui.autoRefresh,
 		),
 	)
 
@@ -91,6 +190,8 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 		inputGrid,
 		ui.progressBar,
 		ui.statusLabel,
+		ui.refreshLabel,
+		ui.stepLog,
 	)
 
 	// 结果区域
@@ -109,13 +210,14 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 			nameLabel := widget.NewLabelWithStyle("Header-Name", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 			valueLabel := widget.NewLabel("value")
 			valueLabel.Wrapping = fyne.TextWrapBreak
+			expiryLabel := widget.NewLabel("")
 			copyBtn := widget.NewButton("复制", nil)
 
 			// 使用水平布局，更紧凑
 			return container.NewBorder(
 				nil, nil, 
 				container.NewHBox(iconLabel, nameLabel),
-				copyBtn,
+				container.NewHBox(expiryLabel, copyBtn),
 				valueLabel,
 			)
 		},
@@ -128,7 +230,7 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 			border := obj.(*fyne.Container)
 
 			// 更新图标和名称（左侧）
-			leftBox := border.Objects[2].(*fyne.Container)
+			leftBox := border.Objects[1].(*fyne.Container)
 			iconLabel := leftBox.Objects[0].(*widget.Label)
 			nameLabel := leftBox.Objects[1].(*widget.Label)
 
@@ -137,10 +239,10 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 			} else {
 				iconLabel.SetText("📋")
 			}
-			nameLabel.SetText(header.Name)
+			nameLabel.SetText(sourceIcon(header.Source) + header.Name)
 
 			// 更新值（中间）
-			valueLabel := border.Objects[4].(*widget.Label)
+			valueLabel := border.Objects[0].(*widget.Label)
 			// 如果值太长，截断显示
 			value := header.Value
 			if len(value) > 80 {
@@ -148,8 +250,10 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 			}
 			valueLabel.SetText(value)
 
-			// 更新复制按钮（右侧）
-			copyBtn := border.Objects[3].(*widget.Button)
+			// 更新剩余有效时间和复制按钮（右侧）
+			rightBox := border.Objects[2].(*fyne.Container)
+			rightBox.Objects[0].(*widget.Label).SetText(expiryText(header.ExpiresAt, time.Now()))
+			copyBtn := rightBox.Objects[1].(*widget.Button)
 			copyBtn.OnTapped = func() {
 				ui.copyToClipboard(header.Value)
 			}
@@ -161,9 +265,16 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 		ui.copyAllKeyTokens()
 	})
 
+	exportBtn := widget.NewButton("导出...", ui.showExportDialog)
+	requestsBtn := widget.NewButton("请求记录", ui.showRequestsDialog)
+
 	clearBtn := widget.NewButton("清空结果", func() {
 		ui.currentResult = nil
+		ui.requests = nil
 		ui.resultList.Refresh()
+		ui.stepLog.Hide()
+		ui.cancelAutoRefresh()
+		ui.refreshLabel.Hide()
 		ui.statusLabel.SetText("结果已清空")
 	})
 
@@ -171,7 +282,7 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 		resultTitle,
 		widget.NewSeparator(),
 		ui.resultList,
-		container.NewHBox(copyAllBtn, clearBtn),
+		container.NewHBox(copyAllBtn, exportBtn, requestsBtn, clearBtn),
 	)
 
 	// 主布局
@@ -183,6 +294,12 @@ func (ui *TokenExtractorUI) MakeUI() fyne.CanvasObject {
 		resultSection,
 	)
 
+	// 每秒刷新倒计时
+	go ui.runCountdown()
+
+	// 站点配置和历史记录文件被外部修改后重新加载
+	ui.watchFiles()
+
 	return container.NewScroll(mainContent)
 }
 
@@ -208,51 +325,120 @@ func (ui *TokenExtractorUI) handleExtract() {
 		return
 	}
 
-	// 禁用按钮，显示进度
+	// 使用当前站点配置的捕获规则，登录地址以输入框为准
+	profile := ui.currentProfile()
+	if err := profile.Validate(); err != nil {
+		dialog.ShowError(errors.New("站点配置无效: "+err.Error()), ui.window)
+		return
+	}
+
+	ui.startExtract(LoginRequest{
+		Username:  username,
+		Password:  password,
+		TargetURL: targetURL,
+		Profile:   profile,
+	}, false)
+}
+
+// startExtract 在后台执行提取并显示结果；auto 表示自动刷新，失败时发送通知并稍后重试
+func (ui *TokenExtractorUI) startExtract(req LoginRequest, auto bool) {
+	ui.cancelAutoRefresh()
+
+	// 禁用按钮，显示进度和取消按钮
 	ui.extractButton.Disable()
+	ui.cancelButton.Enable()
+	ui.cancelButton.Show()
 	ui.progressBar.Show()
-	ui.statusLabel.SetText("正在连接浏览器...")
+	if auto {
+		ui.statusLabel.SetText("🔄 Token即将过期，正在自动重新提取...")
+	} else if req.Profile != nil && req.Profile.UsesHTTP() {
+		ui.statusLabel.SetText("正在发送登录请求...")
+	} else {
+		ui.statusLabel.SetText("正在启动浏览器...")
+	}
+	ui.stepLog.SetText("📝 提取进度:")
+	ui.stepLog.Show()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ui.cancelExtract = cancel
+	ui.capturedCount = 0
+
+	req.OnProgress = func(event ProgressEvent) {
+		fyne.Do(func() {
+			ui.onProgress(event)
+		})
+	}
 
 	// 在goroutine中执行提取
 	go func() {
-		// 创建请求
-		req := LoginRequest{
-			Username:  username,
-			Password:  password,
-			TargetURL: targetURL,
-		}
-
-		// 更新状态
-		ui.updateStatus("正在登录...")
+		defer cancel()
 
 		// 执行提取
-		ctx := context.Background()
 		result, err := ui.extractor.Extract(ctx, req)
 
-		// 更新UI（必须在主线程）
-		ui.window.Canvas().Content().Refresh()
+		// 保存历史（可选）
+		if err == nil && result.Success {
+			ui.saveHistory(req.Username, result)
+		}
 
-		if err != nil {
+		// 更新UI（必须在主线程）
+		fyne.Do(func() {
+			ui.cancelExtract = nil
 			ui.progressBar.Hide()
+			ui.cancelButton.Hide()
 			ui.extractButton.Enable()
-			ui.statusLabel.SetText(fmt.Sprintf("❌ 提取失败: %v", err))
-			dialog.ShowError(err, ui.window)
-			return
-		}
-
-		// 显示结果
-		ui.displayResult(result)
+			if result != nil {
+				ui.requests = result.Requests
+			}
 
-		// 保存历史（可选）
-		if result.Success {
-			ui.saveHistory(username, result)
-		}
+			if errors.Is(err, ErrCanceled) {
+				ui.statusLabel.SetText("⏹ 已取消提取")
+				return
+			}
+			if err != nil {
+				ui.statusLabel.SetText(fmt.Sprintf("❌ 提取失败: %v", err))
+				if auto {
+					ui.onAutoRefreshFailed(req, err)
+				} else {
+					dialog.ShowError(err, ui.window)
+				}
+				return
+			}
 
-		ui.progressBar.Hide()
-		ui.extractButton.Enable()
+			// 显示结果
+			ui.displayResult(result)
+			ui.lastRequest = &req
+			ui.scheduleAutoRefresh()
+		})
 	}()
 }
 
+// handleCancel 取消正在进行的提取，浏览器会被关闭
+func (ui *TokenExtractorUI) handleCancel() {
+	if ui.cancelExtract == nil {
+		return
+	}
+	ui.cancelExtract()
+	ui.cancelButton.Disable()
+	ui.statusLabel.SetText("正在取消...")
+}
+
+// onProgress 显示提取的进度：状态栏显示最新的进度，登录步骤等记录在进度列表中，捕获到的请求只计数
+func (ui *TokenExtractorUI) onProgress(event ProgressEvent) {
+	// 提取已经结束后到达的事件
+	if ui.cancelExtract == nil {
+		return
+	}
+
+	if event.Kind == ProgressRequestCaptured {
+		ui.capturedCount++
+		ui.updateStatus(fmt.Sprintf("📡 已捕获 %d 个匹配的请求", ui.capturedCount))
+		return
+	}
+	ui.updateStatus(event.String())
+	ui.stepLog.SetText(ui.stepLog.Text + "\n" + event.Time.Format("15:04:05") + "  " + event.String())
+}
+
 // updateStatus 更新状态（线程安全）
 func (ui *TokenExtractorUI) updateStatus(status string) {
 	ui.statusLabel.SetText(status)
@@ -277,7 +463,7 @@ func (ui *TokenExtractorUI) displayResult(result *ExtractResult) {
 	})
 
 	ui.resultList.Refresh()
-	ui.statusLabel.SetText(fmt.Sprintf("✅ 提取成功 (%s) - 共捕获 %d 个请求头",
+	ui.statusLabel.SetText(fmt.Sprintf("✅ 提取成功 (%s) - 共捕获 %d 项",
 		result.Timestamp.Format("2006-01-02 15:04:05"),
 		len(result.Headers)))
 }
@@ -317,26 +503,37 @@ func (ui *TokenExtractorUI) saveHistory(username string, result *ExtractResult)
 		return
 	}
 
-	// 提取关键头部
-	keyHeaders := make(map[string]string)
-	for _, header := range result.Headers {
-		if header.IsKey {
-			// 脱敏处理：只保存前后几位
-			value := header.Value
-			if len(value) > 20 {
-				value = value[:8] + "..." + value[len(value)-8:]
-			}
-			keyHeaders[header.Name] = value
-		}
-	}
+	record := NewHistoryRecord(username, result)
 
-	record := HistoryRecord{
-		ID:         fmt.Sprintf("%d", time.Now().Unix()),
-		Timestamp:  result.Timestamp,
-		Username:   username,
-		Success:    result.Success,
-		KeyHeaders: keyHeaders,
+	storage := ui.storage
+	if err := ui.saveQueue.Save(func() error {
+		return storage.SaveHistory(record)
+	}); err != nil && !storage_recovery.Retryable(err) {
+		fyne.Do(func() {
+			dialog.ShowError(errors.New("保存历史记录失败: "+err.Error()), ui.window)
+		})
 	}
+}
 
-	ui.storage.SaveHistory(record)
+// onSaveQueueChanged 在状态栏显示历史记录的保存状态
+func (ui *TokenExtractorUI) onSaveQueueChanged(pending int, err error) {
+	fyne.Do(func() {
+		switch {
+		case pending > 0:
+			ui.statusLabel.SetText(fmt.Sprintf("⚠️ %d 条历史记录保存失败，正在自动重试：%v", pending, err))
+		case err != nil:
+			dialog.ShowError(errors.New("未能保存的历史记录已放弃: "+err.Error()), ui.window)
+		default:
+			ui.statusLabel.SetText("✅ 历史记录已保存")
+		}
+	})
+}
+
+// FlushPendingSaves 立即重试所有保存失败的历史记录，仍未保存时返回错误
+func (ui *TokenExtractorUI) FlushPendingSaves() error {
+	ui.saveQueue.Flush()
+	if pending, err := ui.saveQueue.Pending(); pending > 0 {
+		return err
+	}
+	return nil
 }
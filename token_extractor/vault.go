@@ -0,0 +1,265 @@
+package token_extractor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"my_portfolio/safe_file"
+	"my_portfolio/secure_storage"
+)
+
+// 保存密码的后端
+const (
+	BackendKeyring = "keyring" // 系统钥匙串
+	BackendFile    = "file"    // 主密码加密的文件
+)
+
+// SavedAccount 保存的账号，密码保存在系统钥匙串或加密文件中，这里只记录账号和位置
+type SavedAccount struct {
+	ProfileID string `json:"profile_id"`
+	Username  string `json:"username"`
+	Backend   string `json:"backend"`
+}
+
+// secretKey 密码在后端中的键
+func (a SavedAccount) secretKey() string {
+	return a.ProfileID + "/" + a.Username
+}
+
+// FileSecretStore 使用主密码加密的文件保存密码（键 -> 密码）
+type FileSecretStore struct {
+	file *secure_storage.File
+}
+
+// NewFileSecretStore 创建加密文件后端（初始为锁定状态）
+func NewFileSecretStore(path string) *FileSecretStore {
+	return &FileSecretStore{file: secure_storage.NewFile(path)}
+}
+
+// Name 后端名称
+func (s *FileSecretStore) Name() string {
+	return "加密文件"
+}
+
+// Exists 加密文件是否已经创建（未创建时解锁即设置主密码）
+func (s *FileSecretStore) Exists() bool {
+	_, err := os.Stat(s.file.Path())
+	return err == nil
+}
+
+// Locked 是否尚未输入主密码
+func (s *FileSecretStore) Locked() bool {
+	return s.file.Locked()
+}
+
+// Unlock 使用主密码解锁，文件已存在时验证主密码
+func (s *FileSecretStore) Unlock(master string) error {
+	return s.file.Unlock(master)
+}
+
+// load 读取所有密码，文件不存在时为空
+func (s *FileSecretStore) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	data, err := s.file.Read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return secrets, nil
+		}
+		var recovered *safe_file.RecoveredError
+		if !errors.As(err, &recovered) {
+			return nil, err
+		}
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &secrets); err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
+// save 加密写入所有密码
+func (s *FileSecretStore) save(secrets map[string]string) error {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	return s.file.Write(data)
+}
+
+// Get 读取密码
+func (s *FileSecretStore) Get(key string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+// Set 保存或覆盖密码
+func (s *FileSecretStore) Set(key, secret string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = secret
+	return s.save(secrets)
+}
+
+// Delete 删除密码
+func (s *FileSecretStore) Delete(key string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return s.save(secrets)
+}
+
+// Vault 账号保险箱：优先使用系统钥匙串，不可用时使用主密码加密的文件
+type Vault struct {
+	keyring SecretStore // 系统钥匙串不可用时为 nil
+	file    *FileSecretStore
+
+	keyringFailed bool // 写入系统钥匙串失败过，之后新保存的账号使用加密文件
+}
+
+// NewVault 创建账号保险箱，filePath 为系统钥匙串不可用时使用的加密文件
+func NewVault(filePath string) *Vault {
+	keyring, err := NewSystemKeyring()
+	if err != nil {
+		keyring = nil
+	}
+	return &Vault{
+		keyring: keyring,
+		file:    NewFileSecretStore(filePath),
+	}
+}
+
+// File 返回加密文件后端，用于解锁
+func (v *Vault) File() *FileSecretStore {
+	return v.file
+}
+
+// Backend 新保存的账号使用的后端
+func (v *Vault) Backend() string {
+	if v.keyring != nil && !v.keyringFailed {
+		return BackendKeyring
+	}
+	return BackendFile
+}
+
+// BackendName 后端的显示名称
+func (v *Vault) BackendName(backend string) string {
+	if store, err := v.store(backend); err == nil {
+		return store.Name()
+	}
+	return backend
+}
+
+// NeedsUnlock 读写该后端前是否需要输入主密码
+func (v *Vault) NeedsUnlock(backend string) bool {
+	return backend == BackendFile && v.file.Locked()
+}
+
+// store 返回后端
+func (v *Vault) store(backend string) (SecretStore, error) {
+	switch backend {
+	case BackendKeyring:
+		if v.keyring == nil {
+			return nil, ErrKeyringUnavailable
+		}
+		return v.keyring, nil
+	case BackendFile:
+		return v.file, nil
+	}
+	return nil, errors.New("未知的密码保存位置: " + backend)
+}
+
+// Save 保存账号的密码
+func (v *Vault) Save(account SavedAccount, password string) error {
+	store, err := v.store(account.Backend)
+	if err != nil {
+		return err
+	}
+	if err := store.Set(account.secretKey(), password); err != nil {
+		if account.Backend == BackendKeyring {
+			// 钥匙串可以读取但无法写入（例如被锁定），之后改用加密文件
+			v.keyringFailed = true
+			return fmt.Errorf("%w，再次保存将使用加密文件: %v", ErrKeyringUnavailable, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Password 读取账号的密码
+func (v *Vault) Password(account SavedAccount) (string, error) {
+	store, err := v.store(account.Backend)
+	if err != nil {
+		return "", err
+	}
+	return store.Get(account.secretKey())
+}
+
+// Remove 删除账号的密码
+func (v *Vault) Remove(account SavedAccount) error {
+	store, err := v.store(account.Backend)
+	if err != nil {
+		return err
+	}
+	return store.Delete(account.secretKey())
+}
+
+// AccountsFor 返回站点配置保存的账号
+func (s *ProfileSet) AccountsFor(profileID string) []SavedAccount {
+	var accounts []SavedAccount
+	for _, account := range s.Accounts {
+		if account.ProfileID == profileID {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// FindAccount 查找站点配置保存的账号
+func (s *ProfileSet) FindAccount(profileID, username string) (SavedAccount, bool) {
+	for _, account := range s.Accounts {
+		if account.ProfileID == profileID && account.Username == username {
+			return account, true
+		}
+	}
+	return SavedAccount{}, false
+}
+
+// PutAccount 添加或替换保存的账号
+func (s *ProfileSet) PutAccount(account SavedAccount) {
+	for i := range s.Accounts {
+		if s.Accounts[i].ProfileID == account.ProfileID && s.Accounts[i].Username == account.Username {
+			s.Accounts[i] = account
+			return
+		}
+	}
+	s.Accounts = append(s.Accounts, account)
+}
+
+// RemoveAccount 删除保存的账号记录
+func (s *ProfileSet) RemoveAccount(profileID, username string) {
+	accounts := s.Accounts[:0]
+	for _, account := range s.Accounts {
+		if account.ProfileID != profileID || account.Username != username {
+			accounts = append(accounts, account)
+		}
+	}
+	s.Accounts = accounts
+}
@@ -0,0 +1,173 @@
+package token_extractor
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createAccountBar 创建已保存账号的选择栏
+func (ui *TokenExtractorUI) createAccountBar() fyne.CanvasObject {
+	ui.accountSelect = widget.NewSelect(nil, ui.onAccountSelected)
+	ui.accountSelect.PlaceHolder = "选择已保存的账号"
+	ui.refreshAccounts()
+
+	saveButton := widget.NewButton("保存账号", ui.saveAccount)
+	deleteButton := widget.NewButton("删除账号", ui.deleteAccount)
+	batchButton := widget.NewButton("批量提取", ui.showBatchDialog)
+
+	return container.NewBorder(nil, nil, widget.NewLabel("已保存:"),
+		container.NewHBox(saveButton, deleteButton, batchButton), ui.accountSelect)
+}
+
+// refreshAccounts 刷新当前站点配置保存的账号
+func (ui *TokenExtractorUI) refreshAccounts() {
+	if ui.accountSelect == nil {
+		return
+	}
+
+	var names []string
+	for _, account := range ui.profiles.AccountsFor(ui.currentProfile().ID) {
+		names = append(names, account.Username)
+	}
+	ui.accountSelect.Options = names
+	ui.accountSelect.ClearSelected()
+	ui.accountSelect.Refresh()
+}
+
+// onAccountSelected 选择账号后填入账号和密码
+func (ui *TokenExtractorUI) onAccountSelected(username string) {
+	account, ok := ui.profiles.FindAccount(ui.currentProfile().ID, username)
+	if !ok {
+		return
+	}
+
+	ui.withVault(account.Backend, func() {
+		password, err := ui.vault.Password(account)
+		if err != nil {
+			dialog.ShowError(errors.New("读取保存的密码失败: "+err.Error()), ui.window)
+			return
+		}
+		ui.usernameEntry.SetText(account.Username)
+		ui.passwordEntry.SetText(password)
+	})
+}
+
+// saveAccount 保存当前输入的账号和密码
+func (ui *TokenExtractorUI) saveAccount() {
+	username := ui.usernameEntry.Text
+	password := ui.passwordEntry.Text
+	if username == "" || password == "" {
+		dialog.ShowError(errors.New("请先输入账号和密码"), ui.window)
+		return
+	}
+
+	profile := ui.currentProfile()
+	account := SavedAccount{
+		ProfileID: profile.ID,
+		Username:  username,
+		Backend:   ui.vault.Backend(),
+	}
+
+	ui.withVault(account.Backend, func() {
+		// 之前保存在其他位置时先删除旧密码
+		if old, ok := ui.profiles.FindAccount(profile.ID, username); ok && old.Backend != account.Backend {
+			ui.vault.Remove(old)
+		}
+		if err := ui.vault.Save(account, password); err != nil {
+			dialog.ShowError(errors.New("保存密码失败: "+err.Error()), ui.window)
+			return
+		}
+
+		ui.profiles.PutAccount(account)
+		if err := ui.saveProfiles(); err != nil {
+			dialog.ShowError(errors.New("保存账号列表失败: "+err.Error()), ui.window)
+			return
+		}
+		ui.refreshAccounts()
+		ui.statusLabel.SetText(fmt.Sprintf("✅ 账号 %s 已保存到%s", username, ui.vault.BackendName(account.Backend)))
+	})
+}
+
+// deleteAccount 确认后删除选中的账号
+func (ui *TokenExtractorUI) deleteAccount() {
+	account, ok := ui.profiles.FindAccount(ui.currentProfile().ID, ui.accountSelect.Selected)
+	if !ok {
+		dialog.ShowError(errors.New("请先选择要删除的账号"), ui.window)
+		return
+	}
+
+	dialog.ShowConfirm("确认删除", fmt.Sprintf("确定要删除保存的账号 %s 吗？", account.Username), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		ui.withVault(account.Backend, func() {
+			if err := ui.vault.Remove(account); err != nil {
+				dialog.ShowError(errors.New("删除保存的密码失败: "+err.Error()), ui.window)
+				return
+			}
+			ui.profiles.RemoveAccount(account.ProfileID, account.Username)
+			if err := ui.saveProfiles(); err != nil {
+				dialog.ShowError(errors.New("保存账号列表失败: "+err.Error()), ui.window)
+			}
+			ui.refreshAccounts()
+		})
+	}, ui.window)
+}
+
+// removeProfileAccounts 删除站点配置保存的所有密码；加密文件未解锁时保留（无法读取，不影响使用）
+func (ui *TokenExtractorUI) removeProfileAccounts(profileID string) {
+	for _, account := range ui.profiles.AccountsFor(profileID) {
+		if !ui.vault.NeedsUnlock(account.Backend) {
+			ui.vault.Remove(account)
+		}
+	}
+}
+
+// withVault 确保后端可用后执行 fn；加密文件未解锁时先输入主密码（第一次使用时设置主密码）
+func (ui *TokenExtractorUI) withVault(backend string, fn func()) {
+	if !ui.vault.NeedsUnlock(backend) {
+		fn()
+		return
+	}
+
+	file := ui.vault.File()
+	creating := !file.Exists()
+
+	passwordEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
+	items := []*widget.FormItem{{Text: "主密码", Widget: passwordEntry}}
+
+	title := "解锁保存的账号"
+	message := "系统钥匙串不可用，账号密码保存在使用主密码加密的文件中"
+	if creating {
+		title = "设置主密码"
+		message += "。主密码无法找回，忘记后需要重新保存账号"
+		items = append(items, &widget.FormItem{Text: "确认主密码", Widget: confirmEntry})
+	}
+	hint := widget.NewLabel(message)
+	hint.Wrapping = fyne.TextWrapWord
+	items = append([]*widget.FormItem{{Widget: hint}}, items...)
+
+	d := dialog.NewForm(title, "确定", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if creating && passwordEntry.Text != confirmEntry.Text {
+			dialog.ShowError(errors.New("两次输入的主密码不一致"), ui.window)
+			return
+		}
+		if err := file.Unlock(passwordEntry.Text); err != nil {
+			dialog.ShowError(errors.New("解锁失败: "+err.Error()), ui.window)
+			return
+		}
+		fn()
+	}, ui.window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+	ui.window.Canvas().Focus(passwordEntry)
+}
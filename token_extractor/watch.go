@@ -0,0 +1,84 @@
+package token_extractor
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+)
+
+// watchFiles 监视站点配置文件和JSON历史记录文件；再次调用时先停止之前的监视
+func (ui *TokenExtractorUI) watchFiles() {
+	ui.StopWatching()
+
+	ui.stopWatches = append(ui.stopWatches, file_watch.Watch(ui.profileStorage.filePath, func() {
+		fyne.Do(ui.reloadProfilesIfChanged)
+	}))
+
+	// 历史记录每次保存时都会重新读取文件，不需要重新加载；
+	// 文件被修复或从备份恢复后立即重试保存失败的记录
+	if storage, ok := ui.storage.(*JSONStorage); ok {
+		ui.stopWatches = append(ui.stopWatches, file_watch.Watch(storage.filePath, ui.retryPendingSaves))
+	}
+}
+
+// StopWatching 停止监视文件，在窗口关闭时调用
+func (ui *TokenExtractorUI) StopWatching() {
+	for _, stop := range ui.stopWatches {
+		stop()
+	}
+	ui.stopWatches = nil
+}
+
+// reloadProfilesIfChanged 站点配置文件内容与上次读写时不同时重新加载并刷新站点和账号列表
+func (ui *TokenExtractorUI) reloadProfilesIfChanged() {
+	if !ui.profileStorage.Guard().Changed() {
+		return
+	}
+	ui.reloadProfiles()
+	ui.statusLabel.SetText("站点配置已被其他程序修改，已重新加载")
+}
+
+// reloadProfiles 重新加载站点配置并刷新站点和账号列表，保留当前选中的站点
+func (ui *TokenExtractorUI) reloadProfiles() {
+	selected := ui.currentProfile().ID
+	ui.loadProfiles()
+	ui.refreshProfileSelect()
+	if profile := ui.currentProfile(); profile.ID != selected {
+		ui.urlEntry.SetText(profile.TargetURL)
+	}
+	ui.refreshAccounts()
+	if ui.autoRefresh != nil {
+		ui.autoRefresh.SetChecked(ui.profiles.AutoRefresh)
+	}
+}
+
+// confirmOverwriteProfiles 站点配置文件在读取后被其他程序修改时，询问覆盖还是重新加载
+func (ui *TokenExtractorUI) confirmOverwriteProfiles() {
+	message := widget.NewLabel("站点配置文件在本程序读取之后被其他程序修改。\n" +
+		"覆盖：用当前的站点配置和账号列表覆盖文件中的内容\n" +
+		"重新加载：读取文件中的最新内容（本次修改将丢失）")
+
+	dialog.NewCustomConfirm("⚠️ 站点配置文件已被修改", "覆盖", "重新加载", message, func(overwrite bool) {
+		if !overwrite {
+			ui.reloadProfiles()
+			ui.statusLabel.SetText("已重新加载站点配置")
+			return
+		}
+
+		ui.profileStorage.Guard().Forget()
+		if err := ui.saveProfiles(); err != nil {
+			dialog.ShowError(errors.New("保存站点配置失败: "+err.Error()), ui.window)
+		}
+	}, ui.window).Show()
+}
+
+// retryPendingSaves 有保存失败的历史记录时立即重试
+func (ui *TokenExtractorUI) retryPendingSaves() {
+	if pending, _ := ui.saveQueue.Pending(); pending > 0 {
+		ui.saveQueue.Flush()
+	}
+}
@@ -0,0 +1,217 @@
+package weight_tracker
+
+import (
+	"errors"
+	"fmt"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/local_api"
+	"my_portfolio/storage_recovery"
+)
+
+// apiInput 通过本地 API 添加或修改体重记录的请求体，修改时省略的字段保持不变
+type apiInput struct {
+	Weight *float64 `json:"weight"`
+	Date   *string  `json:"date"`
+}
+
+// apiRecords 通过本地 API 读写界面中的体重记录
+type apiRecords struct {
+	ui *WeightTrackerUI
+}
+
+// APIResource 返回通过本地 API 提供的体重记录
+func (ui *WeightTrackerUI) APIResource() local_api.Resource {
+	return local_api.Resource{
+		Name:       "weight",
+		Title:      "体重记录",
+		Collection: apiRecords{ui: ui},
+		Record: local_api.Schema{
+			"type": "object",
+			"properties": local_api.Schema{
+				"id":          local_api.Schema{"type": "string"},
+				"weight":      local_api.Schema{"type": "number", "description": "体重（kg）"},
+				"date":        local_api.Schema{"type": "string", "format": "date-time"},
+				"change":      local_api.Schema{"type": "number", "description": "与上一条记录相比的变化（kg）"},
+				"change_type": local_api.Schema{"type": "string", "enum": []string{"first", "increase", "decrease", "stable"}},
+			},
+		},
+		Input: local_api.Schema{
+			"type":     "object",
+			"required": []string{"weight"},
+			"properties": local_api.Schema{
+				"weight": local_api.Schema{"type": "number", "minimum": 20, "maximum": 300},
+				"date":   local_api.Schema{"type": "string", "description": "YYYY-MM-DD 或 RFC 3339，默认为当前时间"},
+			},
+		},
+		Stats: local_api.Schema{
+			"type": "object",
+			"properties": local_api.Schema{
+				"total_records":  local_api.Schema{"type": "integer"},
+				"current_weight": local_api.Schema{"type": "number"},
+				"start_weight":   local_api.Schema{"type": "number"},
+				"total_change":   local_api.Schema{"type": "number"},
+				"highest_weight": local_api.Schema{"type": "number"},
+				"lowest_weight":  local_api.Schema{"type": "number"},
+			},
+		},
+	}
+}
+
+// available 加密存储未解锁或加载失败时不能通过 API 访问
+func (a apiRecords) available() error {
+	if a.ui.Locked() {
+		return fmt.Errorf("%w: 体重记录已加密，请先在程序中解锁", local_api.ErrUnavailable)
+	}
+	if a.ui.loadErr != nil {
+		return fmt.Errorf("%w: 体重记录加载失败: %v", local_api.ErrUnavailable, a.ui.loadErr)
+	}
+	return nil
+}
+
+// List 返回所有记录，最新的在前
+func (a apiRecords) List() (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+	return append([]WeightRecord{}, a.ui.records...), nil
+}
+
+// Create 添加记录
+func (a apiRecords) Create(body []byte) (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+
+	var input apiInput
+	if err := local_api.DecodeInput(body, &input); err != nil {
+		return nil, err
+	}
+	if input.Weight == nil {
+		return nil, local_api.BadRequest(errors.New("请输入体重值"))
+	}
+	if err := ValidateWeight(*input.Weight); err != nil {
+		return nil, local_api.BadRequest(err)
+	}
+
+	record := *NewWeightRecord(*input.Weight, nil)
+	if input.Date != nil {
+		date, err := local_api.ParseDate(*input.Date)
+		if err != nil {
+			return nil, err
+		}
+		record.Date = date
+	}
+
+	before := append([]WeightRecord(nil), a.ui.records...)
+	a.ui.records = InsertRecord(a.ui.records, record)
+	err := a.commit(before)
+	return a.find(record.ID), err
+}
+
+// Update 修改记录的体重或时间，并重新计算变化量
+func (a apiRecords) Update(id string, body []byte) (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+
+	var input apiInput
+	if err := local_api.DecodeInput(body, &input); err != nil {
+		return nil, err
+	}
+
+	record := a.find(id)
+	if record == nil {
+		return nil, local_api.ErrNotFound
+	}
+	updated := *record
+	if input.Weight != nil {
+		if err := ValidateWeight(*input.Weight); err != nil {
+			return nil, local_api.BadRequest(err)
+		}
+		updated.Weight = *input.Weight
+	}
+	if input.Date != nil {
+		date, err := local_api.ParseDate(*input.Date)
+		if err != nil {
+			return nil, err
+		}
+		updated.Date = date
+	}
+
+	before := append([]WeightRecord(nil), a.ui.records...)
+	a.ui.records = InsertRecord(removeRecord(a.ui.records, id), updated)
+	err := a.commit(before)
+	return a.find(id), err
+}
+
+// Delete 删除记录
+func (a apiRecords) Delete(id string) error {
+	if err := a.available(); err != nil {
+		return err
+	}
+	if a.find(id) == nil {
+		return local_api.ErrNotFound
+	}
+
+	before := append([]WeightRecord(nil), a.ui.records...)
+	a.ui.records = removeRecord(a.ui.records, id)
+	recalculateChanges(a.ui.records)
+	return a.commit(before)
+}
+
+// Stats 返回统计信息
+func (a apiRecords) Stats() (any, error) {
+	if err := a.available(); err != nil {
+		return nil, err
+	}
+	return CalculateStats(a.ui.records), nil
+}
+
+// find 按ID查找记录，返回副本
+func (a apiRecords) find(id string) *WeightRecord {
+	for _, record := range a.ui.records {
+		if record.ID == id {
+			return &record
+		}
+	}
+	return nil
+}
+
+// commit 保存修改后的记录并刷新界面。
+// 保存失败但可以重试时修改保留在队列中；无法重试时恢复修改前的记录，数据文件被其他程序修改时重新加载。
+func (a apiRecords) commit(before []WeightRecord) error {
+	ui := a.ui
+	storage := ui.storage
+	records := append([]WeightRecord(nil), ui.records...)
+
+	err := ui.saveQueue.Save(func() error {
+		return storage.Save(records)
+	})
+	if err != nil && !storage_recovery.Retryable(err) {
+		ui.records = before
+		if errors.Is(err, file_watch.ErrChanged) {
+			ui.reloadIfChanged()
+			err = fmt.Errorf("%w，已重新加载，请重试", err)
+		}
+		ui.refreshAll()
+		return err
+	}
+
+	ui.refreshAll()
+	if err != nil {
+		return fmt.Errorf("%w: %v", local_api.ErrSavePending, err)
+	}
+	return nil
+}
+
+// removeRecord 返回删除指定记录后的列表
+func removeRecord(records []WeightRecord, id string) []WeightRecord {
+	result := make([]WeightRecord, 0, len(records))
+	for _, record := range records {
+		if record.ID != id {
+			result = append(result, record)
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package weight_tracker
+
+import (
+	"errors"
+
+	"my_portfolio/secure_storage"
+)
+
+const (
+	// ToolID 工具标识，用于配置文件
+	ToolID = "weight_tracker"
+
+	// DefaultDataFile 默认数据文件名
+	DefaultDataFile = "weight_records.json"
+)
+
+// ErrEncryptionUnsupported 当前存储不支持加密
+var ErrEncryptionUnsupported = errors.New("SQLite 数据库存储暂不支持加密，请先在设置中切换回 JSON 文件存储")
+
+// Locked 加密存储是否尚未解锁
+func (ui *WeightTrackerUI) Locked() bool {
+	storage, ok := ui.storage.(*EncryptedStorage)
+	return ok && storage.Locked()
+}
+
+// Unlock 使用密码解锁加密存储并加载数据
+func (ui *WeightTrackerUI) Unlock(passphrase string) error {
+	storage, ok := ui.storage.(*EncryptedStorage)
+	if !ok {
+		return nil
+	}
+
+	if err := storage.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	ui.loadRecords()
+	ui.refreshAll()
+	return nil
+}
+
+// EncryptionEnabled 当前是否使用加密存储
+func (ui *WeightTrackerUI) EncryptionEnabled() bool {
+	_, ok := ui.storage.(*EncryptedStorage)
+	return ok
+}
+
+// EnableEncryption 将现有数据文件转换为加密格式并切换到加密存储
+func (ui *WeightTrackerUI) EnableEncryption(passphrase string) error {
+	if ui.EncryptionEnabled() {
+		return nil
+	}
+	if _, ok := ui.storage.(*SQLiteStorage); ok {
+		return ErrEncryptionUnsupported
+	}
+
+	if err := secure_storage.EncryptFile(ui.storagePath, passphrase); err != nil {
+		return err
+	}
+
+	storage := NewEncryptedStorage(ui.storagePath)
+	if err := storage.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	ui.storage = storage
+	return nil
+}
+
+// DisableEncryption 将加密数据文件转换回明文并切换到普通存储
+func (ui *WeightTrackerUI) DisableEncryption(passphrase string) error {
+	if !ui.EncryptionEnabled() {
+		return nil
+	}
+
+	if err := secure_storage.DecryptFile(ui.storagePath, passphrase); err != nil && err != secure_storage.ErrNotEncrypted {
+		return err
+	}
+
+	ui.storage = NewJSONStorage(ui.storagePath)
+	return nil
+}
+
+// refreshAll 重新加载数据后刷新整个界面
+func (ui *WeightTrackerUI) refreshAll() {
+	if ui.mainContent == nil {
+		return
+	}
+
+	ui.updateStats()
+	ui.updateListContainer()
+	ui.mainContent.Objects[0] = ui.listContainer
+	ui.mainContent.Refresh()
+
+	if ui.recordList != nil {
+		ui.recordList.Refresh()
+	}
+}
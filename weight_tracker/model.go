@@ -1,7 +1,9 @@
 package weight_tracker
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -49,6 +51,44 @@ func NewWeightRecord(weight float64, previousRecord *WeightRecord) *WeightRecord
 	return record
 }
 
+// ValidateWeight 校验体重值是否有效
+func ValidateWeight(weight float64) error {
+	if weight <= 0 {
+		return errors.New("体重必须大于 0")
+	}
+	if weight < 20 || weight > 300 {
+		return errors.New("请输入合理的体重值 (20-300 kg)")
+	}
+	return nil
+}
+
+// InsertRecord 按日期将记录插入倒序排列的列表，并重新计算变化量
+func InsertRecord(records []WeightRecord, record WeightRecord) []WeightRecord {
+	index := sort.Search(len(records), func(i int) bool {
+		return !records[i].Date.After(record.Date)
+	})
+
+	inserted := make([]WeightRecord, 0, len(records)+1)
+	inserted = append(inserted, records[:index]...)
+	inserted = append(inserted, record)
+	inserted = append(inserted, records[index:]...)
+
+	recalculateChanges(inserted)
+	return inserted
+}
+
+// recalculateChanges 按倒序排列的记录重新计算每条记录相对前一条的变化
+func recalculateChanges(records []WeightRecord) {
+	for i := range records {
+		if i == len(records)-1 {
+			records[i].Change = 0
+			records[i].ChangeType = "first"
+			continue
+		}
+		records[i].Change, records[i].ChangeType = CalculateChange(records[i].Weight, records[i+1].Weight)
+	}
+}
+
 // FormatChange 格式化变化显示文本
 func (r *WeightRecord) FormatChange() string {
 	switch r.ChangeType {
@@ -72,12 +112,12 @@ func (r *WeightRecord) FormatDate() string {
 
 // WeightStats 体重统计信息
 type WeightStats struct {
-	TotalRecords  int
-	CurrentWeight float64
-	StartWeight   float64
-	TotalChange   float64
-	HighestWeight float64
-	LowestWeight  float64
+	TotalRecords  int     `json:"total_records"`
+	CurrentWeight float64 `json:"current_weight"`
+	StartWeight   float64 `json:"start_weight"`
+	TotalChange   float64 `json:"total_change"`
+	HighestWeight float64 `json:"highest_weight"`
+	LowestWeight  float64 `json:"lowest_weight"`
 }
 
 // CalculateStats 计算统计信息
@@ -0,0 +1,134 @@
+package weight_tracker
+
+import (
+	"database/sql"
+	"os"
+
+	"my_portfolio/sqlite_storage"
+)
+
+// migrations 体重记录的数据库结构迁移
+var migrations = []sqlite_storage.Migration{
+	{
+		Version:     1,
+		Description: "创建体重记录表",
+		SQL: `CREATE TABLE weight_records (
+			id          TEXT    PRIMARY KEY,
+			position    INTEGER NOT NULL,
+			weight      REAL    NOT NULL,
+			date        TEXT    NOT NULL,
+			change      REAL    NOT NULL,
+			change_type TEXT    NOT NULL
+		);
+		CREATE INDEX idx_weight_records_position ON weight_records (position);`,
+	},
+}
+
+// SQLiteStorage SQLite数据库存储实现
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage 创建SQLite存储并执行数据库结构迁移
+func NewSQLiteStorage(db *sql.DB) (*SQLiteStorage, error) {
+	if err := sqlite_storage.Migrate(db, ToolID, migrations); err != nil {
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Load 从数据库加载记录（保持保存时的顺序）
+func (s *SQLiteStorage) Load() ([]WeightRecord, error) {
+	rows, err := s.db.Query(`SELECT id, weight, date, change, change_type FROM weight_records ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []WeightRecord{}
+	for rows.Next() {
+		var record WeightRecord
+		var date string
+		if err := rows.Scan(&record.ID, &record.Weight, &date, &record.Change, &record.ChangeType); err != nil {
+			return nil, err
+		}
+		if record.Date, err = sqlite_storage.ParseTime(date); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// Save 保存记录：只写入新增或变化的记录，并删除已不存在的记录
+func (s *SQLiteStorage) Save(records []WeightRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows := make([][]any, 0, len(records))
+	for i, record := range records {
+		rows = append(rows, []any{
+			record.ID, i, record.Weight, sqlite_storage.FormatTime(record.Date), record.Change, record.ChangeType,
+		})
+	}
+
+	columns := []string{"id", "position", "weight", "date", "change", "change_type"}
+	if err := sqlite_storage.SyncRows(tx, "weight_records", columns, rows); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ExportJSON 将数据库中的记录导出到JSON数据文件并清空数据库中的记录，返回导出的记录数。
+// 数据库中没有记录时不修改JSON文件
+func (s *SQLiteStorage) ExportJSON(path string) (int, error) {
+	records, err := s.Load()
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	if err := NewJSONStorage(path).Save(records); err != nil {
+		return 0, err
+	}
+	if err := s.Save([]WeightRecord{}); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// ImportJSON 将JSON数据文件中的记录导入数据库。
+// 只在数据库中还没有体重记录时导入，返回导入的记录数；JSON文件保持不变。
+func (s *SQLiteStorage) ImportJSON(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM weight_records`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	records, err := NewJSONStorage(path).Load()
+	if err != nil && !isRecovered(err) {
+		return 0, err
+	}
+
+	if err := s.Save(records); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
@@ -2,9 +2,22 @@ package weight_tracker
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/safe_file"
+	"my_portfolio/schema"
+	"my_portfolio/secure_storage"
 )
 
+// CurrentSchemaVersion 体重记录文件的当前结构版本
+const CurrentSchemaVersion = 1
+
+// dataSchema 体重记录文件的迁移注册表，修改 WeightRecord 的结构时在这里注册新的迁移
+var dataSchema = schema.NewRegistry("体重记录", CurrentSchemaVersion).
+	Register(0, "为旧版数据文件添加版本信息", schema.Keep)
+
 // Storage 存储接口
 type Storage interface {
 	Load() ([]WeightRecord, error)
@@ -14,12 +27,14 @@ type Storage interface {
 // JSONStorage JSON文件存储实现
 type JSONStorage struct {
 	filepath string
+	guard    *file_watch.Guard
 }
 
 // NewJSONStorage 创建新的JSON存储
 func NewJSONStorage(filepath string) *JSONStorage {
 	return &JSONStorage{
 		filepath: filepath,
+		guard:    file_watch.NewGuard(filepath),
 	}
 }
 
@@ -28,23 +43,74 @@ func (s *JSONStorage) Load() ([]WeightRecord, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(s.filepath); os.IsNotExist(err) {
 		// 文件不存在，返回空列表
+		s.guard.Remember()
 		return []WeightRecord{}, nil
 	}
 
-	// 读取文件
-	data, err := os.ReadFile(s.filepath)
-	if err != nil {
+	// 读取文件（主文件损坏时自动回退到最近的可用备份）
+	data, err := safe_file.ReadFile(s.filepath, validateRecords)
+	if err != nil && !isRecovered(err) {
 		return nil, err
 	}
 
-	// 如果文件为空，返回空列表
+	// 加密文件不能按明文读取
+	if secure_storage.IsEncrypted(data) {
+		return nil, secure_storage.ErrLocked
+	}
+
+	// 旧版本文件先备份再升级到当前版本
+	payload, upgradeErr := dataSchema.UpgradeFile(s.filepath, data, 0644)
+	if upgradeErr != nil {
+		return nil, upgradeErr
+	}
+
+	records, decodeErr := decodeRecords(payload)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	s.guard.Remember()
+	return records, err
+}
+
+// Save 保存记录到JSON文件
+func (s *JSONStorage) Save(records []WeightRecord) error {
+	// 不覆盖由更新版本程序写入的文件
+	if err := dataSchema.CheckWritable(s.filepath); err != nil {
+		return err
+	}
+
+	// 不覆盖读取之后被其他程序修改的文件
+	if err := s.guard.Check(); err != nil {
+		return err
+	}
+
+	// 序列化为带版本信息的JSON
+	data, err := dataSchema.Encode(records)
+	if err != nil {
+		return err
+	}
+
+	// 原子写入文件，并保留历史版本
+	err = safe_file.WriteFile(s.filepath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	s.guard.Remember()
+	return nil
+}
+
+// decodeRecords 解析已升级到当前版本的记录列表
+func decodeRecords(data []byte) ([]WeightRecord, error) {
+	// 如果内容为空，返回空列表
 	if len(data) == 0 {
 		return []WeightRecord{}, nil
 	}
 
 	// 解析JSON
 	var records []WeightRecord
-	err = json.Unmarshal(data, &records)
+	err := json.Unmarshal(data, &records)
 	if err != nil {
 		return nil, err
 	}
@@ -52,19 +118,112 @@ func (s *JSONStorage) Load() ([]WeightRecord, error) {
 	return records, nil
 }
 
-// Save 保存记录到JSON文件
-func (s *JSONStorage) Save(records []WeightRecord) error {
-	// 序列化为JSON
-	data, err := json.MarshalIndent(records, "", "  ")
+// Guard 返回数据文件的外部修改检查
+func (s *JSONStorage) Guard() *file_watch.Guard {
+	return s.guard
+}
+
+// validateRecords 校验记录文件内容是否可用
+func validateRecords(data []byte) error {
+	if secure_storage.IsEncrypted(data) {
+		return nil
+	}
+	var records []WeightRecord
+	return dataSchema.Decode(data, &records)
+}
+
+// isRecovered 判断错误是否表示已从备份恢复（数据仍可使用）
+func isRecovered(err error) bool {
+	var recovered *safe_file.RecoveredError
+	return errors.As(err, &recovered)
+}
+
+// EncryptedStorage 加密JSON文件存储实现
+type EncryptedStorage struct {
+	file  *secure_storage.File
+	guard *file_watch.Guard
+}
+
+// NewEncryptedStorage 创建新的加密存储（需要先调用 Unlock）
+func NewEncryptedStorage(filepath string) *EncryptedStorage {
+	return &EncryptedStorage{
+		file:  secure_storage.NewFile(filepath),
+		guard: file_watch.NewGuard(filepath),
+	}
+}
+
+// Unlock 使用密码解锁存储
+func (s *EncryptedStorage) Unlock(passphrase string) error {
+	return s.file.Unlock(passphrase)
+}
+
+// Locked 是否尚未解锁
+func (s *EncryptedStorage) Locked() bool {
+	return s.file.Locked()
+}
+
+// Load 解密并加载记录
+func (s *EncryptedStorage) Load() ([]WeightRecord, error) {
+	data, err := s.file.Read()
+	if err != nil && !isRecovered(err) {
+		if os.IsNotExist(err) {
+			s.guard.Remember()
+			return []WeightRecord{}, nil
+		}
+		return nil, err
+	}
+
+	payload, upgradeErr := s.upgrade(data)
+	if upgradeErr != nil {
+		return nil, upgradeErr
+	}
+
+	records, decodeErr := decodeRecords(payload)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	s.guard.Remember()
+	return records, err
+}
+
+// upgrade 将旧版本的数据升级到当前版本，升级前写入加密备份，升级后立即保存
+func (s *EncryptedStorage) upgrade(data []byte) ([]byte, error) {
+	payload, migrated, err := dataSchema.Upgrade(data, func(version int, original []byte) error {
+		return s.file.WriteCopy(schema.BackupPath(s.file.Path(), version), original)
+	})
+	if err != nil || !migrated {
+		return payload, err
+	}
+
+	upgraded, err := dataSchema.Wrap(payload)
 	if err != nil {
+		return nil, err
+	}
+	return payload, s.file.Write(upgraded)
+}
+
+// Save 加密并保存记录
+func (s *EncryptedStorage) Save(records []WeightRecord) error {
+	// 不覆盖读取之后被其他程序修改的文件
+	if err := s.guard.Check(); err != nil {
 		return err
 	}
 
-	// 写入文件
-	err = os.WriteFile(s.filepath, data, 0644)
+	data, err := dataSchema.Encode(records)
 	if err != nil {
 		return err
 	}
 
+	if err := s.file.Write(data); err != nil {
+		return err
+	}
+
+	s.guard.Remember()
 	return nil
 }
+
+// Guard 返回数据文件的外部修改检查
+func (s *EncryptedStorage) Guard() *file_watch.Guard {
+	return s.guard
+}
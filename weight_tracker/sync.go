@@ -0,0 +1,94 @@
+package weight_tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"my_portfolio/storage_recovery"
+)
+
+// ErrSyncEncrypted 加密数据不能以明文同步
+var ErrSyncEncrypted = errors.New("体重记录已加密，不能通过文件夹同步")
+
+// syncRecord 同步的体重记录内容；变化量由记录顺序推导，不参与同步
+type syncRecord struct {
+	ID     string    `json:"id"`
+	Weight float64   `json:"weight"`
+	Date   time.Time `json:"date"`
+}
+
+// SyncRecords 返回用于同步的记录（记录ID -> 内容）
+func (ui *WeightTrackerUI) SyncRecords() (map[string]json.RawMessage, error) {
+	if ui.EncryptionEnabled() {
+		return nil, ErrSyncEncrypted
+	}
+	// 加载失败时的空数据不能参与同步，否则会删除其他设备上的记录
+	if ui.loadErr != nil {
+		return nil, errors.New("体重记录加载失败，修复后才能同步: " + ui.loadErr.Error())
+	}
+
+	records := make(map[string]json.RawMessage, len(ui.records))
+	for _, record := range ui.records {
+		data, err := json.Marshal(syncRecord{
+			ID:     record.ID,
+			Weight: record.Weight,
+			Date:   record.Date,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records[record.ID] = data
+	}
+	return records, nil
+}
+
+// ApplySyncRecords 用同步合并后的记录替换本地数据，重新计算变化量后保存并刷新界面
+func (ui *WeightTrackerUI) ApplySyncRecords(records map[string]json.RawMessage) error {
+	merged := make([]WeightRecord, 0, len(records))
+	for _, data := range records {
+		var record syncRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		merged = append(merged, WeightRecord{
+			ID:     record.ID,
+			Weight: record.Weight,
+			Date:   record.Date,
+		})
+	}
+
+	// 保持倒序（最新的记录在前）
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Date.After(merged[j].Date)
+	})
+	recalculateChanges(merged)
+
+	// 通过保存队列写入，避免仍在重试的旧数据覆盖合并结果；可以重试的失败保留在队列中
+	storage := ui.storage
+	saved := append([]WeightRecord(nil), merged...)
+	if err := ui.saveQueue.Save(func() error {
+		return storage.Save(saved)
+	}); err != nil && !storage_recovery.Retryable(err) {
+		return err
+	}
+
+	ui.records = merged
+	ui.refreshAll()
+	return nil
+}
+
+// DescribeSyncRecord 返回记录的简短描述，用于同步冲突对话框
+func (ui *WeightTrackerUI) DescribeSyncRecord(key string, data json.RawMessage) string {
+	if data == nil {
+		return "（已删除）"
+	}
+
+	var record syncRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return key
+	}
+	return fmt.Sprintf("%s  %.1f kg", record.Date.Format("2006-01-02 15:04"), record.Weight)
+}
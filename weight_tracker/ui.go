@@ -14,11 +14,16 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+	"my_portfolio/safe_file"
+	"my_portfolio/storage_recovery"
 )
 
 // WeightTrackerUI 体重记录UI
 type WeightTrackerUI struct {
 	storage        Storage
+	storagePath    string
 	records        []WeightRecord
 	weightEntry    *widget.Entry
 	recordList     *widget.List
@@ -31,14 +36,49 @@ type WeightTrackerUI struct {
 	recordCount    *canvas.Text
 	highestWeight  *canvas.Text
 	lowestWeight   *canvas.Text
+
+	// 加载失败时的错误，修复前不保存，避免空数据覆盖原文件
+	loadErr error
+	// 保存失败后在后台重试
+	saveQueue       *storage_recovery.SaveQueue
+	saveStatus      *fyne.Container
+	saveStatusLabel *widget.Label
+
+	// 停止监视数据文件，未监视时为 nil
+	stopWatch func()
+}
+
+// Options 体重记录UI的创建选项
+type Options struct {
+	DataPath  string  // 数据文件路径，为空时使用默认文件名
+	Encrypted bool    // 是否使用加密存储（需要解锁后才能加载数据）
+	Storage   Storage // 自定义存储（例如SQLite），非空且未启用加密时使用
 }
 
 // NewWeightTrackerUI 创建新的体重记录UI
 func NewWeightTrackerUI(window fyne.Window) *WeightTrackerUI {
+	return NewWeightTrackerUIWithOptions(window, Options{})
+}
+
+// NewWeightTrackerUIWithOptions 按选项创建体重记录UI
+func NewWeightTrackerUIWithOptions(window fyne.Window, opts Options) *WeightTrackerUI {
+	if opts.DataPath == "" {
+		opts.DataPath = DefaultDataFile
+	}
+
+	var storage Storage = NewJSONStorage(opts.DataPath)
+	if opts.Encrypted {
+		storage = NewEncryptedStorage(opts.DataPath)
+	} else if opts.Storage != nil {
+		storage = opts.Storage
+	}
+
 	ui := &WeightTrackerUI{
-		storage: NewJSONStorage("weight_records.json"),
-		window:  window,
+		storage:     storage,
+		storagePath: opts.DataPath,
+		window:      window,
 	}
+	ui.saveQueue = storage_recovery.NewSaveQueue(true, ui.onSaveQueueChanged)
 
 	// 加载现有记录
 	ui.loadRecords()
@@ -54,6 +94,9 @@ func (ui *WeightTrackerUI) MakeUI() fyne.CanvasObject {
 	// 创建输入区域
 	inputCard := ui.createInputCard()
 
+	// 保存失败提示
+	ui.saveStatus = ui.createSaveStatus()
+
 	// 创建记录列表
 	ui.createRecordList()
 
@@ -66,6 +109,7 @@ func (ui *WeightTrackerUI) MakeUI() fyne.CanvasObject {
 	// 组合布局
 	ui.mainContent = container.NewBorder(
 		container.NewVBox(
+			ui.saveStatus,
 			statsCard,
 			widget.NewSeparator(),
 			inputCard,
@@ -76,6 +120,9 @@ func (ui *WeightTrackerUI) MakeUI() fyne.CanvasObject {
 		ui.listContainer,
 	)
 
+	// 数据文件被外部修改后自动重新加载
+	ui.watchDataFile()
+
 	return ui.mainContent
 }
 
@@ -337,21 +384,9 @@ func (ui *WeightTrackerUI) addRecord() {
 		return
 	}
 
-	// 验证：检查是否为正数
-	if weight <= 0 {
-		dialog.ShowError(
-			errors.New("体重必须大于 0"),
-			ui.window,
-		)
-		return
-	}
-
-	// 验证：检查合理范围
-	if weight < 20 || weight > 300 {
-		dialog.ShowError(
-			errors.New("请输入合理的体重值 (20-300 kg)"),
-			ui.window,
-		)
+	// 验证：检查是否为正数且在合理范围内
+	if err := ValidateWeight(weight); err != nil {
+		dialog.ShowError(err, ui.window)
 		return
 	}
 
@@ -426,25 +461,70 @@ func (ui *WeightTrackerUI) animateStatsUpdate() {
 	ui.currentWeight.Refresh()
 }
 
-// loadRecords 从存储加载记录
+// loadRecords 从存储加载记录，失败时显示恢复对话框
 func (ui *WeightTrackerUI) loadRecords() {
+	if err := ui.tryLoadRecords(); err != nil {
+		ui.showLoadError(err)
+	}
+}
+
+// tryLoadRecords 从存储加载记录；失败时使用空列表，并在重新加载成功前阻止保存
+func (ui *WeightTrackerUI) tryLoadRecords() error {
+	ui.loadErr = nil
+
+	// 加密存储尚未解锁时等待解锁后再加载
+	if ui.Locked() {
+		ui.records = []WeightRecord{}
+		return nil
+	}
+
 	records, err := ui.storage.Load()
 	if err != nil {
-		// 如果加载失败，使用空列表
+		// 主文件损坏但已从备份恢复，提示用户后继续使用
+		var recovered *safe_file.RecoveredError
+		if errors.As(err, &recovered) {
+			ui.records = records
+			dialog.ShowInformation("⚠️ 数据已从备份恢复", recovered.Error(), ui.window)
+			return nil
+		}
+
 		ui.records = []WeightRecord{}
-		return
+		ui.loadErr = err
+		return err
 	}
 
 	ui.records = records
+	return nil
 }
 
-// saveRecords 保存记录到存储
+// saveRecords 保存记录到存储，失败时保留在队列中自动重试
 func (ui *WeightTrackerUI) saveRecords() {
-	err := ui.storage.Save(ui.records)
-	if err != nil {
-		dialog.ShowError(
-			errors.New("保存失败: "+err.Error()),
-			ui.window,
-		)
+	// 加载失败时不保存，避免用空数据覆盖原文件
+	if ui.loadErr != nil {
+		ui.showLoadError(ui.loadErr)
+		return
+	}
+
+	storage := ui.storage
+	records := append([]WeightRecord(nil), ui.records...)
+	alreadyPending, _ := ui.saveQueue.Pending()
+
+	err := ui.saveQueue.Save(func() error {
+		return storage.Save(records)
+	})
+	// 之前的保存仍在重试时，结果由 onSaveQueueChanged 处理
+	if err == nil || alreadyPending > 0 {
+		return
+	}
+
+	if errors.Is(err, file_watch.ErrChanged) {
+		ui.confirmOverwrite()
+		return
+	}
+
+	message := "保存失败: " + err.Error()
+	if storage_recovery.Retryable(err) {
+		message += "\n修改已保留，将在后台自动重试"
 	}
+	dialog.ShowError(errors.New(message), ui.window)
 }
@@ -0,0 +1,65 @@
+package weight_tracker
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"my_portfolio/file_watch"
+)
+
+// watchedStorage 可以发现数据文件外部修改的存储（JSON文件和加密文件）
+type watchedStorage interface {
+	Guard() *file_watch.Guard
+}
+
+// watchDataFile 监视数据文件，被其他程序修改或从备份恢复后重新加载并刷新界面；再次调用时先停止之前的监视
+func (ui *WeightTrackerUI) watchDataFile() {
+	ui.StopWatching()
+	if _, ok := ui.storage.(watchedStorage); !ok {
+		return
+	}
+
+	ui.stopWatch = file_watch.Watch(ui.storagePath, func() {
+		fyne.Do(ui.reloadIfChanged)
+	})
+}
+
+// StopWatching 停止监视数据文件，在窗口关闭时调用
+func (ui *WeightTrackerUI) StopWatching() {
+	if ui.stopWatch != nil {
+		ui.stopWatch()
+		ui.stopWatch = nil
+	}
+}
+
+// reloadIfChanged 数据文件内容与上次读写时不同时重新加载
+func (ui *WeightTrackerUI) reloadIfChanged() {
+	storage, ok := ui.storage.(watchedStorage)
+	if !ok || ui.Locked() || !storage.Guard().Changed() {
+		return
+	}
+
+	ui.loadRecords()
+	ui.refreshAll()
+}
+
+// confirmOverwrite 数据文件在读取后被其他程序修改时，询问覆盖还是重新加载
+func (ui *WeightTrackerUI) confirmOverwrite() {
+	message := widget.NewLabel("体重记录文件在本程序读取之后被其他程序修改。\n" +
+		"覆盖：用当前显示的记录覆盖文件中的内容\n" +
+		"重新加载：读取文件中的最新内容（本次修改将丢失）")
+
+	dialog.NewCustomConfirm("⚠️ 数据文件已被修改", "覆盖", "重新加载", message, func(overwrite bool) {
+		if !overwrite {
+			ui.loadRecords()
+			ui.refreshAll()
+			return
+		}
+
+		if storage, ok := ui.storage.(watchedStorage); ok {
+			storage.Guard().Forget()
+		}
+		ui.saveRecords()
+	}, ui.window).Show()
+}